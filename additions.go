@@ -0,0 +1,109 @@
+package apicompat
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// ReportAdditions returns an option that causes CheckAll to
+// report the fields and methods that have been added to
+// existing types in Report.Additions, alongside the added types
+// in Report.Added. Additions are compatible changes, so they
+// do not affect Report.OK, but they are useful for producing
+// release notes and for deciding whether a new release needs
+// a new minor version.
+func ReportAdditions() CheckOption {
+	return func(o *checkOptions) {
+		o.additions = true
+	}
+}
+
+// addition records a compatible addition found in the
+// types being checked when additions are being reported.
+// Unlike errorf, it ignores whether the rule is optional:
+// additions are only recorded when asked for, and then
+// only an explicit DisableRule suppresses them.
+func (ctxt *checkContext) addition(rule string, path Path, msg string, a ...interface{}) {
+	if !ctxt.additions || ctxt.stability == jsontypes.StabilityExperimental {
+		return
+	}
+	if on, ok := ctxt.rules[rule]; ok && !on {
+		return
+	}
+	p := &Problem{
+		Rule:          rule,
+		Severity:      Additive,
+		Path:          path,
+		Message:       fmt.Sprintf(msg, a...),
+		Old:           ctxt.type0,
+		New:           ctxt.type1,
+		formattedPath: ctxt.formatPath(path),
+	}
+	if ctxt.decls != nil {
+		p.OldDecl, p.NewDecl = ctxt.decls()
+	}
+	ctxt.added = append(ctxt.added, p)
+}
+
+// addedNames returns the sorted names in new, a map of
+// package-level declarations in the new API, that do not
+// correspond to any of the names in old.
+func (o *checkOptions) addedNames(old, new interface{}) []jsontypes.TypeName {
+	oldNames, newNames := mapKeys(old), mapKeys(new)
+	known := make(map[jsontypes.TypeName]bool)
+	for _, name := range oldNames {
+		known[name] = true
+		known[o.renamed(name)] = true
+	}
+	var added []jsontypes.TypeName
+	for _, name := range sortTypeNames(newNames) {
+		if !known[name] {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// mapKeys returns the keys of m, which must
+// be a map with jsontypes.TypeName keys.
+func mapKeys(m interface{}) []jsontypes.TypeName {
+	v := reflect.ValueOf(m)
+	names := make([]jsontypes.TypeName, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		names = append(names, jsontypes.TypeName(k.String()))
+	}
+	return names
+}
+
+// AdditionLines returns a description of each addition in
+// the report, in a form suitable for release notes: the
+// added types, functions, variables and constants first,
+// then the fields and methods added to existing types. The fields and methods are only included
+// when the report was produced with ReportAdditions.
+func (r *Report) AdditionLines() []string {
+	var lines []string
+	for _, name := range r.Added {
+		lines = append(lines, fmt.Sprintf("type %s has been added", name))
+	}
+	for _, name := range r.AddedFuncs {
+		lines = append(lines, fmt.Sprintf("function %s has been added", name))
+	}
+	for _, name := range r.AddedVars {
+		lines = append(lines, fmt.Sprintf("variable %s has been added", name))
+	}
+	for _, name := range r.AddedConsts {
+		lines = append(lines, fmt.Sprintf("constant %s has been added", name))
+	}
+	for _, tr := range r.Additions {
+		for _, err := range tr.Errors {
+			line := fmt.Sprintf("%s: %v", tr.Name, err)
+			if p, ok := err.(*Problem); ok {
+				line += formatDecls(p.OldDecl, p.NewDecl)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
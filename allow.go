@@ -0,0 +1,49 @@
+package apicompat
+
+import "github.com/rogpeppe/apicompat/jsontypes"
+
+// ProblemContext describes where a problem was found,
+// for deciding whether to allow it. See AllowFunc.
+type ProblemContext struct {
+	// TypeName holds the name of the innermost named
+	// type being checked, if any.
+	TypeName jsontypes.TypeName
+
+	// Role holds the role of the type being
+	// checked, if known (see jsontypes.Role).
+	Role jsontypes.Role
+
+	// Stability holds the stability of the item
+	// being checked, if known.
+	Stability jsontypes.Stability
+}
+
+// AllowFunc returns an option that causes any problem for which
+// allow returns true to be treated as compatible. When several
+// AllowFunc options are provided, a problem is allowed if any
+// of the functions allow it. See the policy package for
+// functions defined by CEL expressions.
+func AllowFunc(allow func(p *Problem, c *ProblemContext) bool) CheckOption {
+	return func(o *checkOptions) {
+		o.allowFuncs = append(o.allowFuncs, allow)
+	}
+}
+
+// allowed reports whether the problem p is allowed
+// by any of the functions passed to AllowFunc.
+func (ctxt *checkContext) allowed(p *Problem) bool {
+	if len(ctxt.allowFuncs) == 0 {
+		return false
+	}
+	c := &ProblemContext{
+		TypeName:  ctxt.typeName,
+		Role:      ctxt.role,
+		Stability: ctxt.stability,
+	}
+	for _, allow := range ctxt.allowFuncs {
+		if allow(p, c) {
+			return true
+		}
+	}
+	return false
+}
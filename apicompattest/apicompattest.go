@@ -0,0 +1,61 @@
+// Package apicompattest provides helpers for checking API
+// compatibility from Go tests. It's separate from package
+// apicompat so that programs using that package don't
+// depend on package testing.
+package apicompattest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// RequireCompatible checks that the types of the values in
+// newValues are backwardly compatible with the types of the
+// corresponding values in oldValues and fails the test if not.
+//
+// This makes it possible to keep copies of old type
+// definitions, (for example in a compat_test package)
+// and check that the current types still work with them:
+//
+//	apicompattest.RequireCompatible(t,
+//		[]interface{}{oldtypes.Params{}, oldtypes.Result{}},
+//		[]interface{}{api.Params{}, api.Result{}},
+//	)
+//
+// The types are compared pairwise, so the names of
+// the old and new types need not match. The values
+// must not be nil; to check an interface type, pass
+// a pointer to it, such as (*io.Reader)(nil).
+func RequireCompatible(t testing.TB, oldValues, newValues []interface{}, opts ...apicompat.CheckOption) {
+	t.Helper()
+	if len(oldValues) != len(newValues) {
+		t.Fatalf("mismatched value count; %d old vs %d new", len(oldValues), len(newValues))
+	}
+	for i := range oldValues {
+		if oldValues[i] == nil || newValues[i] == nil {
+			t.Fatalf("nil value at index %d; use a typed value such as a pointer instead", i)
+		}
+	}
+	info0, info1 := jsontypes.NewInfo(), jsontypes.NewInfo()
+	var buf bytes.Buffer
+	for i := range oldValues {
+		t0 := info0.TypeInfo(reflect.TypeOf(oldValues[i]))
+		t1 := info1.TypeInfo(reflect.TypeOf(newValues[i]))
+		err := apicompat.Check(info0, info1, t0, t1, nil, opts...)
+		if err == nil {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n%s is not compatible with %s:", jsontypes.Format(info1, t1), jsontypes.Format(info0, t0))
+		for _, err := range err.(*apicompat.CheckError).Errors {
+			fmt.Fprintf(&buf, "\n\t%v", err)
+		}
+	}
+	if buf.Len() > 0 {
+		t.Fatalf("incompatible types found:%s", buf.String())
+	}
+}
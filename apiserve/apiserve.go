@@ -0,0 +1,38 @@
+// Package apiserve serves an API snapshot embedded in a program,
+// so that a running service can advertise the API it implements
+// and apicompat can check it directly. The code that embeds the
+// snapshot is usually generated by "apicompat extract -embed".
+package apiserve
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Path holds the path under which Register serves the snapshot.
+const Path = "/debug/api"
+
+// Handler returns a handler that serves the given
+// API snapshot, which should be encoded as JSON.
+func Handler(snapshot []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" && req.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(snapshot)))
+		if req.Method == "HEAD" {
+			return
+		}
+		w.Write(snapshot)
+	})
+}
+
+// Register registers a handler for the given snapshot
+// on http.DefaultServeMux at Path, in the same way that
+// net/http/pprof and expvar register their handlers.
+func Register(snapshot []byte) {
+	http.Handle(Path, Handler(snapshot))
+}
@@ -0,0 +1,77 @@
+package apicompat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// VersionBump describes which part of a semantic
+// version should be incremented for a new release.
+type VersionBump string
+
+const (
+	// MajorBump is needed for incompatible changes.
+	MajorBump VersionBump = "major"
+
+	// MinorBump is needed for compatible additions.
+	MinorBump VersionBump = "minor"
+
+	// PatchBump is enough when the API is unchanged.
+	PatchBump VersionBump = "patch"
+)
+
+// SuggestedBump returns the version bump suggested by the
+// changes in the report: major if there are any breaking
+// changes, minor if there are warnings or additions, and
+// patch otherwise. Added fields and methods are only taken
+// into account when the report was produced with
+// ReportAdditions.
+func (r *Report) SuggestedBump() VersionBump {
+	counts := r.CountBySeverity()
+	switch {
+	case counts[Breaking] > 0:
+		return MajorBump
+	case counts[Warning] > 0 || counts[Additive] > 0:
+		return MinorBump
+	}
+	return PatchBump
+}
+
+// NextVersion returns the version that follows the given
+// semantic version with the given bump, for example v1.3.0
+// for v1.2.3 with MinorBump. Any prerelease or build suffix
+// is dropped. Following semver conventions for unstable
+// versions, a major bump of a v0 version increments the
+// minor version only.
+func NextVersion(version string, bump VersionBump) (string, error) {
+	if !semver.IsValid(version) {
+		return "", fmt.Errorf("invalid semantic version %q", version)
+	}
+	v := strings.TrimPrefix(semver.Canonical(version), "v")
+	v = strings.TrimSuffix(v, semver.Prerelease(version))
+	var n [3]int
+	for i, s := range strings.SplitN(v, ".", 3) {
+		x, err := strconv.Atoi(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid semantic version %q", version)
+		}
+		n[i] = x
+	}
+	if bump == MajorBump && n[0] == 0 {
+		bump = MinorBump
+	}
+	switch bump {
+	case MajorBump:
+		n = [3]int{n[0] + 1, 0, 0}
+	case MinorBump:
+		n = [3]int{n[0], n[1] + 1, 0}
+	case PatchBump:
+		n[2]++
+	default:
+		return "", fmt.Errorf("unknown version bump %q", bump)
+	}
+	return fmt.Sprintf("v%d.%d.%d", n[0], n[1], n[2]), nil
+}
@@ -1,79 +1,94 @@
+// The apicompat command checks API compatibility
+// between snapshots of Go types.
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"github.com/rogpeppe/apicompat"
-	"github.com/rogpeppe/apicompat/jsontypes"
-	"io/ioutil"
 	"log"
+	"os"
 )
 
-func main() {
-	flag.Parse()
-	if flag.NArg() != 2 {
-		log.Fatal("usage: check api_old.json api_new.json")
-	}
-	info0, err := readInfo(flag.Arg(0))
-	if err != nil {
-		log.Fatal(err)
-	}
-	info1, err := readInfo(flag.Arg(1))
-	if err != nil {
-		log.Fatal(err)
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		checkCommand,
+		ciCommand,
+		extractCommand,
+		graphCommand,
+		grepCommand,
+		keygenCommand,
+		lintCommand,
+		modulesCommand,
+		protoCommand,
+		pruneCommand,
+		reduceCommand,
+		rulesCommand,
+		saveCommand,
+		verifyCommand,
 	}
-	for _, t0 := range info0.Types {
-		t1, ok := info1.Types[t0.Name]
-		if !ok {
-			fmt.Printf("type %s has gone away\n", t0.Name)
-			continue
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("apicompat: ")
+	args := os.Args[1:]
+	cmd := checkCommand
+	if len(args) > 0 {
+		if c := lookupCommand(args[0]); c != nil {
+			cmd, args = c, args[1:]
+		} else if args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+			usage()
+			os.Exit(0)
 		}
-		err := apicompat.Check(info0, info1, t0, t1, customMarshaler)
-		if err != nil {
-			err := err.(*apicompat.CheckError)
-			for _, err := range err.Errors {
-				fmt.Printf("%s incompatible: %v\n", t0.Name, err)
-			}
+	}
+	if err := cmd.run(args); err != nil {
+		if err != flag.ErrHelp {
+			log.Print(err)
 		}
+		os.Exit(2)
 	}
 }
 
-func readInfo(f string) (*jsontypes.Info, error) {
-	data, err := ioutil.ReadFile(f)
-	if err != nil {
-		return nil, err
-	}
-	var info *jsontypes.Info
-	if err := json.Unmarshal(data, &info); err != nil {
-		return nil, err
-	}
-	// Remove all non-marshaling-related methods
-	// because they're irrelevant to our compatiblity.
-	apicompat.PruneMethods(info, func(t *jsontypes.Type, m *jsontypes.Method) bool {
-		for _, name := range marshalMethodNames {
-			if m.Name == name {
-				return true
-			}
+func lookupCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
 		}
-		return false
-	})
-	return info, nil
+	}
+	return nil
 }
 
-var marshalMethodNames = []string{
-	"MarshalJSON",
-	"UnmarshalJSON",
-	"MarshalText",
-	"UnmarshalText",
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: apicompat <command> [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nIf no command is given, check is assumed.\n")
 }
 
-func customMarshaler(info *jsontypes.Info, t *jsontypes.Type) bool {
-	for _, name := range marshalMethodNames {
-		if t.Methods[name] != nil {
-			// TODO check sig too
-			return true
-		}
+// newFlagSet returns a flag set for the given command
+// with a usage message that mentions the command's
+// arguments.
+func newFlagSet(c *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: apicompat %s [flags] %s\n\n%s\n", c.name, c.args, c.summary)
+		fs.PrintDefaults()
 	}
-	return false
+	return fs
+}
+
+// usageError returns an error reporting incorrect
+// usage of the given command.
+func usageError(c *command) error {
+	return fmt.Errorf("usage: apicompat %s [flags] %s", c.name, c.args)
 }
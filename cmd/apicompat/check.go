@@ -0,0 +1,627 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+	"github.com/rogpeppe/apicompat/policy"
+)
+
+var checkCommand = &command{
+	name:    "check",
+	args:    "[api_old.json api_new.json | [package...] [@rev]]",
+	summary: "check that a new API is backwardly compatible with an old one",
+}
+
+func init() {
+	checkCommand.run = runCheck
+}
+
+func runCheck(args []string) (err error) {
+	fs := newFlagSet(checkCommand)
+	inferRoles := fs.Bool("infer-roles", false, "infer type roles (request, response, etc) from type names")
+	verbose := fs.Bool("v", false, "log progress to stderr")
+	ignoreConvention := fs.Bool("ignore-naming-convention", false, "match names that differ only in convention, such as userId and user_id")
+	since := fs.String("since", "", "check compatibility relative to `version`, ignoring old items marked as introduced later")
+	lenientNumbers := fs.Bool("lenient-numbers", false, "allow numeric types to be widened")
+	lenientFuncs := fs.Bool("lenient-funcs", false, "check function parameters contravariantly and results covariantly")
+	suggest := fs.Bool("suggest", false, "show suggested remediations")
+	against := fs.String("against", "", "check packages against `version` of the module published on the module proxy, or latest for the latest release")
+	verifyKey := fs.String("verify-key", "", "require the old snapshot to be signed with the public key in `file` (see keygen)")
+	exemptionsFile := fs.String("exemptions", "", "do not report the problems listed in the JSON `file` unless their exemptions have expired")
+	webhook := fs.String("webhook", "", "post a summary of any incompatibilities to `url`")
+	reportURL := fs.String("report-url", "", "link to the full report at `url` in webhook notifications")
+	showImpact := fs.Bool("impact", false, "show the root types affected by each changed type")
+	metricsFile := fs.String("metrics", "", "write counts of changes by severity to `file` in OpenMetrics format")
+	pushgateway := fs.String("pushgateway", "", "push counts of changes by severity to the Prometheus Pushgateway at `url`")
+	groupBy := fs.String("group-by", "", "group changes by `package`, rule or type")
+	ownersFile := fs.String("owners", "", "group changes by the owners listed in `file`")
+	ownerDir := fs.String("owner-dir", "", "with -owners, also write the changes for each owner to a file in `dir`")
+	format := fs.String("format", "text", "output `format` (text, json or sarif)")
+	pathFormat := fs.String("path-format", "go", "format of paths in messages (go, json or dotted)")
+	bump := fs.Bool("bump", false, "suggest a version bump for the changes and, if the old version is known, the next version")
+	additions := fs.Bool("additions", false, "also show the types, fields and methods that have been added")
+	failOn := fs.String("fail-on", "", "exit with a non-zero status if there are changes of `severity` (breaking, warning or additive) or more serious")
+	baselineFile := fs.String("baseline", "", "do not report the problems listed in the JSON `file`, as written by -accept")
+	accept := fs.Bool("accept", false, "write all the problems currently reported to the -baseline file, so that they are not reported again")
+	profile := fs.String("profile", "", "check with the rules for compatibility `profile` ("+strings.Join(apicompat.ProfileNames(), ", ")+")")
+	configFile := fs.String("config", "", "read configuration from `file` instead of "+configFileName+" at the repository root")
+	var roots []jsontypes.TypeName
+	fs.Func("root", "check only the types reachable from `type` (may be repeated)", func(s string) error {
+		roots = append(roots, jsontypes.TypeName(s))
+		return nil
+	})
+	var renames []apicompat.CheckOption
+	fs.Func("rename", "declare that packages have moved, as `old=new` (may be repeated)", func(s string) error {
+		oldPath, newPath, ok := strings.Cut(s, "=")
+		if !ok || oldPath == "" || newPath == "" {
+			return fmt.Errorf("rename must be of the form old=new")
+		}
+		renames = append(renames, apicompat.PackageRename(oldPath, newPath))
+		return nil
+	})
+	var plugins [][]string
+	fs.Func("plugin", "run the custom rules implemented by `command` (may be repeated)", func(s string) error {
+		argv := strings.Fields(s)
+		if len(argv) == 0 {
+			return fmt.Errorf("empty plugin command")
+		}
+		plugins = append(plugins, argv)
+		return nil
+	})
+	var opaque []string
+	fs.Func("opaque", "compare types matching `pattern` by name only, instead of ignoring all types with custom marshalers (may be repeated)", func(s string) error {
+		opaque = append(opaque, s)
+		return nil
+	})
+	var ignore []string
+	fs.Func("ignore", "do not check types matching `pattern`, such as example.com/foo#Internal* (may be repeated)", func(s string) error {
+		if _, err := path.Match(s, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %v", s, err)
+		}
+		ignore = append(ignore, s)
+		return nil
+	})
+	var tags, ignoreTags []string
+	fs.Func("tag", "compare only the struct tags with the given `key`, such as json (may be repeated)", func(s string) error {
+		tags = append(tags, s)
+		return nil
+	})
+	fs.Func("ignore-tag", "ignore changes to the struct tags with the given `key`, such as validate (may be repeated)", func(s string) error {
+		ignoreTags = append(ignoreTags, s)
+		return nil
+	})
+	var policies []apicompat.CheckOption
+	fs.Func("allow-if", "allow any problem for which the CEL `expression` is true (may be repeated)", func(s string) error {
+		p, err := policy.Parse(s)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, policy.AllowIf(p))
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	conf, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if conf.Format != "" && !flagWasSet(fs, "format") {
+		*format = conf.Format
+	}
+	if *profile != "" {
+		conf.Profile = *profile
+	}
+	formatPath, ok := pathFormatters[*pathFormat]
+	if !ok {
+		return fmt.Errorf("unknown path format %q", *pathFormat)
+	}
+	switch *format {
+	case "text":
+	case "json", "sarif":
+		if *ownersFile != "" || *groupBy != "" {
+			return fmt.Errorf("cannot group changes with -format %s", *format)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if *accept && *baselineFile == "" {
+		return fmt.Errorf("-accept requires -baseline")
+	}
+	var failSeverity apicompat.Severity
+	if *failOn != "" {
+		s, err := apicompat.ParseSeverity(*failOn)
+		if err != nil {
+			return err
+		}
+		failSeverity = s
+	}
+	var key ed25519.PublicKey
+	if *verifyKey != "" {
+		k, err := readPublicKey(*verifyKey)
+		if err != nil {
+			return err
+		}
+		key = k
+	}
+	cfg := &srcload.Config{
+		// SARIF results refer to source positions.
+		Positions: *format == "sarif",
+	}
+	args = fs.Args()
+	if !hasPatterns(args) {
+		args = append(conf.Packages, args...)
+	}
+	info0, info1, err := loadCheckInfos(cfg, args, key, *against)
+	if err != nil {
+		return err
+	}
+	if !conf.sourceLevel() {
+		// Remove all non-marshaling-related methods
+		// because they're irrelevant to compatibility.
+		apicompat.PruneMethods(info0, conf.isMarshalMethod)
+		apicompat.PruneMethods(info1, conf.isMarshalMethod)
+	}
+	if *inferRoles {
+		info0.InferRoles(nil)
+		info1.InferRoles(nil)
+	}
+	opts, err := conf.options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, apicompat.FormatPath(formatPath))
+	switch {
+	case len(opaque) > 0:
+		opts = append(opts, apicompat.Opaque(opaque...))
+	case !conf.sourceLevel():
+		opts = append(opts, apicompat.MarshalMethods(conf.MarshalMethods...))
+	}
+	if len(ignore) > 0 {
+		opts = append(opts, apicompat.IgnoreTypes(ignore...))
+	}
+	if len(tags) > 0 {
+		opts = append(opts, apicompat.CheckTags(tags...))
+	}
+	if len(ignoreTags) > 0 {
+		opts = append(opts, apicompat.IgnoreTags(ignoreTags...))
+	}
+	opts = append(opts, renames...)
+	opts = append(opts, policies...)
+	if len(roots) > 0 {
+		opts = append(opts, apicompat.Roots(roots...))
+	}
+	if *ignoreConvention {
+		opts = append(opts, apicompat.MatchNames(apicompat.NormalizeCase))
+	}
+	if *since != "" {
+		opts = append(opts, apicompat.SinceVersion(*since))
+	}
+	if *lenientNumbers {
+		opts = append(opts, apicompat.LenientNumbers())
+	}
+	if *lenientFuncs {
+		opts = append(opts, apicompat.LenientFuncs())
+	}
+	if *additions || *bump {
+		opts = append(opts, apicompat.ReportAdditions())
+	}
+	if *verbose {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		opts = append(opts, apicompat.ReportProgress(jsontypes.SlogProgress(logger, slog.LevelInfo)))
+	}
+	r := apicompat.CheckAll(info0, info1, opts...)
+	for _, argv := range plugins {
+		if err := apicompat.CheckPlugin(r, info0, info1, argv); err != nil {
+			return err
+		}
+	}
+	if *exemptionsFile != "" {
+		data, err := ioutil.ReadFile(*exemptionsFile)
+		if err != nil {
+			return err
+		}
+		exemptions, err := apicompat.ParseExemptions(data)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", *exemptionsFile, err)
+		}
+		r.Exempt(exemptions, time.Now())
+	}
+	if *baselineFile != "" {
+		if err := applyBaseline(r, *baselineFile, *accept); err != nil {
+			return err
+		}
+	}
+	if info0.Meta != nil || info1.Meta != nil {
+		fmt.Fprintf(os.Stderr, "old: %s\nnew: %s\n", info0.Meta.Describe(), info1.Meta.Describe())
+	}
+	for _, w := range r.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if *metricsFile != "" || *pushgateway != "" {
+		data := formatMetrics(r, time.Now())
+		if *metricsFile != "" {
+			if err := writeMetricsFile(*metricsFile, data); err != nil {
+				return err
+			}
+		}
+		if *pushgateway != "" {
+			if err := pushMetrics(*pushgateway, data); err != nil {
+				return err
+			}
+		}
+	}
+	if *webhook != "" && !r.OK() {
+		if err := postWebhook(*webhook, r, *reportURL); err != nil {
+			return err
+		}
+	}
+	if failSeverity != "" {
+		// Check after the changes have been printed.
+		defer func() {
+			if n := r.CountAtLeast(failSeverity); n > 0 && err == nil {
+				err = fmt.Errorf("%d changes of severity %s or more serious", n, failSeverity)
+			}
+		}()
+	}
+	if *format != "text" {
+		var v interface{} = apicompat.NewJSONReport(r)
+		if *format == "sarif" {
+			v = newSARIFLog(r, info0, info1)
+		}
+		data, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(append(data, '\n'))
+		return nil
+	}
+	if *bump {
+		defer func() {
+			b := r.SuggestedBump()
+			fmt.Printf("\nsuggested version bump: %s\n", b)
+			if r.Old != nil && r.Old.Version != "" {
+				if next, err := apicompat.NextVersion(r.Old.Version, b); err == nil {
+					fmt.Printf("next version: %s\n", next)
+				}
+			}
+		}()
+	}
+	if *additions {
+		defer func() {
+			if lines := r.AdditionLines(); len(lines) > 0 {
+				fmt.Println()
+				for _, line := range lines {
+					fmt.Println(line)
+				}
+			}
+		}()
+	}
+	if *showImpact {
+		defer func() {
+			if lines := r.ImpactSummary(); len(lines) > 0 {
+				fmt.Println()
+				for _, line := range lines {
+					fmt.Println(line)
+				}
+			}
+		}()
+	}
+	if *ownersFile != "" {
+		data, err := ioutil.ReadFile(*ownersFile)
+		if err != nil {
+			return err
+		}
+		owners, err := apicompat.ParseOwners(data)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", *ownersFile, err)
+		}
+		groups := r.ChangesByOwner(owners, *suggest)
+		if *ownerDir != "" {
+			if err := writeOwnerFiles(*ownerDir, groups); err != nil {
+				return err
+			}
+		}
+		printGroups("owner", groups)
+		return nil
+	}
+	if *groupBy != "" {
+		groups, err := r.GroupedChanges(apicompat.GroupBy(*groupBy), *suggest)
+		if err != nil {
+			return err
+		}
+		printGroups(*groupBy, groups)
+		return nil
+	}
+	changes := r.Changes()
+	if *suggest {
+		changes = r.ChangesWithSuggestions()
+	}
+	for _, line := range changes {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// applyBaseline removes from r the problems listed in the given
+// baseline file, which need not exist. If accept is true, it
+// first replaces the baseline with all the problems in r,
+// keeping the reasons given for any that were already listed.
+func applyBaseline(r *apicompat.Report, file string, accept bool) error {
+	var baseline []*apicompat.Exemption
+	data, err := ioutil.ReadFile(file)
+	switch {
+	case err == nil:
+		baseline, err = apicompat.ParseExemptions(data)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", file, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if accept {
+		reasons := make(map[apicompat.Exemption]string)
+		for _, e := range baseline {
+			reasons[apicompat.Exemption{Type: e.Type, Rule: e.Rule, Path: e.Path}] = e.Reason
+		}
+		baseline = r.Baseline()
+		for _, e := range baseline {
+			e.Reason = reasons[*e]
+		}
+		data, err := json.MarshalIndent(baseline, "", "\t")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, append(data, '\n'), 0666); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "accepted %d problems in %s\n", len(baseline), file)
+	}
+	r.Exempt(baseline, time.Now())
+	return nil
+}
+
+// flagWasSet reports whether the named flag
+// was set on the command line.
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// hasPatterns reports whether the command line arguments
+// include any snapshots or package patterns, as opposed
+// to only a revision.
+func hasPatterns(args []string) bool {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// printGroups prints the given groups of changes,
+// each headed by its key.
+func printGroups(by string, groups []apicompat.ChangeGroup) {
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		key := g.Key
+		if key == "" {
+			key = "(other)"
+		}
+		fmt.Printf("%s %s:\n", by, key)
+		for _, line := range g.Changes {
+			fmt.Println(line)
+		}
+	}
+}
+
+// writeOwnerFiles writes the changes in each group to a file
+// in dir named after the owner, or unowned.txt for changes
+// that have no owner.
+func writeOwnerFiles(dir string, groups []apicompat.ChangeGroup) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for _, g := range groups {
+		name := "unowned"
+		if g.Key != "" {
+			name = strings.NewReplacer("@", "", "/", "-").Replace(g.Key)
+		}
+		data := strings.Join(g.Changes, "\n") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".txt"), []byte(data), 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCheckInfos returns the old and new APIs to be checked,
+// given the command line arguments. When the arguments are two
+// JSON snapshot files, they are read; either may instead be the
+// URL of a running service that serves its snapshot (see the
+// apiserve package), such as http://host/debug/api. Otherwise
+// the arguments are taken as package patterns (./... by default),
+// and the packages in the working tree are compared against the
+// same packages at the git revision given by an argument of the
+// form @rev (for example @v1.2.3 or @HEAD~1), or at the most
+// recent release tag if there is none. If against is non-empty,
+// they are compared against that version of the module as
+// published on the module proxy instead (see extractPublished).
+// If key is non-nil, the old snapshot must have been signed
+// with it. The packages are loaded with the given configuration.
+func loadCheckInfos(cfg *srcload.Config, args []string, key ed25519.PublicKey, against string) (info0, info1 *jsontypes.Info, err error) {
+	if len(args) == 2 && isSnapshotArg(args[0]) && isSnapshotArg(args[1]) {
+		if against != "" {
+			return nil, nil, fmt.Errorf("-against cannot be used when checking snapshot files")
+		}
+		info0, err := readSnapshot(args[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		if key != nil {
+			if err := info0.Verify(key); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", args[0], err)
+			}
+		}
+		info1, err := readSnapshot(args[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return info0, info1, nil
+	}
+	for _, arg := range args {
+		if isSnapshotArg(arg) {
+			return nil, nil, usageError(checkCommand)
+		}
+	}
+	if key != nil {
+		return nil, nil, fmt.Errorf("-verify-key can only be used when checking snapshot files")
+	}
+	var base string
+	patterns := args[:0:0]
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			patterns = append(patterns, arg)
+			continue
+		}
+		if base != "" || arg == "@" {
+			return nil, nil, usageError(checkCommand)
+		}
+		base = arg[1:]
+	}
+	args = patterns
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	switch {
+	case against != "":
+		if base != "" {
+			return nil, nil, fmt.Errorf("cannot use -against with @%s", base)
+		}
+		var version string
+		info0, version, err = extractPublished(cfg, against, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(os.Stderr, "checking against published %s\n", version)
+		base = version
+	default:
+		if base == "" {
+			base, err = latestTag(".")
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot determine baseline: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "checking against %s\n", base)
+		}
+		info0, err = extractAtRevision(cfg, base, args)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if v := path.Base(base); semver.IsValid(v) {
+		// Record the version so that it's mentioned in
+		// changes and can be used to suggest the next one.
+		if info0.Meta == nil {
+			info0.Meta = &jsontypes.Meta{}
+		}
+		if info0.Meta.Version == "" {
+			info0.Meta.Version = v
+		}
+	}
+	info1, err = srcload.Load(cfg, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info0, info1, nil
+}
+
+var pathFormatters = map[string]apicompat.PathFormatter{
+	"go":     apicompat.GoPath,
+	"json":   apicompat.JSONPointerPath,
+	"dotted": apicompat.DottedPath,
+}
+
+func readInfo(f string) (*jsontypes.Info, error) {
+	info, err := readSnapshot(f)
+	if err != nil {
+		return nil, err
+	}
+	// Remove all non-marshaling-related methods
+	// because they're irrelevant to our compatiblity.
+	apicompat.PruneMethods(info, apicompat.IsMarshalMethod)
+	return info, nil
+}
+
+// isSnapshotArg reports whether the command line
+// argument refers to a snapshot rather than a package.
+func isSnapshotArg(arg string) bool {
+	return strings.HasSuffix(arg, ".json") || isURL(arg)
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// readSnapshot reads the API snapshot in the given file,
+// or fetches it if f is an HTTP URL.
+// If the snapshot has been sealed, it checks that it has
+// not been modified since.
+func readSnapshot(f string) (*jsontypes.Info, error) {
+	var data []byte
+	var err error
+	if isURL(f) {
+		data, err = fetchSnapshot(f)
+	} else {
+		data, err = ioutil.ReadFile(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var info *jsontypes.Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	if info.Integrity != nil {
+		if err := info.Verify(nil); err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+	}
+	return info, nil
+}
+
+// fetchSnapshot returns the snapshot served at the given URL.
+func fetchSnapshot(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot fetch %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
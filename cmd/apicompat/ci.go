@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+var ciCommand = &command{
+	name:    "ci",
+	args:    "[package...]",
+	summary: "check the working tree against its baseline, configured from the CI environment",
+}
+
+func init() {
+	ciCommand.run = runCI
+}
+
+// ciEnv describes a continuous integration environment.
+type ciEnv struct {
+	// name holds the name of the environment.
+	name string
+
+	// base holds the git revision to compare against,
+	// if known from the environment.
+	base string
+
+	// annotate formats a line of the report for
+	// display in the CI system.
+	annotate func(line string) string
+}
+
+// detectCI returns the CI environment that
+// the command is running in.
+func detectCI() *ciEnv {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		env := &ciEnv{
+			name:     "github",
+			annotate: githubAnnotation,
+		}
+		if ref := os.Getenv("GITHUB_BASE_REF"); ref != "" {
+			env.base = "origin/" + ref
+		}
+		return env
+	case os.Getenv("GITLAB_CI") == "true":
+		env := &ciEnv{
+			name:     "gitlab",
+			annotate: plainAnnotation,
+		}
+		if ref := os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"); ref != "" {
+			env.base = "origin/" + ref
+		}
+		return env
+	}
+	return &ciEnv{
+		name:     "local",
+		annotate: plainAnnotation,
+	}
+}
+
+// githubAnnotation formats line as a GitHub Actions
+// error annotation.
+func githubAnnotation(line string) string {
+	line = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(line)
+	return "::error title=API incompatibility::" + line
+}
+
+func plainAnnotation(line string) string {
+	return line
+}
+
+// runCI compares the packages in the working tree (./... by
+// default) against the base branch of the merge request
+// being built or, failing that, against the most recent tag,
+// and fails if there are any incompatibilities.
+func runCI(args []string) error {
+	fs := newFlagSet(ciCommand)
+	base := fs.String("base", "", "compare against git `revision` rather than detecting it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	env := detectCI()
+	if *base != "" {
+		env.base = *base
+	}
+	if env.base == "" {
+		tag, err := git("describe", "--tags", "--abbrev=0")
+		if err != nil {
+			return fmt.Errorf("cannot determine baseline: %v", err)
+		}
+		env.base = tag
+	}
+	fmt.Fprintf(os.Stderr, "checking against %s (%s environment)\n", env.base, env.name)
+	cfg := &srcload.Config{}
+	info0, err := extractAtRevision(cfg, env.base, patterns)
+	if err != nil {
+		return err
+	}
+	info1, err := srcload.Load(cfg, patterns...)
+	if err != nil {
+		return err
+	}
+	apicompat.PruneMethods(info0, apicompat.IsMarshalMethod)
+	apicompat.PruneMethods(info1, apicompat.IsMarshalMethod)
+	r := apicompat.CheckAll(info0, info1, apicompat.Ignore(apicompat.HasCustomMarshaler))
+	for _, line := range r.ChangesWithSuggestions() {
+		fmt.Println(env.annotate(line))
+	}
+	if !r.OK() {
+		return fmt.Errorf("API is not backwardly compatible with %s", env.base)
+	}
+	return nil
+}
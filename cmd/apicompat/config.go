@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/policy"
+)
+
+// configFileName holds the name of the configuration file
+// read by check from the root of the repository.
+const configFileName = ".apicompat.yaml"
+
+// config holds the contents of a configuration file,
+// which lets a repository record how its API is checked
+// rather than repeating flags in every CI job. For example:
+//
+//	packages:
+//	  - ./api/...
+//	ignore:
+//	  - example.com/m/internal/*#*
+//	allow-if:
+//	  - path.startsWith(".Debug")
+//	rules:
+//	  tag-changed: off
+//	  field-added: warning
+//	marshal-methods:
+//	  - MarshalYAML
+//	format: sarif
+//	profile: json-wire
+//	tags:
+//	  ignore: [validate, gorm]
+type config struct {
+	// Packages holds the package patterns to check
+	// when none are given on the command line.
+	Packages []string `yaml:"packages"`
+
+	// Ignore holds patterns matching the names of types
+	// to ignore, as for -ignore.
+	Ignore []string `yaml:"ignore"`
+
+	// AllowIf holds CEL expressions, as for -allow-if,
+	// which can match problems by path.
+	AllowIf []string `yaml:"allow-if"`
+
+	// Rules holds the setting for each rule: "on" or
+	// "off" to enable or disable it, or a severity
+	// to enable it with that severity.
+	Rules map[string]string `yaml:"rules"`
+
+	// MarshalMethods holds the names of methods that
+	// affect marshaling, in addition to those in
+	// apicompat.MarshalMethodNames.
+	MarshalMethods []string `yaml:"marshal-methods"`
+
+	// Format holds the output format used when
+	// -format is not given.
+	Format string `yaml:"format"`
+
+	// Profile holds the compatibility profile used
+	// when -profile is not given.
+	Profile string `yaml:"profile"`
+
+	// Tags holds which struct tags are compared.
+	Tags tagsConfig `yaml:"tags"`
+}
+
+// tagsConfig holds the struct tag keys to compare
+// in a configuration file.
+type tagsConfig struct {
+	// Check holds the keys of the only tags to
+	// compare, as for -tag.
+	Check []string `yaml:"check"`
+
+	// Ignore holds the keys of tags to ignore,
+	// as for -ignore-tag.
+	Ignore []string `yaml:"ignore"`
+}
+
+// loadConfig reads the configuration in the given file. If file
+// is empty, it reads .apicompat.yaml from the root of the git
+// repository containing the current directory, or from the
+// current directory if it's not in one, and returns an empty
+// configuration if that doesn't exist.
+func loadConfig(file string) (*config, error) {
+	if file == "" {
+		dir, err := git("rev-parse", "--show-toplevel")
+		if err != nil {
+			dir = "."
+		}
+		file = filepath.Join(dir, configFileName)
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return &config{}, nil
+		}
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", file, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return &cfg, nil
+}
+
+// validate checks that the patterns and rule
+// settings in cfg are well formed.
+func (cfg *config) validate() error {
+	for _, pattern := range cfg.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad ignore pattern %q: %v", pattern, err)
+		}
+	}
+	if cfg.Profile != "" {
+		if _, err := apicompat.Profile(cfg.Profile); err != nil {
+			return err
+		}
+	}
+	for id, setting := range cfg.Rules {
+		if apicompat.LookupRule(id) == nil {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		switch setting {
+		case "on", "off":
+		default:
+			if _, err := apicompat.ParseSeverity(setting); err != nil {
+				return fmt.Errorf("bad setting for rule %q: %v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sourceLevel reports whether cfg selects the go-source profile,
+// in which case all methods are checked, and types with custom
+// marshalers are checked as usual rather than ignored.
+func (cfg *config) sourceLevel() bool {
+	return cfg.Profile == "go-source"
+}
+
+// isMarshalMethod reports whether m affects marshaling: that is,
+// whether it's named in apicompat.MarshalMethodNames or
+// in cfg.MarshalMethods.
+func (cfg *config) isMarshalMethod(t *jsontypes.Type, m *jsontypes.Method) bool {
+	for _, name := range cfg.MarshalMethods {
+		if m.Name == name {
+			return true
+		}
+	}
+	return apicompat.IsMarshalMethod(t, m)
+}
+
+// options returns the check options corresponding to cfg.
+// The profile comes first, so that the rule settings
+// take precedence over it.
+func (cfg *config) options() ([]apicompat.CheckOption, error) {
+	var opts []apicompat.CheckOption
+	if cfg.Profile != "" {
+		opt, err := apicompat.Profile(cfg.Profile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	if len(cfg.Ignore) > 0 {
+		opts = append(opts, apicompat.IgnoreTypes(cfg.Ignore...))
+	}
+	if len(cfg.Tags.Check) > 0 {
+		opts = append(opts, apicompat.CheckTags(cfg.Tags.Check...))
+	}
+	if len(cfg.Tags.Ignore) > 0 {
+		opts = append(opts, apicompat.IgnoreTags(cfg.Tags.Ignore...))
+	}
+	for _, expr := range cfg.AllowIf {
+		p, err := policy.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, policy.AllowIf(p))
+	}
+	ids := make([]string, 0, len(cfg.Rules))
+	for id := range cfg.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		switch setting := cfg.Rules[id]; setting {
+		case "on":
+			opts = append(opts, apicompat.EnableRule(id))
+		case "off":
+			opts = append(opts, apicompat.DisableRule(id))
+		default:
+			s, _ := apicompat.ParseSeverity(setting)
+			opts = append(opts, apicompat.EnableRule(id), apicompat.SetSeverity(id, s))
+		}
+	}
+	return opts, nil
+}
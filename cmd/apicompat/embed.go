@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// writeEmbedFile writes a Go source file to goFile that embeds the
+// snapshot in snapshotFile, which must be in the same directory
+// or below, and registers it with apiserve.Register so that the
+// program serves it.
+func writeEmbedFile(goFile, snapshotFile string) error {
+	dir := filepath.Dir(goFile)
+	rel, err := filepath.Rel(dir, snapshotFile)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("snapshot %s must be in the same directory as %s or below", snapshotFile, goFile)
+	}
+	pkg, err := packageName(dir, goFile)
+	if err != nil {
+		return err
+	}
+	src := fmt.Sprintf(`// Code generated by apicompat; DO NOT EDIT.
+
+package %s
+
+import (
+	_ "embed"
+
+	"github.com/rogpeppe/apicompat/apiserve"
+)
+
+//go:embed %s
+var apiSnapshot []byte
+
+func init() {
+	apiserve.Register(apiSnapshot)
+}
+`, pkg, filepath.ToSlash(rel))
+	return ioutil.WriteFile(goFile, []byte(src), 0666)
+}
+
+// packageName returns the name of the package whose source is in
+// dir, ignoring the file exclude and any external test package.
+// If there are no Go files, the name of the directory is used.
+func packageName(dir, exclude string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if filepath.Clean(file) == filepath.Clean(exclude) {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		if name := f.Name.Name; !strings.HasSuffix(name, "_test") {
+			return name, nil
+		}
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(abs), nil
+}
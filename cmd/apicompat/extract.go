@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+var extractCommand = &command{
+	name:    "extract",
+	args:    "package...",
+	summary: "print an API snapshot of the Go packages matching the given patterns",
+}
+
+func init() {
+	extractCommand.run = runExtract
+}
+
+// runExtract loads the named packages from source and prints
+// their API as JSON. The output depends only on the source,
+// so it is suitable for committing and regenerating with
+// a directive such as:
+//
+//	//go:generate apicompat extract -o api.json ./...
+//
+// The flags controlling the output, including -verify, are
+// as for the proto command (see snapshotFlags.write).
+// The -stamp flag also records the current commit and time
+// in the snapshot's metadata, so that it's no longer
+// reproducible from the source alone.
+func runExtract(args []string) error {
+	fs := newFlagSet(extractCommand)
+	var sf snapshotFlags
+	sf.register(fs)
+	serializableOnly := fs.Bool("serializable-only", false, "record fields that cannot be serialized as unsupported")
+	stamp := fs.Bool("stamp", false, "record the current git commit and time in the snapshot")
+	cfg := &srcload.Config{}
+	fs.Func("platform", "load packages for `goos/goarch[,tag...]` (may be repeated)", func(s string) error {
+		p, err := srcload.ParsePlatform(s)
+		if err != nil {
+			return err
+		}
+		cfg.Platforms = append(cfg.Platforms, p)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(extractCommand)
+	}
+	if err := sf.validate(); err != nil {
+		return err
+	}
+	if *stamp && sf.verify != "" {
+		return fmt.Errorf("cannot use -stamp and -verify together")
+	}
+	cfg.SerializableOnly = *serializableOnly
+	info, err := srcload.Load(cfg, fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if *stamp {
+		commit, err := git("rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		info.Meta.Commit = commit
+		info.Meta.Time = time.Now().UTC()
+	}
+	return sf.write(info, extractCommand)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+// extractAtRevision loads the packages matching the given
+// patterns as they were at the given git revision of the
+// repository containing the current directory. The revision is
+// checked out into a temporary worktree, which is removed
+// afterwards. If cfg.Dir is set, it must be relative to
+// the current directory. The commit is recorded in the
+// returned info's metadata.
+func extractAtRevision(cfg *srcload.Config, rev string, patterns []string) (*jsontypes.Info, error) {
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := git("rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := ioutil.TempDir("", "apicompat")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	worktree := filepath.Join(tmpDir, "src")
+	if _, err := git("worktree", "add", "--detach", worktree, commit); err != nil {
+		return nil, err
+	}
+	defer git("worktree", "remove", "--force", worktree)
+	cfg1 := *cfg
+	cfg1.Dir = filepath.Join(worktree, filepath.FromSlash(prefix), cfg.Dir)
+	info, err := srcload.Load(&cfg1, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load packages at %s: %v", rev, err)
+	}
+	info.Meta.Commit = commit
+	return info, nil
+}
+
+// git runs git with the given arguments in the current
+// directory and returns its output with any trailing
+// newline removed.
+func git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
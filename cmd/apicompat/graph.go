@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var graphCommand = &command{
+	name:    "graph",
+	args:    "api.json",
+	summary: "print the type reference graph of an API snapshot in DOT format",
+}
+
+func init() {
+	graphCommand.run = runGraph
+}
+
+// runGraph prints the graph of references between the named
+// types in a snapshot in Graphviz DOT format. With the -old flag,
+// the types that have changed incompatibly since the old snapshot
+// are highlighted in red, and the types that have been added are
+// highlighted in green.
+func runGraph(args []string) error {
+	fs := newFlagSet(graphCommand)
+	var roots []jsontypes.TypeName
+	fs.Func("root", "include only the types reachable from `type` (may be repeated)", func(s string) error {
+		roots = append(roots, jsontypes.TypeName(s))
+		return nil
+	})
+	out := fs.String("o", "", "write the graph to `file` rather than standard output")
+	old := fs.String("old", "", "highlight the types that have changed since the snapshot in `file`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Allow flags after the snapshot file too.
+	if fs.NArg() > 1 {
+		file := fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return usageError(graphCommand)
+		}
+		args = []string{file}
+	} else {
+		args = fs.Args()
+	}
+	if len(args) != 1 {
+		return usageError(graphCommand)
+	}
+	info, err := readSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	if len(roots) > 0 {
+		info, err = info.Prune(roots...)
+		if err != nil {
+			return err
+		}
+	}
+	colors := make(map[jsontypes.TypeName]string)
+	if *old != "" {
+		info0, err := readInfo(*old)
+		if err != nil {
+			return err
+		}
+		info1, err := readInfo(args[0])
+		if err != nil {
+			return err
+		}
+		r := apicompat.CheckAll(info0, info1, apicompat.Ignore(apicompat.HasCustomMarshaler))
+		for _, name := range r.Added {
+			colors[name] = "palegreen"
+		}
+		for _, tr := range r.Incompatible {
+			name := tr.Name
+			if tr.NewName != "" {
+				name = tr.NewName
+			}
+			colors[name] = "lightpink"
+		}
+	}
+	data := graphDOT(info, colors)
+	if *out != "" {
+		return ioutil.WriteFile(*out, data, 0666)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// graphDOT returns the reference graph of the named types in info
+// in DOT format. Each node is labelled with the type name as
+// it would be printed by jsontypes.Format, and is filled with
+// the color in colors, if any.
+func graphDOT(info *jsontypes.Info, colors map[jsontypes.TypeName]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph api {\n")
+	buf.WriteString("\tnode [shape=box];\n")
+	names := sortedTypeNames(info)
+	for _, name := range names {
+		t := info.Types[name]
+		fmt.Fprintf(&buf, "\t%s [label=%s", strconv.Quote(string(name)), strconv.Quote(jsontypes.Format(info, t)))
+		if c := colors[name]; c != "" {
+			fmt.Fprintf(&buf, ", style=filled, fillcolor=%s", c)
+		}
+		buf.WriteString("];\n")
+	}
+	for _, name := range names {
+		for _, ref := range info.References(info.Types[name]) {
+			fmt.Fprintf(&buf, "\t%s -> %s;\n", strconv.Quote(string(name)), strconv.Quote(string(ref)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
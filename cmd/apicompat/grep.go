@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var grepCommand = &command{
+	name:    "grep",
+	args:    "api.json query...",
+	summary: "print the definitions of types in an API snapshot",
+}
+
+func init() {
+	grepCommand.run = runGrep
+}
+
+// runGrep prints the definition of each type in a snapshot that
+// matches a query, followed by the definitions of all the named
+// types it refers to, indented to show where they are first used.
+//
+// A query may be the full name of a type (for example
+// "example.com/foo#Bar"), optionally followed by a path of field
+// names (for example "example.com/foo#Bar.Baz.Qux") to select a
+// field within it, or a regular expression that is matched
+// against type names.
+func runGrep(args []string) error {
+	fs := newFlagSet(grepCommand)
+	depth := fs.Int("depth", -1, "print referenced types up to `n` levels deep (-1 means unlimited)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return usageError(grepCommand)
+	}
+	info, err := readInfo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	p := &treePrinter{
+		w:        os.Stdout,
+		info:     info,
+		maxDepth: *depth,
+	}
+	for _, q := range fs.Args()[1:] {
+		n, err := p.query(q)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no types match %q", q)
+		}
+	}
+	return nil
+}
+
+// treePrinter prints type definitions along with the
+// definitions of the types they refer to.
+type treePrinter struct {
+	w        io.Writer
+	info     *jsontypes.Info
+	maxDepth int
+	// printed holds the number of trees printed so far.
+	printed int
+}
+
+// query prints the types or fields matching q and returns
+// the number printed.
+func (p *treePrinter) query(q string) (int, error) {
+	if t, fields, ok := p.lookupPath(q); ok {
+		if len(fields) == 0 {
+			p.tree(t)
+			return 1, nil
+		}
+		var f *jsontypes.Field
+		for _, name := range fields {
+			f = findField(p.info, t, name)
+			if f == nil {
+				return 0, fmt.Errorf("%s: field %q not found in %s", q, name, jsontypes.Format(p.info, t))
+			}
+			t = fieldStruct(p.info, f.Type)
+		}
+		p.start()
+		fmt.Fprintf(p.w, "%s: %s\n", q, jsontypes.FormatField(p.info, f))
+		p.refs(f.Type, 1, make(map[*jsontypes.Type]bool))
+		return 1, nil
+	}
+	re, err := regexp.Compile(q)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, name := range sortedTypeNames(p.info) {
+		if re.MatchString(string(name)) {
+			p.tree(p.info.Types[name])
+			n++
+		}
+	}
+	return n, nil
+}
+
+// lookupPath returns the type named by a prefix of q,
+// and the names of any fields following it.
+func (p *treePrinter) lookupPath(q string) (*jsontypes.Type, []string, bool) {
+	if t := p.info.Types[jsontypes.TypeName(q)]; t != nil {
+		return t, nil, true
+	}
+	i := strings.Index(q, "#")
+	if i < 0 {
+		return nil, nil, false
+	}
+	j := strings.Index(q[i:], ".")
+	if j < 0 {
+		return nil, nil, false
+	}
+	t := p.info.Types[jsontypes.TypeName(q[0:i+j])]
+	if t == nil {
+		return nil, nil, false
+	}
+	return t, strings.Split(q[i+j+1:], "."), true
+}
+
+// start starts a new tree, separating it from
+// any previous one.
+func (p *treePrinter) start() {
+	if p.printed > 0 {
+		fmt.Fprintln(p.w)
+	}
+	p.printed++
+}
+
+// tree prints the definition of the named type t,
+// followed by the types it refers to.
+func (p *treePrinter) tree(t *jsontypes.Type) {
+	p.start()
+	seen := map[*jsontypes.Type]bool{t: true}
+	p.decl(t, 0)
+	p.refs(t, 1, seen)
+}
+
+// refs prints the definitions of the named types referred
+// to by t that have not already been seen, each indented
+// to the given depth and followed by the types it refers to.
+func (p *treePrinter) refs(t *jsontypes.Type, depth int, seen map[*jsontypes.Type]bool) {
+	if p.maxDepth >= 0 && depth > p.maxDepth {
+		return
+	}
+	// Mark all the direct references as seen first, so that
+	// each type is printed at the shallowest depth possible.
+	var unseen []*jsontypes.Type
+	for _, name := range p.info.References(t) {
+		if rt := p.info.Types[name]; !seen[rt] {
+			seen[rt] = true
+			unseen = append(unseen, rt)
+		}
+	}
+	for _, rt := range unseen {
+		p.decl(rt, depth)
+		p.refs(rt, depth+1, seen)
+	}
+}
+
+// decl prints the declaration of the named type t and its
+// methods, indented to the given depth. Struct fields
+// are printed one per line.
+func (p *treePrinter) decl(t *jsontypes.Type, depth int) {
+	indent := strings.Repeat("\t", depth)
+	if t.Kind != jsontypes.Struct || len(t.Fields) == 0 {
+		fmt.Fprintf(p.w, "%s%s\n", indent, jsontypes.FormatDecl(p.info, t))
+	} else {
+		fmt.Fprintf(p.w, "%stype %s struct {\n", indent, jsontypes.Format(p.info, &jsontypes.Type{Name: t.Name}))
+		for _, f := range t.Fields {
+			fmt.Fprintf(p.w, "%s\t%s\n", indent, jsontypes.FormatField(p.info, f))
+		}
+		fmt.Fprintf(p.w, "%s}\n", indent)
+	}
+	if t.Kind == jsontypes.Interface {
+		return
+	}
+	for _, name := range sortedMethods(t) {
+		fmt.Fprintf(p.w, "%s%s\n", indent, jsontypes.FormatMethod(p.info, t, t.Methods[name]))
+	}
+}
+
+// findField returns the field of the struct type t with the
+// given Go or encoded name, or nil if there is none.
+func findField(info *jsontypes.Info, t *jsontypes.Type, name string) *jsontypes.Field {
+	if t == nil {
+		return nil
+	}
+	for _, f := range info.Deref(t).Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	for _, f := range info.Deref(t).Fields {
+		if f.EncodedName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// fieldStruct returns the struct type whose fields a path
+// through a value of type t would refer to, looking
+// through pointers, slices, arrays and maps.
+func fieldStruct(info *jsontypes.Info, t *jsontypes.Type) *jsontypes.Type {
+	for {
+		t = info.Deref(t)
+		switch t.Kind {
+		case jsontypes.Ptr, jsontypes.Slice, jsontypes.Array, jsontypes.Map:
+			t = t.Elem
+		default:
+			return t
+		}
+	}
+}
+
+func sortedTypeNames(info *jsontypes.Info) []jsontypes.TypeName {
+	names := make([]jsontypes.TypeName, 0, len(info.Types))
+	for name := range info.Types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func sortedMethods(t *jsontypes.Type) []string {
+	names := make([]string, 0, len(t.Methods))
+	for name := range t.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+var keygenCommand = &command{
+	name:    "keygen",
+	args:    "name",
+	summary: "generate a key pair for signing snapshots",
+}
+
+func init() {
+	keygenCommand.run = runKeygen
+}
+
+// runKeygen writes a new Ed25519 private key to name.key and
+// the corresponding public key to name.pub, for use with the
+// -sign-key flag of the extract and proto commands and the
+// -verify-key flag of the check command.
+func runKeygen(args []string) error {
+	fs := newFlagSet(keygenCommand)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError(keygenCommand)
+	}
+	name := fs.Arg(0)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(name+".key", []byte(base64.StdEncoding.EncodeToString(priv.Seed())+"\n"), 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name+".pub", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0666)
+}
+
+// readPrivateKey reads a private key as written by keygen.
+func readPrivateKey(file string) (ed25519.PrivateKey, error) {
+	seed, err := readKey(file, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// readPublicKey reads a public key as written by keygen.
+func readPublicKey(file string) (ed25519.PublicKey, error) {
+	key, err := readKey(file, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// readKey reads a base64-encoded key of the given size.
+func readKey(file string, size int) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%s does not hold a valid key", file)
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/apicompat"
+)
+
+var lintCommand = &command{
+	name:    "lint",
+	args:    "api.json",
+	summary: "check a single API snapshot for inconsistencies",
+}
+
+func init() {
+	lintCommand.run = runLint
+}
+
+// runLint prints the problems found by apicompat.Lint
+// in a snapshot, one per line.
+func runLint(args []string) error {
+	fs := newFlagSet(lintCommand)
+	var opts []apicompat.CheckOption
+	suggest := fs.Bool("suggest", false, "print a suggested remediation for each problem")
+	fs.Func("tag-names", "expect field names in the tags with the given key to be the snake_case forms of the JSON names, as `key=snake` (may be repeated)", func(s string) error {
+		key, convention, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("tag names must be of the form key=convention")
+		}
+		if convention != "snake" {
+			return fmt.Errorf("unknown naming convention %q", convention)
+		}
+		opts = append(opts, apicompat.TagNamePolicy(key, apicompat.NormalizeCase))
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError(lintCommand)
+	}
+	info, err := readSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, tr := range apicompat.Lint(info, opts...) {
+		for _, err := range tr.Errors {
+			fmt.Printf("%s: %v\n", tr.Name, err)
+			if p, ok := err.(*apicompat.Problem); ok && *suggest && p.Suggestion != "" {
+				fmt.Printf("\tsuggestion: %s\n", p.Suggestion)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rogpeppe/apicompat"
+)
+
+// metricsSeverities holds the severities recorded
+// in metrics, in the order they are written.
+var metricsSeverities = []apicompat.Severity{
+	apicompat.Breaking,
+	apicompat.Warning,
+	apicompat.Additive,
+}
+
+// formatMetrics returns the number of changes of each severity in
+// the report in OpenMetrics text format, labelled with the module
+// and version of the new API when known, along with the time of
+// the check, so that API stability can be graphed over time.
+func formatMetrics(r *apicompat.Report, now time.Time) []byte {
+	var labels string
+	if r.New != nil && r.New.Module != "" {
+		labels += ",module=" + strconv.Quote(r.New.Module)
+		if r.New.Version != "" {
+			labels += ",version=" + strconv.Quote(r.New.Version)
+		}
+	}
+	counts := r.CountBySeverity()
+	var buf bytes.Buffer
+	buf.WriteString("# HELP apicompat_changes Number of API changes found by the last check.\n")
+	buf.WriteString("# TYPE apicompat_changes gauge\n")
+	for _, severity := range metricsSeverities {
+		fmt.Fprintf(&buf, "apicompat_changes{severity=%q%s} %d\n", severity, labels, counts[severity])
+	}
+	buf.WriteString("# HELP apicompat_last_check_timestamp_seconds Time of the last check.\n")
+	buf.WriteString("# TYPE apicompat_last_check_timestamp_seconds gauge\n")
+	buf.WriteString("apicompat_last_check_timestamp_seconds")
+	if labels != "" {
+		fmt.Fprintf(&buf, "{%s}", strings.TrimPrefix(labels, ","))
+	}
+	fmt.Fprintf(&buf, " %d\n", now.Unix())
+	buf.WriteString("# EOF\n")
+	return buf.Bytes()
+}
+
+// writeMetricsFile writes the metrics to the named file, suitable
+// for the Prometheus node exporter's textfile collector. The file
+// is replaced atomically so that the collector never sees a
+// partially written file.
+func writeMetricsFile(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".apicompat-metrics-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// pushMetrics pushes the metrics to the Prometheus Pushgateway
+// at the given URL, grouped under the apicompat job.
+func pushMetrics(url string, data []byte) error {
+	// The Pushgateway does not accept the OpenMetrics EOF marker.
+	data = bytes.TrimSuffix(data, []byte("# EOF\n"))
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	req, err := http.NewRequest("PUT", strings.TrimSuffix(url, "/")+"/metrics/job/apicompat", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot push metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("cannot push metrics: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+var modulesCommand = &command{
+	name:    "modules",
+	args:    "[dir]",
+	summary: "check every module in a repository against its own latest release",
+}
+
+func init() {
+	modulesCommand.run = runModules
+}
+
+// module describes a module found in a repository.
+type module struct {
+	// path holds the module path.
+	path string
+	// dir holds the module's directory, relative
+	// to the current directory, in slash-separated form.
+	dir string
+}
+
+// moduleResult holds the result of checking a module.
+type moduleResult struct {
+	module
+	// base holds the revision that the module
+	// was checked against.
+	base string
+	// report holds the report, or nil if the module
+	// could not be checked.
+	report *apicompat.Report
+	// err holds why the module could not be checked.
+	err error
+}
+
+// runModules checks each module found in the given directory
+// (the current directory by default) and its subdirectories,
+// printing a report for each followed by a summary. Each module
+// is compared against its most recent release tag, as found by
+// git describe, where the tags of a module in a subdirectory are
+// prefixed by the directory, following the usual convention
+// for multi-module repositories (for example "sub/mod/v1.2.3").
+func runModules(args []string) error {
+	fs := newFlagSet(modulesCommand)
+	base := fs.String("base", "", "compare all modules against git `revision` rather than their latest tags")
+	suggest := fs.Bool("suggest", false, "show suggested remediations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError(modulesCommand)
+	}
+	root := "."
+	if fs.NArg() == 1 {
+		root = fs.Arg(0)
+	}
+	mods, err := findModules(root)
+	if err != nil {
+		return err
+	}
+	if len(mods) == 0 {
+		return fmt.Errorf("no modules found in %s", root)
+	}
+	results := make([]*moduleResult, len(mods))
+	for i, m := range mods {
+		r := &moduleResult{
+			module: m,
+			base:   *base,
+		}
+		results[i] = r
+		if r.base == "" {
+			r.base, r.err = latestTag(m.dir)
+			if r.err != nil {
+				continue
+			}
+		}
+		r.report, r.err = checkModule(m, r.base)
+	}
+	failed := false
+	for _, r := range results {
+		if r.report == nil {
+			continue
+		}
+		changes := r.report.Changes()
+		if *suggest {
+			changes = r.report.ChangesWithSuggestions()
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Printf("# %s (against %s)\n", r.path, r.base)
+		for _, line := range changes {
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+	fmt.Println("# summary")
+	for _, r := range results {
+		var status string
+		switch {
+		case r.err != nil:
+			status = fmt.Sprintf("not checked: %v", r.err)
+		case r.report.OK():
+			status = "ok"
+		default:
+			status = fmt.Sprintf("%d incompatible changes since %s", r.report.CountAtLeast(apicompat.Breaking), r.base)
+			failed = true
+		}
+		fmt.Printf("%s\t%s\n", r.path, status)
+	}
+	if failed {
+		return fmt.Errorf("some modules are not backwardly compatible")
+	}
+	return nil
+}
+
+// checkModule checks the packages in the given module against
+// those at the given git revision.
+func checkModule(m module, base string) (*apicompat.Report, error) {
+	cfg := &srcload.Config{
+		Dir: filepath.FromSlash(m.dir),
+	}
+	info0, err := extractAtRevision(cfg, base, []string{"./..."})
+	if err != nil {
+		return nil, err
+	}
+	info1, err := srcload.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+	apicompat.PruneMethods(info0, apicompat.IsMarshalMethod)
+	apicompat.PruneMethods(info1, apicompat.IsMarshalMethod)
+	return apicompat.CheckAll(info0, info1, apicompat.Ignore(apicompat.HasCustomMarshaler)), nil
+}
+
+// latestTag returns the most recent release tag reachable from
+// HEAD for the module in the given directory, which is
+// relative to the current directory.
+func latestTag(dir string) (string, error) {
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+	match := "v[0-9]*"
+	if dir := path.Join(prefix, dir); dir != "." {
+		match = dir + "/" + match
+	}
+	tag, err := git("describe", "--tags", "--abbrev=0", "--match", match)
+	if err != nil {
+		return "", fmt.Errorf("no release tag found")
+	}
+	return tag, nil
+}
+
+// findModules returns all the modules in the given directory
+// and its subdirectories, ignoring vendor and testdata
+// directories and directories starting with "." or "_",
+// as the go command does.
+func findModules(root string) ([]module, error) {
+	var mods []module
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			name := fi.Name()
+			if p != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Name() != "go.mod" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		modPath := modfile.ModulePath(data)
+		if modPath == "" {
+			return fmt.Errorf("no module path found in %s", p)
+		}
+		mods = append(mods, module{
+			path: modPath,
+			dir:  path.Clean(filepath.ToSlash(filepath.Dir(p))),
+		})
+		return nil
+	})
+	return mods, err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/protoload"
+)
+
+var protoCommand = &command{
+	name:    "proto",
+	args:    "descriptor-set...",
+	summary: "print an API snapshot of the gRPC services in protobuf descriptor sets",
+}
+
+func init() {
+	protoCommand.run = runProto
+}
+
+// runProto reads descriptor sets as written by
+// protoc --descriptor_set_out and prints the services
+// they declare as JSON. Use --include_source_info to
+// include doc comments. See snapshotFlags.write for
+// the flags that control the output.
+func runProto(args []string) error {
+	fs := newFlagSet(protoCommand)
+	var sf snapshotFlags
+	sf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError(protoCommand)
+	}
+	if err := sf.validate(); err != nil {
+		return err
+	}
+	info := jsontypes.NewInfo()
+	for _, f := range fs.Args() {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		var set descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(data, &set); err != nil {
+			return err
+		}
+		files, err := protodesc.NewFiles(&set)
+		if err != nil {
+			return err
+		}
+		files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+			protoload.AddServices(info, fd)
+			return true
+		})
+	}
+	return sf.write(info, protoCommand)
+}
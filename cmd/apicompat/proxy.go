@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+// extractPublished loads the packages matching the given patterns
+// as they are in a published version of the module containing the
+// current directory, which is downloaded from the module proxy
+// (see GOPROXY) in the same way as the go command downloads
+// dependencies. The version may be "latest" to use the latest
+// published release. It also returns the version that was used.
+// If cfg.Dir is set, it must be relative to the current directory.
+func extractPublished(cfg *srcload.Config, version string, patterns []string) (*jsontypes.Info, string, error) {
+	modPath, modDir, err := currentModule()
+	if err != nil {
+		return nil, "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	prefix, err := filepath.Rel(modDir, wd)
+	if err != nil {
+		return nil, "", err
+	}
+	m, err := downloadModule(modPath + "@" + version)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg1 := *cfg
+	cfg1.Dir = filepath.Join(m.Dir, prefix, cfg.Dir)
+	info, err := srcload.Load(&cfg1, patterns...)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot load packages at %s@%s: %v", modPath, m.Version, err)
+	}
+	return info, m.Version, nil
+}
+
+// currentModule returns the path and root directory of the
+// main module for the current directory.
+func currentModule() (path, dir string, err error) {
+	out, err := goCommand("", "list", "-m", "-f", "{{.Path}}\t{{.Dir}}")
+	if err != nil {
+		return "", "", err
+	}
+	// In a workspace, the first module listed is the one
+	// containing the current directory.
+	line, _, _ := strings.Cut(out, "\n")
+	path, dir, ok := strings.Cut(line, "\t")
+	if !ok || path == "" || dir == "" {
+		return "", "", fmt.Errorf("cannot determine current module")
+	}
+	return path, dir, nil
+}
+
+// downloadedModule holds the fields of interest
+// printed by go mod download -json.
+type downloadedModule struct {
+	Path    string
+	Version string
+	Dir     string
+	Error   string
+}
+
+// downloadModule downloads the module with the given
+// path@version query into the module cache.
+func downloadModule(query string) (*downloadedModule, error) {
+	// Run outside the current module so that the query
+	// is not resolved against the main module itself.
+	out, err := goCommand(os.TempDir(), "mod", "download", "-json", query)
+	var m downloadedModule
+	if jerr := json.Unmarshal([]byte(out), &m); jerr == nil && m.Error != "" {
+		return nil, fmt.Errorf("cannot download %s: %s", query, m.Error)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.Dir == "" {
+		return nil, fmt.Errorf("cannot download %s: no directory in go output", query)
+	}
+	return &m, nil
+}
+
+// goCommand runs the go command with the given arguments
+// in the given directory (the current directory if it's empty)
+// and returns its output with any trailing newline removed.
+// The output is also returned when the command fails.
+func goCommand(dir string, args ...string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("go %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var pruneCommand = &command{
+	name:    "prune",
+	args:    "api.json -root type...",
+	summary: "print the part of an API snapshot reachable from the given root types",
+}
+
+func init() {
+	pruneCommand.run = runPrune
+}
+
+// runPrune prints a snapshot holding only the types
+// reachable from the root types, for example to produce
+// a minimal contract to share with external consumers.
+func runPrune(args []string) error {
+	fs := newFlagSet(pruneCommand)
+	var roots []jsontypes.TypeName
+	fs.Func("root", "include the types reachable from `type` (may be repeated)", func(s string) error {
+		roots = append(roots, jsontypes.TypeName(s))
+		return nil
+	})
+	out := fs.String("o", "", "write the snapshot to `file` rather than standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Allow flags after the snapshot file too.
+	if fs.NArg() > 1 {
+		file := fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return usageError(pruneCommand)
+		}
+		args = []string{file}
+	} else {
+		args = fs.Args()
+	}
+	if len(args) != 1 || len(roots) == 0 {
+		return usageError(pruneCommand)
+	}
+	info, err := readSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	info, err = info.Prune(roots...)
+	if err != nil {
+		return err
+	}
+	data, err := marshalInfo(info)
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		return ioutil.WriteFile(*out, data, 0666)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var reduceCommand = &command{
+	name:    "reduce",
+	args:    "api_old.json api_new.json",
+	summary: "shrink two snapshots to a minimal pair that reproduces their incompatibilities",
+}
+
+func init() {
+	reduceCommand.run = runReduce
+}
+
+// runReduce writes reduced versions of the old and new snapshots
+// that still produce all the incompatibilities found in the types
+// of the originals (or those found by the rules given with -rule),
+// as a small reproduction for bug reports.
+func runReduce(args []string) error {
+	fs := newFlagSet(reduceCommand)
+	out := fs.String("o", "reduced", "write the reduced snapshots to `prefix`-old.json and prefix-new.json")
+	rules := make(map[string]bool)
+	fs.Func("rule", "reproduce only the problems found by the rule with the given `id` (may be repeated)", func(s string) error {
+		if apicompat.LookupRule(s) == nil {
+			return fmt.Errorf("unknown rule %q", s)
+		}
+		rules[s] = true
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError(reduceCommand)
+	}
+	info0, err := readInfo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	info1, err := readInfo(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	opts := []apicompat.CheckOption{
+		apicompat.Ignore(apicompat.HasCustomMarshaler),
+	}
+	want := problemSet(apicompat.CheckAll(info0, info1, opts...), rules)
+	if len(want) == 0 {
+		return fmt.Errorf("no incompatibilities to reproduce")
+	}
+	interesting := func(r *apicompat.Report) bool {
+		got := problemSet(r, rules)
+		for p := range want {
+			if !got[p] {
+				return false
+			}
+		}
+		return true
+	}
+	reduced0, reduced1, err := apicompat.Reduce(info0, info1, interesting, opts...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "reduced %d and %d types to %d and %d\n", len(info0.Types), len(info1.Types), len(reduced0.Types), len(reduced1.Types))
+	for _, f := range []struct {
+		name string
+		info *jsontypes.Info
+	}{
+		{*out + "-old.json", reduced0},
+		{*out + "-new.json", reduced1},
+	} {
+		data, err := marshalInfo(f.info)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(f.name, data, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// problemSet returns the set of problems found in types in the
+// report, found by one of the given rules if there are any,
+// identified by rule and message so that they can be compared
+// regardless of where they were found.
+func problemSet(r *apicompat.Report, rules map[string]bool) map[string]bool {
+	set := make(map[string]bool)
+	if len(rules) == 0 || rules["type-removed"] {
+		for _, name := range r.Removed {
+			set["type-removed: "+string(name)] = true
+		}
+	}
+	for _, tr := range r.Incompatible {
+		for _, err := range tr.Errors {
+			p, ok := err.(*apicompat.Problem)
+			if !ok || len(rules) > 0 && !rules[p.Rule] {
+				continue
+			}
+			set[p.Rule+": "+p.Message] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rogpeppe/apicompat"
+)
+
+var rulesCommand = &command{
+	name:    "rules",
+	args:    "[rule-id...]",
+	summary: "list the compatibility rules, or describe the given rules",
+}
+
+func init() {
+	rulesCommand.run = runRules
+}
+
+func runRules(args []string) error {
+	fs := newFlagSet(rulesCommand)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, r := range apicompat.Rules() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Severity, firstSentence(r.Description))
+		}
+		return w.Flush()
+	}
+	for i, id := range fs.Args() {
+		r := apicompat.LookupRule(id)
+		if r == nil {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s", r.ID, r.Severity)
+		if r.Optional {
+			fmt.Printf(", optional")
+		}
+		fmt.Printf(")\n\n%s\n", r.Description)
+		if r.Example != "" {
+			fmt.Printf("\nExample:\n\n\t%s\n", strings.Replace(r.Example, "\n", "\n\t", -1))
+		}
+	}
+	return nil
+}
+
+// firstSentence returns the first sentence of s.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[0 : i+1]
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// The types below hold the parts of the SARIF 2.1.0 format
+// (see https://docs.oasis-open.org/sarif/sarif/v2.1.0/) that
+// are needed to report problems to code scanning tools.
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	FullDescription      *sarifMessage     `json:"fullDescription,omitempty"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   sarifMessage     `json:"message"`
+	Locations []*sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// newSARIFLog returns the problems in r in SARIF form. Each
+// result is located at the source position of the innermost
+// field, method or type involved, when the positions are
+// recorded in info1 or, failing that, in info0.
+func newSARIFLog(r *apicompat.Report, info0, info1 *jsontypes.Info) *sarifLog {
+	run := &sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "apicompat",
+				InformationURI: "https://github.com/rogpeppe/apicompat",
+				Rules:          []*sarifRule{},
+			},
+		},
+		Results: []*sarifResult{},
+	}
+	seen := make(map[string]bool)
+	for _, p := range apicompat.NewJSONReport(r).Problems {
+		rule := p.Rule
+		if rule == "" {
+			rule = "custom"
+		}
+		if !seen[rule] {
+			seen[rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(rule))
+		}
+		msg := p.Item + ": " + p.Message
+		if p.FormattedPath != "" {
+			msg = p.Item + ": " + p.FormattedPath + ": " + p.Message
+		}
+		result := &sarifResult{
+			RuleID:  rule,
+			Level:   sarifLevel(p.Severity),
+			Message: sarifMessage{Text: msg},
+		}
+		pos := itemPos(info1, p.Item, p.Path)
+		if pos == "" {
+			pos = itemPos(info0, p.Item, p.Path)
+		}
+		if loc := sarifLocationOf(pos); loc != nil {
+			result.Locations = []*sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	return &sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []*sarifRun{run},
+	}
+}
+
+// newSARIFRule returns the SARIF description of
+// the rule with the given ID.
+func newSARIFRule(id string) *sarifRule {
+	rule := &sarifRule{
+		ID:                   id,
+		ShortDescription:     sarifMessage{Text: id},
+		DefaultConfiguration: sarifRuleDefaults{Level: "error"},
+	}
+	if ri := apicompat.LookupRule(id); ri != nil {
+		rule.FullDescription = &sarifMessage{Text: ri.Description}
+		rule.DefaultConfiguration.Level = sarifLevel(ri.Severity)
+	}
+	return rule
+}
+
+// sarifLevel returns the SARIF level corresponding
+// to the given severity.
+func sarifLevel(s apicompat.Severity) string {
+	switch s {
+	case apicompat.Warning:
+		return "warning"
+	case apicompat.Additive:
+		return "note"
+	}
+	return "error"
+}
+
+// itemPos returns the recorded source position of the
+// innermost field or method along path within the named
+// type, or of the type itself, or the empty string if
+// there is none.
+func itemPos(info *jsontypes.Info, item string, path apicompat.Path) string {
+	t := info.Types[jsontypes.TypeName(item)]
+	if t == nil {
+		return ""
+	}
+	pos := t.Pos
+	for _, e := range path {
+		switch e.Kind {
+		case apicompat.PathField:
+			f := t.FieldByName(e.Name)
+			if f == nil {
+				return pos
+			}
+			if f.Pos != "" {
+				pos = f.Pos
+			}
+			t = f.Type
+		case apicompat.PathMethod:
+			if m := t.Methods[e.Name]; m != nil && m.Pos != "" {
+				pos = m.Pos
+			}
+			return pos
+		case apicompat.PathDeref, apicompat.PathElemType:
+			t = t.Elem
+		default:
+			return pos
+		}
+		if t == nil {
+			return pos
+		}
+		if t.Name != "" {
+			// Fields of other named types are reported
+			// at the field that refers to them.
+			return pos
+		}
+	}
+	return pos
+}
+
+// sarifLocationOf returns the location for a position
+// of the form "file:line", or nil if pos is empty.
+func sarifLocationOf(pos string) *sarifLocation {
+	if pos == "" {
+		return nil
+	}
+	loc := &sarifLocation{}
+	loc.PhysicalLocation.ArtifactLocation.URI = pos
+	if i := strings.LastIndex(pos, ":"); i >= 0 {
+		if n, err := strconv.Atoi(pos[i+1:]); err == nil {
+			loc.PhysicalLocation.ArtifactLocation.URI = pos[:i]
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: n}
+		}
+	}
+	return loc
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+var saveCommand = &command{
+	name:    "save",
+	args:    "[package...]",
+	summary: "save an API snapshot of the packages in the working tree for verify",
+}
+
+func init() {
+	saveCommand.run = runSave
+}
+
+// defaultSnapshotFile holds the file that save writes
+// and verify reads by default, relative to the current
+// directory.
+const defaultSnapshotFile = "api/api.json"
+
+// runSave writes a snapshot of the API of the given packages
+// (by default those in the configuration file, or ./...),
+// to be committed and checked against later with verify.
+func runSave(args []string) error {
+	fs := newFlagSet(saveCommand)
+	file := fs.String("snapshot", defaultSnapshotFile, "write the snapshot to `file`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	conf, err := loadConfig("")
+	if err != nil {
+		return err
+	}
+	info, err := srcload.Load(nil, snapshotPatterns(conf, fs.Args())...)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*file), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err := jsontypes.Encode(f, info); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saved API of %d types to %s\n", len(info.Types), *file)
+	return nil
+}
+
+// snapshotPatterns returns the package patterns to be saved
+// or verified, given the command line arguments.
+func snapshotPatterns(conf *config, args []string) []string {
+	switch {
+	case len(args) > 0:
+		return args
+	case len(conf.Packages) > 0:
+		return conf.Packages
+	}
+	return []string{"./..."}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// snapshotFlags holds the flags common to the
+// commands that write API snapshots.
+type snapshotFlags struct {
+	out     string
+	verify  string
+	seal    bool
+	signKey string
+	embed   string
+}
+
+// register defines the flags in fs.
+func (sf *snapshotFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&sf.out, "o", "", "write the snapshot to `file` rather than standard output")
+	fs.StringVar(&sf.verify, "verify", "", "check that `file` holds an up to date snapshot rather than printing it")
+	fs.BoolVar(&sf.seal, "seal", false, "record a hash of the snapshot so that it can be checked for modification")
+	fs.StringVar(&sf.signKey, "sign-key", "", "seal the snapshot and sign it with the private key in `file` (see keygen)")
+	fs.StringVar(&sf.embed, "embed", "", "also write a Go `file` that embeds the snapshot written with -o and serves it")
+}
+
+// validate returns an error if the flags are inconsistent.
+func (sf *snapshotFlags) validate() error {
+	if sf.out != "" && sf.verify != "" {
+		return fmt.Errorf("cannot use -o and -verify together")
+	}
+	if sf.embed != "" && sf.out == "" {
+		return fmt.Errorf("-embed requires -o")
+	}
+	return nil
+}
+
+// write writes info as JSON as directed by the flags, on behalf
+// of the command c. The output depends only on info, so a snapshot
+// is suitable for committing and regenerating with a directive
+// such as:
+//
+//	//go:generate apicompat proto -o api.json service.pb
+//
+// With the -verify flag, the snapshot is compared against the
+// given file instead, and an error is returned if it is stale.
+//
+// With the -embed flag, a Go file is also written that embeds
+// the snapshot written with -o into the program and serves it
+// at /debug/api (see the apiserve package).
+func (sf *snapshotFlags) write(info *jsontypes.Info, c *command) error {
+	if sf.signKey != "" {
+		key, err := readPrivateKey(sf.signKey)
+		if err != nil {
+			return err
+		}
+		if err := info.Seal(key); err != nil {
+			return err
+		}
+	} else if sf.seal {
+		if err := info.Seal(nil); err != nil {
+			return err
+		}
+	}
+	if sf.verify != "" {
+		data, err := marshalInfo(info)
+		if err != nil {
+			return err
+		}
+		old, err := ioutil.ReadFile(sf.verify)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(old, data) {
+			return fmt.Errorf("%s is out of date; regenerate it with apicompat %s -o %s", sf.verify, c.name, sf.verify)
+		}
+		return nil
+	}
+	if sf.out == "" {
+		return jsontypes.Encode(os.Stdout, info)
+	}
+	f, err := os.Create(sf.out)
+	if err != nil {
+		return err
+	}
+	if err := jsontypes.Encode(f, info); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if sf.embed != "" {
+		return writeEmbedFile(sf.embed, sf.out)
+	}
+	return nil
+}
+
+// marshalInfo returns info formatted as indented JSON
+// with a trailing newline.
+func marshalInfo(info *jsontypes.Info) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jsontypes.Encode(&buf, info); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
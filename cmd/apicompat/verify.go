@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+var verifyCommand = &command{
+	name:    "verify",
+	args:    "[package...]",
+	summary: "check the packages in the working tree against the snapshot written by save",
+}
+
+func init() {
+	verifyCommand.run = runVerify
+}
+
+// runVerify checks the API of the given packages (by default
+// those in the configuration file, or ./...) against the
+// snapshot written by save, and fails if it's not backwardly
+// compatible, so that it can be used as a pre-merge check.
+// Compatible changes such as additions are mentioned
+// but do not cause it to fail.
+func runVerify(args []string) error {
+	fs := newFlagSet(verifyCommand)
+	file := fs.String("snapshot", defaultSnapshotFile, "check against the snapshot in `file`")
+	suggest := fs.Bool("suggest", false, "show suggested remediations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	conf, err := loadConfig("")
+	if err != nil {
+		return err
+	}
+	opts, err := conf.options()
+	if err != nil {
+		return err
+	}
+	info0, err := readSnapshot(*file)
+	if err != nil {
+		return fmt.Errorf("cannot read snapshot (create it with apicompat save): %v", err)
+	}
+	info1, err := srcload.Load(nil, snapshotPatterns(conf, fs.Args())...)
+	if err != nil {
+		return err
+	}
+	if !conf.sourceLevel() {
+		apicompat.PruneMethods(info0, conf.isMarshalMethod)
+		apicompat.PruneMethods(info1, conf.isMarshalMethod)
+		opts = append(opts, apicompat.MarshalMethods(conf.MarshalMethods...))
+	}
+	opts = append(opts, apicompat.ReportAdditions())
+	r := apicompat.CheckAll(info0, info1, opts...)
+	changes := r.Changes()
+	if *suggest {
+		changes = r.ChangesWithSuggestions()
+	}
+	for _, line := range changes {
+		fmt.Println(line)
+	}
+	if !r.OK() {
+		return fmt.Errorf("API is not backwardly compatible with %s", *file)
+	}
+	if n := r.CountBySeverity()[apicompat.Additive]; n > 0 {
+		fmt.Fprintf(os.Stderr, "%s is out of date (%d additions); update it with apicompat save\n", *file, n)
+	}
+	return nil
+}
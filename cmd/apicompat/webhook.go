@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rogpeppe/apicompat"
+)
+
+// maxWebhookChanges holds the maximum number of changes
+// listed in a webhook notification.
+const maxWebhookChanges = 5
+
+// webhookSummary returns a short summary of the report
+// suitable for posting to a chat system, listing at most
+// maxWebhookChanges changes, followed by a link to the
+// full report if reportURL is non-empty.
+func webhookSummary(r *apicompat.Report, reportURL string) string {
+	changes := r.Changes()
+	var buf strings.Builder
+	what := "API"
+	if r.New != nil && r.New.Module != "" {
+		what = r.New.Module
+	}
+	fmt.Fprintf(&buf, "%s has %d incompatible changes", what, len(changes))
+	if s := r.Old.String(); s != "" {
+		fmt.Fprintf(&buf, " since %s", s)
+	}
+	buf.WriteString("\n")
+	for i, c := range changes {
+		if i == maxWebhookChanges {
+			fmt.Fprintf(&buf, "• ... and %d more\n", len(changes)-i)
+			break
+		}
+		// Only the first line of each change, without
+		// the declarations.
+		c, _, _ = strings.Cut(c, "\n")
+		fmt.Fprintf(&buf, "• %s\n", c)
+	}
+	if reportURL != "" {
+		fmt.Fprintf(&buf, "Full report: %s\n", reportURL)
+	}
+	return buf.String()
+}
+
+// postWebhook posts a summary of the report to the given
+// webhook URL as a JSON object with a "text" field, as
+// accepted by Slack incoming webhooks and compatible services.
+func postWebhook(url string, r *apicompat.Report, reportURL string) error {
+	data, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{webhookSummary(r, reportURL)})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot post to webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("cannot post to webhook: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
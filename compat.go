@@ -2,7 +2,10 @@ package apicompat
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/rogpeppe/apicompat/jsontypes"
 )
@@ -20,11 +23,79 @@ func PruneMethods(info *jsontypes.Info, f func(t *jsontypes.Type, m *jsontypes.M
 	}
 }
 
+// PruneFields deletes all fields from info that
+// do not satisfy the given function, which is called
+// for every field on every struct type, including
+// anonymous struct types.
+func PruneFields(info *jsontypes.Info, f func(t *jsontypes.Type, f *jsontypes.Field) bool) {
+	jsontypes.Walk(info, jsontypes.VisitorFuncs{
+		Type: func(t *jsontypes.Type) bool {
+			if t.Kind != jsontypes.Struct {
+				return true
+			}
+			fields := t.Fields[:0]
+			for _, field := range t.Fields {
+				if f(t, field) {
+					fields = append(fields, field)
+				}
+			}
+			t.Fields = fields
+			return true
+		},
+	})
+}
+
 type checkContext struct {
+	checkOptions
 	info0, info1 *jsontypes.Info
-	ignore       func(info *jsontypes.Info, t *jsontypes.Type) bool
-	checked      map[*jsontypes.Type]bool
+	checked      map[checkKey]bool
 	errors       []error
+	// role holds the role of the type currently being
+	// checked, inherited from the enclosing type when
+	// the type itself has no role.
+	role jsontypes.Role
+	// stability holds the stability of the item currently
+	// being checked, inherited similarly.
+	stability jsontypes.Stability
+	// decls returns renderings of the innermost old and
+	// new declarations being checked. It's a function
+	// because rendering is only needed when a problem
+	// is found.
+	decls func() (decl0, decl1 string)
+	// typeName holds the name of the innermost
+	// named type being checked.
+	typeName jsontypes.TypeName
+	// tparams0 and tparams1 hold the type parameters
+	// of the generic type being checked, if any.
+	tparams0, tparams1 []*jsontypes.TypeParam
+	// type0 and type1 hold the innermost old and new
+	// types being checked.
+	type0, type1 *jsontypes.Type
+	// added holds the additions found when additions
+	// are being reported (see ReportAdditions).
+	added []error
+}
+
+func newCheckContext(o checkOptions, info0, info1 *jsontypes.Info) *checkContext {
+	return &checkContext{
+		checkOptions: o,
+		info0:        info0,
+		info1:        info1,
+		checked:      make(map[checkKey]bool),
+	}
+}
+
+// checkKey identifies the check of an old type against a new
+// type. Each check is done only once, so that checking terminates
+// even for cycles that do not pass through named types. The role
+// and stability inherited from the enclosing item are part of the
+// key because they determine which rules apply: a type reached
+// from both a request and a response type must be checked as
+// both.
+type checkKey struct {
+	t0, t1    *jsontypes.Type
+	role      jsontypes.Role
+	stability jsontypes.Stability
 }
 
 type CheckError struct {
@@ -45,15 +116,24 @@ func (e *CheckError) Error() string {
 // Both types must have been taken from the given info value.
 //
 // If a type satisfies the given ignore function, it
-// will be always be treated as compatible.
-func Check(info0, info1 *jsontypes.Info, t0, t1 *jsontypes.Type, ignore func(info *jsontypes.Info, t *jsontypes.Type) bool) error {
-	ctxt := checkContext{
-		info0:   info0,
-		info1:   info1,
-		ignore:  ignore,
-		checked: make(map[*jsontypes.Type]bool),
-	}
-	ctxt.check(t0, t1, "")
+// will be always be treated as compatible. The ignore
+// function may be nil.
+//
+// The rules applied depend on the role of the types
+// being checked (see jsontypes.Role). For example, fields
+// may be removed from request types, but not from response
+// types. Types without a role inherit the role of the type
+// that refers to them.
+//
+// The rules also depend on the stability level of the old
+// types, fields and methods (see jsontypes.Stability):
+// experimental items may change arbitrarily and beta items
+// may be removed.
+func Check(info0, info1 *jsontypes.Info, t0, t1 *jsontypes.Type, ignore func(info *jsontypes.Info, t *jsontypes.Type) bool, opts ...CheckOption) error {
+	o := newCheckOptions(opts)
+	Ignore(ignore)(&o)
+	ctxt := newCheckContext(o, info0, info1)
+	ctxt.check(t0, t1, nil)
 	if len(ctxt.errors) > 0 {
 		return &CheckError{
 			Errors: ctxt.errors,
@@ -62,91 +142,644 @@ func Check(info0, info1 *jsontypes.Info, t0, t1 *jsontypes.Type, ignore func(inf
 	return nil
 }
 
-func (ctxt *checkContext) errorf(path string, msg string, a ...interface{}) {
-	ctxt.errors = append(ctxt.errors, fmt.Errorf(path+": "+fmt.Sprintf(msg, a...)))
+func (ctxt *checkContext) errorf(rule string, path Path, msg string, a ...interface{}) {
+	if !ctxt.enabled(rule, ctxt.role, ctxt.stability) {
+		return
+	}
+	p := &Problem{
+		Rule:          rule,
+		Severity:      ctxt.severity(rule),
+		Path:          path,
+		Message:       fmt.Sprintf(msg, a...),
+		Old:           ctxt.type0,
+		New:           ctxt.type1,
+		formattedPath: ctxt.formatPath(path),
+	}
+	if ctxt.decls != nil {
+		p.OldDecl, p.NewDecl = ctxt.decls()
+	}
+	if ctxt.allowed(p) {
+		return
+	}
+	p.Suggestion = suggestion(p)
+	ctxt.errors = append(ctxt.errors, p)
 }
 
-func (ctxt *checkContext) check(t0, t1 *jsontypes.Type, path string) {
-	if ctxt.checked[t0] && ctxt.checked[t1] {
+// setDecls sets the function used to render the declarations
+// attached to any problems found and returns a function that
+// restores the previous one.
+func (ctxt *checkContext) setDecls(decls func() (decl0, decl1 string)) (restore func()) {
+	old := ctxt.decls
+	ctxt.decls = decls
+	return func() {
+		ctxt.decls = old
+	}
+}
+
+// typeDecls returns a function that renders the
+// declarations of the named types t0 and t1.
+func (ctxt *checkContext) typeDecls(t0, t1 *jsontypes.Type) func() (string, string) {
+	return func() (string, string) {
+		return jsontypes.FormatDecl(ctxt.info0, t0), jsontypes.FormatDecl(ctxt.info1, t1)
+	}
+}
+
+// fieldDecls returns a function that renders the old
+// and new fields, either of which may be nil.
+func (ctxt *checkContext) fieldDecls(f0, f1 *jsontypes.Field) func() (string, string) {
+	return func() (decl0, decl1 string) {
+		if f0 != nil {
+			decl0 = jsontypes.FormatField(ctxt.info0, f0)
+		}
+		if f1 != nil {
+			decl1 = jsontypes.FormatField(ctxt.info1, f1)
+		}
+		return decl0, decl1
+	}
+}
+
+// methodDecls returns a function that renders the old
+// method m0 on t0 and the new method m1 on t1, either
+// of which may be nil.
+func (ctxt *checkContext) methodDecls(t0 *jsontypes.Type, m0 *jsontypes.Method, t1 *jsontypes.Type, m1 *jsontypes.Method) func() (string, string) {
+	return func() (decl0, decl1 string) {
+		if m0 != nil {
+			decl0 = jsontypes.FormatMethod(ctxt.info0, t0, m0)
+		}
+		if m1 != nil {
+			decl1 = jsontypes.FormatMethod(ctxt.info1, t1, m1)
+		}
+		return decl0, decl1
+	}
+}
+
+func (ctxt *checkContext) check(t0, t1 *jsontypes.Type, path Path) {
+	key := checkKey{t0, t1, ctxt.role, ctxt.stability}
+	if ctxt.checked[key] {
+		return
+	}
+	ctxt.checked[key] = true
+	if t0 == nil || t1 == nil {
+		ctxt.errorf(ruleNilType, path, "nil type found")
 		return
 	}
-	ctxt.checked[t0] = true
-	ctxt.checked[t1] = true
 	t0 = ctxt.info0.Deref(t0)
 	t1 = ctxt.info1.Deref(t1)
+	defer func(type0, type1 *jsontypes.Type) {
+		ctxt.type0, ctxt.type1 = type0, type1
+	}(ctxt.type0, ctxt.type1)
+	ctxt.type0, ctxt.type1 = t0, t1
+	if ctxt.isOpaque(t0) || ctxt.isOpaque(t1) {
+		ctxt.checkOpaque(t0, t1, path)
+		return
+	}
 	if ctxt.ignore(ctxt.info0, t0) || ctxt.ignore(ctxt.info1, t1) {
 		return
 	}
-	if t0 == nil || t1 == nil {
-		ctxt.errorf(path, "nil type found")
+	if role := roleOf(t0, t1); role != jsontypes.NoRole {
+		defer func(role jsontypes.Role) {
+			ctxt.role = role
+		}(ctxt.role)
+		ctxt.role = role
+	}
+	defer ctxt.setStability(t0.StabilityOf())()
+	if t0.Name.PkgPath() != "" && t1.Name.PkgPath() != "" {
+		defer ctxt.setDecls(ctxt.typeDecls(t0, t1))()
+		defer func(name jsontypes.TypeName) {
+			ctxt.typeName = name
+		}(ctxt.typeName)
+		ctxt.typeName = t0.Name
+	}
+	ctxt.checkExtraRules(t0, t1, path)
+	for _, cmp := range ctxt.comparators(t0, t1) {
+		handled, err := cmp(ctxt.info0, t0, ctxt.info1, t1)
+		if !handled {
+			continue
+		}
+		if err != nil {
+			ctxt.errorf(ruleCustom, path, "%v", err)
+		}
+		return
+	}
+	if isTypeParamRef(ctxt.info0, t0) || isTypeParamRef(ctxt.info1, t1) {
+		ctxt.checkTypeParamRef(t0, t1, path)
+		return
+	}
+	if len(t0.TypeParams) > 0 || len(t1.TypeParams) > 0 {
+		defer ctxt.setTypeParams(t0.TypeParams, t1.TypeParams)()
+		ctxt.checkTypeParams(t0, t1, path)
+	}
+	ctxt.checkConditional(path, "type", t0.Constraint, t0.Platforms, t1.Constraint, t1.Platforms)
+	if ctxt.checkKindChange(t0, t1, path) {
+		return
 	}
 	if t0.Kind != t1.Kind {
-		ctxt.errorf(path, "incompatible kinds %s vs %s", t0.Kind, t1.Kind)
+		ctxt.errorf(ruleKindChanged, path, "incompatible types %s vs %s", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
 		return
 	}
+	ctxt.checkImplements(t0, t1, path)
 	switch t0.Kind {
+	case jsontypes.Interface:
+		switch {
+		case !t0.Sealed && t1.Sealed:
+			ctxt.errorf(ruleBecameSealed, path, "interface has become sealed")
+		case t0.Sealed && !t1.Sealed:
+			ctxt.errorf(ruleBecameUnsealed, path, "interface is no longer sealed")
+		}
+		ctxt.checkTypeSet(t0, t1, path)
 	case jsontypes.Array, jsontypes.Slice:
-		ctxt.check(t0.Elem, t1.Elem, path+"[]")
+		if t0.Kind == jsontypes.Array {
+			ctxt.checkComparable(t0, t1, path)
+		}
+		if t0.Len != t1.Len {
+			ctxt.errorf(ruleLengthChanged, path, "array length changed from %d to %d", t0.Len, t1.Len)
+		}
+		ctxt.check(t0.Elem, t1.Elem, path.with(PathElem{Kind: PathElemType}))
 	case jsontypes.Chan:
-		ctxt.check(t0.Elem, t1.Elem, "(<-"+path+")")
+		ctxt.check(t0.Elem, t1.Elem, path.with(PathElem{Kind: PathChanElem}))
 	case jsontypes.Ptr:
-		ctxt.check(t0.Elem, t1.Elem, "(*"+path+")")
+		ctxt.check(t0.Elem, t1.Elem, path.with(PathElem{Kind: PathDeref}))
 	case jsontypes.Map:
-		ctxt.check(t0.Key, t1.Key, path+"[key]")
-		ctxt.check(t0.Elem, t1.Elem, path+"[]")
+		if k0, k1 := jsonKeyEncoding(ctxt.info0, t0.Key), jsonKeyEncoding(ctxt.info1, t1.Key); k0 != k1 {
+			ctxt.errorf(ruleMapKeyEncoding, path.with(PathElem{Kind: PathKey}), "map key encoding changed from %s to %s (%s vs %s)", k0, k1, describe(ctxt.info0, ctxt.info0.Deref(t0.Key)), describe(ctxt.info1, ctxt.info1.Deref(t1.Key)))
+		} else {
+			ctxt.check(t0.Key, t1.Key, path.with(PathElem{Kind: PathKey}))
+		}
+		ctxt.check(t0.Elem, t1.Elem, path.with(PathElem{Kind: PathElemType}))
 	case jsontypes.Func:
 		if len(t0.In) != len(t1.In) {
-			ctxt.errorf(path, "differing parameter count %d vs %d", len(t0.In), len(t1.In))
+			ctxt.errorf(ruleParamCount, path, "differing parameter count %d vs %d", len(t0.In), len(t1.In))
 		} else {
 			for i := range t0.In {
-				ctxt.check(t0.In[i], t1.In[i], fmt.Sprintf("%s(param %d)", path, i))
+				if ctxt.lenientFuncs && assignable(ctxt.info0, t0.In[i], ctxt.info1, t1.In[i]) {
+					continue
+				}
+				ctxt.check(t0.In[i], t1.In[i], path.with(PathElem{Kind: PathParam, Index: i}))
 			}
 			if t0.Variadic != t1.Variadic {
-				ctxt.errorf(path, "variadic status changed")
+				ctxt.errorf(ruleVariadicChanged, path, "variadic status changed")
 			}
 		}
 		if len(t0.Out) != len(t1.Out) {
-			ctxt.errorf(path, "differing out parameter count %d vs %d", len(t0.Out), len(t1.Out))
+			ctxt.errorf(ruleResultCount, path, "differing out parameter count %d vs %d", len(t0.Out), len(t1.Out))
 		} else {
 			for i := range t0.Out {
-				ctxt.check(t0.Out[i], t1.Out[i], fmt.Sprintf("%s(param %d)", path, i))
+				if ctxt.lenientFuncs && assignable(ctxt.info1, t1.Out[i], ctxt.info0, t0.Out[i]) {
+					continue
+				}
+				ctxt.check(t0.Out[i], t1.Out[i], path.with(PathElem{Kind: PathResult, Index: i}))
 			}
 		}
 	case jsontypes.Struct:
+		ctxt.checkComparable(t0, t1, path)
+		if ctxt.models && isModel(t0) && isModel(t1) {
+			ctxt.checkModel(t0, t1, path)
+		}
+		if ctxt.positional != nil && ctxt.positional(ctxt.info0, t0) {
+			ctxt.checkPositions(t0, t1, path)
+		} else {
+			ctxt.checkFieldOrder(t0, t1, path)
+		}
 		for _, f0 := range t0.Fields {
-			path := path + "." + f0.Name
-			f1 := t1.FieldByName(f0.Name)
-			if f1 == nil {
-				ctxt.errorf(path, "field is missing")
+			if ctxt.introducedLater(f0.Doc) || f0.Ignored || ctxt.unencoded(f0) {
+				continue
+			}
+			path := path.with(PathElem{Kind: PathField, Name: f0.Name, EncodedName: f0.EncodedName})
+			f1 := ctxt.promotedFieldByName(ctxt.info1, t1, f0.Name)
+			if f1 != nil && (f1.Ignored || ctxt.unencoded(f1)) {
 				continue
 			}
-			ctxt.check(f0.Type, f1.Type, path)
-			ctxt.checkTagCompat(f0.Tag, f1.Tag, path)
+			restore := ctxt.setStability(f0.StabilityOf())
+			if f1 == nil {
+				restoreDecls := ctxt.setDecls(ctxt.fieldDecls(f0, nil))
+				ctxt.removed(t0, f0.Name, f0.Doc, path, "field is missing")
+				restoreDecls()
+			} else {
+				restoreDecls := ctxt.setDecls(ctxt.fieldDecls(f0, f1))
+				ctxt.checkConditional(path, "field", "", f0.Platforms, "", f1.Platforms)
+				ctxt.check(f0.Type, f1.Type, path)
+				ctxt.checkTagCompat(f0, f1, path)
+				ctxt.checkDefault(f0, f1, path)
+				ctxt.checkJSSafe(f0, f1, path)
+				restoreDecls()
+			}
+			restore()
+		}
+		for _, f1 := range t1.Fields {
+			if ctxt.promotedFieldByName(ctxt.info0, t0, f1.Name) == nil && !f1.Ignored && !ctxt.unencoded(f1) {
+				restoreDecls := ctxt.setDecls(ctxt.fieldDecls(nil, f1))
+				path := path.with(PathElem{Kind: PathField, Name: f1.Name, EncodedName: f1.EncodedName})
+				if ctxt.enabled(ruleFieldAdded, ctxt.role, ctxt.stability) {
+					ctxt.errorf(ruleFieldAdded, path, "field has been added")
+				} else {
+					ctxt.addition(ruleFieldAdded, path, "field has been added")
+				}
+				if isRequired(ctxt.info1, f1) {
+					ctxt.errorf(ruleRequiredAdded, path, "required field has been added")
+				}
+				ctxt.checkJSSafe(nil, f1, path)
+				restoreDecls()
+			}
 		}
 	}
 
-	for name, m0 := range t0.Methods {
-		m1, ok := t1.Methods[name]
-		if !ok {
-			ctxt.errorf(path, "method %s is missing", name)
+	for _, name := range sortedMethodNames(t0) {
+		m0 := t0.Methods[name]
+		if ctxt.introducedLater(m0.Doc) || m0.Ignored {
+			continue
+		}
+		m1 := ctxt.methodByName(t1, name)
+		if m1 != nil && m1.Ignored {
 			continue
 		}
-		if !m0.PtrReceiver && m1.PtrReceiver {
-			ctxt.errorf(path, "method %s has changed from value to pointer receiver", name)
+		restore := ctxt.setStability(m0.StabilityOf())
+		if m1 == nil {
+			restoreDecls := ctxt.setDecls(ctxt.methodDecls(t0, m0, t1, nil))
+			ctxt.removed(t0, name, m0.Doc, path, fmt.Sprintf("method %s is missing", name))
+			restoreDecls()
+		} else {
+			restoreDecls := ctxt.setDecls(ctxt.methodDecls(t0, m0, t1, m1))
+			ctxt.checkConditional(path.with(PathElem{Kind: PathMethod, Name: name}), "method", m0.Constraint, m0.Platforms, m1.Constraint, m1.Platforms)
+			switch {
+			case !m0.PtrReceiver && m1.PtrReceiver:
+				ctxt.errorf(ruleReceiverChanged, path, "method %s has changed from value to pointer receiver", name)
+			case m0.PtrReceiver && !m1.PtrReceiver:
+				ctxt.errorf(ruleReceiverToValue, path, "method %s has changed from pointer to value receiver", name)
+			}
+			ctxt.check(m0.Type, m1.Type, path.with(PathElem{Kind: PathMethod, Name: name}))
+			restoreDecls()
+		}
+		restore()
+	}
+	for _, name := range sortedMethodNames(t1) {
+		if ctxt.methodByName(t0, name) == nil && !t1.Methods[name].Ignored {
+			restoreDecls := ctxt.setDecls(ctxt.methodDecls(t0, nil, t1, t1.Methods[name]))
+			if implementable(t0) && ctxt.enabled(ruleInterfaceWidened, ctxt.role, ctxt.stability) {
+				ctxt.errorf(ruleInterfaceWidened, path, "method %s has been added to interface", name)
+			} else {
+				ctxt.addition(ruleMethodAdded, path, "method %s has been added", name)
+			}
+			restoreDecls()
 		}
-		ctxt.check(m0.Type, m1.Type, path+"."+name)
 	}
 }
 
-func (ctxt *checkContext) checkTagCompat(tag0, tag1 string, path string) {
-	tags0, tags1 := allTags(tag0), allTags(tag1)
-	for name, val0 := range tags0 {
+// removed reports that the field or method with the given name
+// and doc comment has been removed from t.
+func (ctxt *checkContext) removed(t *jsontypes.Type, name, doc string, path Path, msg string) {
+	rule := ruleFieldRemoved
+	if t.Methods[name] != nil {
+		rule = ruleMethodRemoved
+	}
+	policy := ctxt.deprecation
+	if policy == nil || !ctxt.enabled(rule, ctxt.role, ctxt.stability) {
+		ctxt.errorf(rule, path, "%s", msg)
+		return
+	}
+	switch {
+	case !jsontypes.IsDeprecated(doc):
+		ctxt.errorf(ruleNotDeprecated, path, "%s but was not deprecated", msg)
+	case !policy.deprecatedFor(t.Name, name):
+		ctxt.errorf(ruleNotDeprecated, path, "%s but was deprecated for fewer than %d versions", msg, policy.minVersions)
+	}
+}
+
+// checkDefault checks that the default value of f1 is
+// the same as that of f0, if f0 has one. Clients that
+// omit the field rely on the default.
+func (ctxt *checkContext) checkDefault(f0, f1 *jsontypes.Field, path Path) {
+	switch {
+	case f0.Default == "" || f0.Default == f1.Default:
+	case f1.Default == "":
+		ctxt.errorf(ruleDefaultChanged, path, "default value %q has been removed", f0.Default)
+	default:
+		ctxt.errorf(ruleDefaultChanged, path, "default value changed from %q to %q", f0.Default, f1.Default)
+	}
+}
+
+// checkKindChange checks for the changes of kind that
+// are reported specially, returning true if one was found.
+func (ctxt *checkContext) checkKindChange(t0, t1 *jsontypes.Type, path Path) bool {
+	return ctxt.checkNullability(t0, t1, path) ||
+		ctxt.checkSliceArray(t0, t1, path) ||
+		ctxt.checkMapStruct(t0, t1, path) ||
+		ctxt.checkBytesString(t0, t1, path) ||
+		ctxt.checkNumber(t0, t1, path)
+}
+
+// checkNullability checks whether t0 has changed from a value
+// type to a pointer to the same kind of type or vice versa,
+// and if so, reports that and checks the element type,
+// returning true.
+func (ctxt *checkContext) checkNullability(t0, t1 *jsontypes.Type, path Path) bool {
+	switch {
+	case t0.Kind != jsontypes.Ptr && t1.Kind == jsontypes.Ptr && ctxt.info1.Deref(t1.Elem).Kind == t0.Kind:
+		ctxt.errorf(ruleBecameNullable, path, "type is now nullable (%s vs %s)", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+		ctxt.check(t0, t1.Elem, path)
+	case t0.Kind == jsontypes.Ptr && t1.Kind != jsontypes.Ptr && ctxt.info0.Deref(t0.Elem).Kind == t1.Kind:
+		ctxt.errorf(ruleBecameNonNull, path, "type is no longer nullable (%s vs %s)", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+		ctxt.check(t0.Elem, t1, path)
+	default:
+		return false
+	}
+	return true
+}
+
+// checkSliceArray checks whether t0 has changed from
+// a slice to an array or vice versa, and if so, reports that
+// and checks the element type, returning true. Byte slices
+// are not included because they are encoded as strings.
+func (ctxt *checkContext) checkSliceArray(t0, t1 *jsontypes.Type, path Path) bool {
+	switch {
+	case t0.Kind == jsontypes.Slice && t1.Kind == jsontypes.Array:
+		if isBytes(ctxt.info0, t0) {
+			return false
+		}
+		ctxt.errorf(ruleLengthChanged, path, "slice changed to array of length %d", t1.Len)
+	case t0.Kind == jsontypes.Array && t1.Kind == jsontypes.Slice:
+		if isBytes(ctxt.info1, t1) {
+			return false
+		}
+		ctxt.errorf(ruleLengthChanged, path, "array of length %d changed to slice", t0.Len)
+	default:
+		return false
+	}
+	ctxt.check(t0.Elem, t1.Elem, path.with(PathElem{Kind: PathElemType}))
+	return true
+}
+
+// checkMapStruct checks whether t0 has changed from a map
+// with string keys to a struct with fields of the map's element
+// type, or vice versa, when that is allowed by LenientMapStruct.
+// If so, it reports that, checks the element type and returns true.
+func (ctxt *checkContext) checkMapStruct(t0, t1 *jsontypes.Type, path Path) bool {
+	if !ctxt.enabled(ruleMapStruct, ctxt.role, ctxt.stability) {
+		return false
+	}
+	switch {
+	case t0.Kind == jsontypes.Map && t1.Kind == jsontypes.Struct:
+		names, elem := mapLikeStruct(ctxt.info1, t1)
+		if elem == nil || ctxt.info0.Deref(t0.Key).Kind != jsontypes.String {
+			return false
+		}
+		ctxt.errorf(ruleMapStruct, path, "map changed to struct; keys other than %s will be ignored", strings.Join(names, ", "))
+		ctxt.check(t0.Elem, elem, path.with(PathElem{Kind: PathElemType}))
+	case t0.Kind == jsontypes.Struct && t1.Kind == jsontypes.Map:
+		names, elem := mapLikeStruct(ctxt.info0, t0)
+		if elem == nil || ctxt.info1.Deref(t1.Key).Kind != jsontypes.String {
+			return false
+		}
+		ctxt.errorf(ruleMapStruct, path, "struct changed to map; keys other than %s may now be present", strings.Join(names, ", "))
+		ctxt.check(elem, t1.Elem, path.with(PathElem{Kind: PathElemType}))
+	default:
+		return false
+	}
+	return true
+}
+
+// mapLikeStruct returns the quoted encoded names of the fields
+// of the struct type t and their common type, or a nil type if t
+// has no fields, or if they do not all have the same type
+// and their own encoded names.
+func mapLikeStruct(info *jsontypes.Info, t *jsontypes.Type) ([]string, *jsontypes.Type) {
+	if len(t.Fields) == 0 {
+		return nil, nil
+	}
+	elem := t.Fields[0].Type
+	var names []string
+	for _, f := range t.Fields {
+		if f.EncodedName == "" || !jsontypes.TypeEqual(info, f.Type, info, elem) {
+			return nil, nil
+		}
+		names = append(names, strconv.Quote(f.EncodedName))
+	}
+	return names, elem
+}
+
+// checkBytesString checks whether t0 has changed from a string
+// to a byte slice or vice versa, and if so, reports that and
+// returns true.
+func (ctxt *checkContext) checkBytesString(t0, t1 *jsontypes.Type, path Path) bool {
+	switch {
+	case t0.Kind == jsontypes.String && isBytes(ctxt.info1, t1):
+		ctxt.errorf(ruleBytesString, path, "string changed to byte slice (%s vs %s); byte slices are encoded as base64", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+	case isBytes(ctxt.info0, t0) && t1.Kind == jsontypes.String:
+		ctxt.errorf(ruleBytesString, path, "byte slice changed to string (%s vs %s); byte slices are encoded as base64", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+	default:
+		return false
+	}
+	return true
+}
+
+// jsonKeyEncoding returns how encoding/json encodes map keys
+// of type t: one of "string", "text" (for types implementing
+// encoding.TextMarshaler), "integer" or "unsupported".
+func jsonKeyEncoding(info *jsontypes.Info, t *jsontypes.Type) string {
+	t = info.Deref(t)
+	if t.Kind == jsontypes.String {
+		return "string"
+	}
+	if m := t.Methods["MarshalText"]; (m != nil && !m.PtrReceiver) || t.ImplementsInterface("encoding#TextMarshaler", false) {
+		return "text"
+	}
+	if n, ok := numberKinds[t.Kind]; ok && n.class != floatNumber {
+		return "integer"
+	}
+	return "unsupported"
+}
+
+// isBytes reports whether t is a byte slice.
+func isBytes(info *jsontypes.Info, t *jsontypes.Type) bool {
+	return t.Kind == jsontypes.Slice && info.Deref(t.Elem).Kind == jsontypes.Uint8
+}
+
+// isRequired reports whether the field f in info appears to be
+// required: either it has a "required" validation tag, or it's
+// encoded as JSON and is neither a pointer nor omitted when empty.
+func isRequired(info *jsontypes.Info, f *jsontypes.Field) bool {
+	tags := reflect.StructTag(f.Tag)
+	for _, key := range []string{"validate", "binding"} {
+		for _, opt := range strings.Split(tags.Get(key), ",") {
+			if opt == "required" {
+				return true
+			}
+		}
+	}
+	if f.EncodedName == "" || info.Deref(f.Type).Kind == jsontypes.Ptr {
+		return false
+	}
+	_, opts, _ := strings.Cut(tags.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkImplements checks that t1 still implements all the
+// well-known interfaces that t0 implements.
+func (ctxt *checkContext) checkImplements(t0, t1 *jsontypes.Type, path Path) {
+	for _, name := range t0.Implements {
+		if ptrName := strings.TrimPrefix(string(name), "*"); ptrName != string(name) {
+			if !t1.ImplementsInterface(jsontypes.TypeName(ptrName), true) {
+				ctxt.errorf(ruleInterfaceLost, path, "pointer no longer implements %s", ptrName)
+			}
+			continue
+		}
+		switch {
+		case t1.ImplementsInterface(name, false):
+		case t1.ImplementsInterface(name, true):
+			ctxt.errorf(ruleInterfaceLost, path, "only pointer now implements %s", name)
+		default:
+			ctxt.errorf(ruleInterfaceLost, path, "no longer implements %s", name)
+		}
+	}
+}
+
+// checkConditional checks that an item that was present
+// on all platforms (having no build constraint and no
+// restricted set of platforms) is still present on all
+// platforms.
+func (ctxt *checkContext) checkConditional(path Path, what string, constraint0 string, platforms0 []string, constraint1 string, platforms1 []string) {
+	if constraint0 != "" || len(platforms0) > 0 {
+		return
+	}
+	switch {
+	case constraint1 != "":
+		ctxt.errorf(ruleBecameConditional, path, "%s is now only present when %s", what, constraint1)
+	case len(platforms1) > 0:
+		ctxt.errorf(ruleBecameConditional, path, "%s is now only present on %s", what, strings.Join(platforms1, ", "))
+	}
+}
+
+// setStability sets the stability level for the item about
+// to be checked and returns a function that restores
+// the previous level. The level is determined by the
+// old item, as that's what existing users depend on;
+// an unknown level leaves the current level unchanged.
+func (ctxt *checkContext) setStability(st jsontypes.Stability) (restore func()) {
+	old := ctxt.stability
+	if st != jsontypes.StabilityUnknown {
+		ctxt.stability = st
+	}
+	return func() {
+		ctxt.stability = old
+	}
+}
+
+// roleOf returns the role to use when checking t0 against t1.
+// The new type's role takes precedence.
+func roleOf(t0, t1 *jsontypes.Type) jsontypes.Role {
+	if t1.Role != jsontypes.NoRole {
+		return t1.Role
+	}
+	return t0.Role
+}
+
+// describe returns a description of t suitable for
+// an error message.
+func describe(info *jsontypes.Info, t *jsontypes.Type) string {
+	s := jsontypes.Format(info, t)
+	if t.Name.PkgPath() != "" {
+		s += " (" + string(t.Kind) + ")"
+	}
+	return s
+}
+
+// checkTagCompat checks that the struct tags of the field f1
+// are compatible with those of f0. Encoding tags (see
+// EncodingTags) are compared by checkEncodingTag, and
+// other tags must be unchanged. Tags excluded by CheckTags
+// or IgnoreTags are not checked.
+func (ctxt *checkContext) checkTagCompat(f0, f1 *jsontypes.Field, path Path) {
+	tags0, tags1 := allTags(f0.Tag), allTags(f1.Tag)
+	for _, name := range sortedTagKeys(tags1) {
+		if _, ok := tags0[name]; !ok && ctxt.isEncodingTag(name) && ctxt.checksTag(name) {
+			ctxt.checkEncodingTag(name, f0, f1, path)
+		}
+	}
+	for _, name := range sortedTagKeys(tags0) {
+		val0 := tags0[name]
+		if !ctxt.checksTag(name) {
+			continue
+		}
+		if ctxt.models && ormTagKeys[name] {
+			// Changes to ORM tags are checked by checkModel.
+			continue
+		}
+		if name == "default" {
+			// Changes to defaults are checked by checkDefault.
+			continue
+		}
+		if ctxt.isEncodingTag(name) {
+			ctxt.checkEncodingTag(name, f0, f1, path)
+			continue
+		}
 		if val1 := tags1[name]; val1 != val0 {
-			ctxt.errorf(path, "incompatible tag %s:%q vs %s:%q", name, val0, name, val1)
+			ctxt.errorf(ruleTagChanged, path, "incompatible tag %s:%q vs %s:%q", name, val0, name, val1)
 		}
 	}
 }
 
+// checkEncodingTag checks that the encoding tag with the given key
+// still encodes the field f1 under the same name as f0, and with
+// the same options, in any order.
+func (ctxt *checkContext) checkEncodingTag(key string, f0, f1 *jsontypes.Field, path Path) {
+	tags0, tags1 := reflect.StructTag(f0.Tag), reflect.StructTag(f1.Tag)
+	name0 := jsontypes.EncodedName(f0.Name, f0.Tag, key, isEmbeddedStruct(ctxt.info0, f0))
+	name1 := jsontypes.EncodedName(f1.Name, f1.Tag, key, isEmbeddedStruct(ctxt.info1, f1))
+	switch {
+	case name0 == name1:
+	case name1 == "":
+		ctxt.errorf(ruleTagNameChanged, path, "field is no longer encoded by %s (was encoded as %q)", key, name0)
+	case name0 == "":
+		ctxt.errorf(ruleTagNameChanged, path, "field is now encoded by %s as %q", key, name1)
+	default:
+		ctxt.errorf(ruleTagNameChanged, path, "field is encoded by %s as %q, was %q", key, name1, name0)
+	}
+	_, opts0, _ := strings.Cut(tags0.Get(key), ",")
+	_, opts1, _ := strings.Cut(tags1.Get(key), ",")
+	for _, opt := range strings.Split(opts0, ",") {
+		if opt != "" && !hasTagOption(tags1.Get(key), opt) {
+			ctxt.errorf(ruleTagChanged, path, "%s tag option %q has been removed", key, opt)
+		}
+	}
+	for _, opt := range strings.Split(opts1, ",") {
+		if opt == "" || hasTagOption(tags0.Get(key), opt) {
+			continue
+		}
+		if opt == "omitempty" {
+			ctxt.errorf(ruleOmitEmptyAdded, path, "%s tag option %q has been added", key, opt)
+		} else {
+			ctxt.errorf(ruleTagChanged, path, "%s tag option %q has been added", key, opt)
+		}
+	}
+}
+
+// isEmbeddedStruct reports whether the field f in info is an
+// embedded struct, or a pointer to one, whose fields are
+// promoted by encoders when it has no tag name. Embedded
+// fields of unknown type are assumed to be structs.
+func isEmbeddedStruct(info *jsontypes.Info, f *jsontypes.Field) bool {
+	if !f.Anonymous {
+		return false
+	}
+	t := lookupType(info, derefPtr(f.Type))
+	return t == nil || t.Kind == jsontypes.Struct
+}
+
+// sortedTagKeys returns the keys of the given tags,
+// as returned by allTags, in sorted order.
+func sortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // allTags returns all struct tag values in the given tag
 // as a map from key to value.
 // Note: most of this was copied verbatim from reflect.
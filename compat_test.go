@@ -0,0 +1,166 @@
+package apicompat_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// changes returns the changes reported by CheckAll
+// when checking info1 against info0.
+func changes(info0, info1 *jsontypes.Info, opts ...apicompat.CheckOption) []string {
+	return apicompat.CheckAll(info0, info1, opts...).Changes()
+}
+
+func TestSharedTypeCheckedForEachRole(t *testing.T) {
+	// Shared is reached from a request type, whose fields may
+	// be removed, before it's reached from a response type,
+	// whose fields may not. The types of its fields are
+	// the same in both cases.
+	types := func(inner *jsontypes.Type) *jsontypes.Info {
+		return jsontypes.NewInfo().Add(
+			jsontypes.NewStruct("x#Call").Field("Req", "x#Req").Field("Resp", "x#Resp").Build(),
+			jsontypes.NewStruct("x#Req").Field("S", "x#Shared").Role(jsontypes.RoleRequest).Build(),
+			jsontypes.NewStruct("x#Resp").Field("S", "x#Shared").Role(jsontypes.RoleResponse).Build(),
+			jsontypes.NewStruct("x#Shared").Field("In", "x#Inner").Build(),
+			inner,
+		)
+	}
+	info0 := types(jsontypes.NewStruct("x#Inner").Field("A", "int").Field("B", "int").Build())
+	info1 := types(jsontypes.NewStruct("x#Inner").Field("A", "int").Build())
+	got := changes(info0, info1)
+	want := []string{
+		"x#Call incompatible: .Resp.S.In.B: field is missing\n\told: B int\n\tnew: (none)",
+		"x#Inner incompatible: .B: field is missing\n\told: B int\n\tnew: (none)",
+		"x#Resp incompatible: .S.In.B: field is missing\n\told: B int\n\tnew: (none)",
+		"x#Shared incompatible: .In.B: field is missing\n\told: B int\n\tnew: (none)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestNilType(t *testing.T) {
+	t0 := &jsontypes.Type{Kind: jsontypes.Struct, Fields: []*jsontypes.Field{{Name: "A", Type: jsontypes.MustParse("int")}}}
+	t1 := &jsontypes.Type{Kind: jsontypes.Struct, Fields: []*jsontypes.Field{{Name: "A"}}}
+	info := jsontypes.NewInfo()
+	err := apicompat.Check(info, info, t0, t1, nil)
+	if err == nil || err.Error() != ".A: nil type found" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCheckAllNilInfo(t *testing.T) {
+	info := jsontypes.NewInfo().Add(jsontypes.NewStruct("x#T").Field("A", "int").Build())
+	r := apicompat.CheckAll(nil, info)
+	if want := []jsontypes.TypeName{"x#T"}; !reflect.DeepEqual(r.Added, want) {
+		t.Errorf("got added %q want %q", r.Added, want)
+	}
+	r = apicompat.CheckAll(info, nil)
+	if want := []jsontypes.TypeName{"x#T"}; !reflect.DeepEqual(r.Removed, want) {
+		t.Errorf("got removed %q want %q", r.Removed, want)
+	}
+}
+
+func TestMarshalMethods(t *testing.T) {
+	info0 := jsontypes.NewInfo().Add(jsontypes.NewStruct("x#T").Field("A", "int").Method("MarshalYAML", "func() (interface{}, error)").Build())
+	info1 := jsontypes.NewInfo().Add(jsontypes.NewStruct("x#T").Field("B", "int").Method("MarshalYAML", "func() (interface{}, error)").Build())
+	if got := changes(info0, info1, apicompat.MarshalMethods()); len(got) == 0 {
+		t.Errorf("no changes reported without MarshalYAML")
+	}
+	if got := changes(info0, info1, apicompat.MarshalMethods("MarshalYAML")); len(got) != 0 {
+		t.Errorf("unexpected changes %q", got)
+	}
+}
+
+func TestReportOKWithWarnings(t *testing.T) {
+	info0 := jsontypes.NewInfo().Add(jsontypes.NewStruct("x#T").Field("A", "int").Field("B", "int").Build())
+	info1 := jsontypes.NewInfo().Add(jsontypes.NewStruct("x#T").Field("A", "int").Build())
+	if r := apicompat.CheckAll(info0, info1); r.OK() {
+		t.Errorf("report with breaking change is OK")
+	}
+	r := apicompat.CheckAll(info0, info1, apicompat.SetSeverity("field-removed", apicompat.Warning))
+	if n := r.CountBySeverity()[apicompat.Warning]; n != 1 {
+		t.Errorf("got %d warnings, want 1", n)
+	}
+	if !r.OK() {
+		t.Errorf("report with only warnings is not OK")
+	}
+}
+
+func TestArrayLengthChanged(t *testing.T) {
+	tests := []struct {
+		t0, t1 string
+		want   string
+	}{
+		{"[0]int", "[2]int", "array length changed from 0 to 2"},
+		{"[2]int", "[0]int", "array length changed from 2 to 0"},
+		{"[2]int", "[3]int", "array length changed from 2 to 3"},
+		{"[0]int", "[0]int", ""},
+	}
+	info := jsontypes.NewInfo()
+	for _, test := range tests {
+		err := apicompat.Check(info, info, jsontypes.MustParse(test.t0), jsontypes.MustParse(test.t1), nil)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != test.want {
+			t.Errorf("%s to %s: got %q want %q", test.t0, test.t1, got, test.want)
+		}
+	}
+}
+
+func TestSharedTypeCheckedForEachStability(t *testing.T) {
+	// Shared is reached from an experimental field, which is
+	// not checked, before it's reached from a stable one.
+	types := func(inner *jsontypes.Type) *jsontypes.Info {
+		return jsontypes.NewInfo().Add(
+			jsontypes.NewStruct("x#T").
+				Field("Exp", "x#Shared").
+				Field("S", "x#Shared").Build(),
+			jsontypes.NewStruct("x#Shared").Field("In", "x#Inner").Build(),
+			inner,
+		)
+	}
+	info0 := types(jsontypes.NewStruct("x#Inner").Field("A", "int").Field("B", "int").Build())
+	info0.Types["x#T"].Fields[0].Stability = jsontypes.StabilityExperimental
+	info1 := types(jsontypes.NewStruct("x#Inner").Field("A", "int").Build())
+	got := changes(info0, info1)
+	want := []string{
+		"x#Inner incompatible: .B: field is missing\n\told: B int\n\tnew: (none)",
+		"x#Shared incompatible: .In.B: field is missing\n\told: B int\n\tnew: (none)",
+		"x#T incompatible: .S.In.B: field is missing\n\told: B int\n\tnew: (none)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestDiamondCheckedByPair(t *testing.T) {
+	// The old types a and c are checked against the new
+	// types b and d respectively before a is checked
+	// against d, so every type in the last pair has
+	// already been seen but the pair itself has not.
+	a := jsontypes.MustParse("struct{A int; B int}")
+	b := jsontypes.MustParse("struct{A int; B int}")
+	c := jsontypes.MustParse("struct{A int}")
+	d := jsontypes.MustParse("struct{A int}")
+	t0 := &jsontypes.Type{Kind: jsontypes.Struct, Fields: []*jsontypes.Field{
+		{Name: "X", Type: a},
+		{Name: "Y", Type: c},
+		{Name: "Z", Type: a},
+	}}
+	t1 := &jsontypes.Type{Kind: jsontypes.Struct, Fields: []*jsontypes.Field{
+		{Name: "X", Type: b},
+		{Name: "Y", Type: d},
+		{Name: "Z", Type: d},
+	}}
+	info := jsontypes.NewInfo()
+	err := apicompat.Check(info, info, t0, t1, nil)
+	if err == nil || err.Error() != ".Z.B: field is missing" {
+		t.Errorf("got %v", err)
+	}
+}
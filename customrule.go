@@ -0,0 +1,95 @@
+package apicompat
+
+import "github.com/rogpeppe/apicompat/jsontypes"
+
+// Rule is implemented by additional rules that check each pair
+// of old and new types alongside the built-in rules, such as
+// organization-specific conventions. See ExtraRules.
+//
+// For example, this rule requires fields added to structs
+// to be omitted from JSON when empty:
+//
+//	type newFieldOmitEmpty struct{}
+//
+//	func (newFieldOmitEmpty) ID() string {
+//		return "new-field-omitempty"
+//	}
+//
+//	func (newFieldOmitEmpty) Check(c *apicompat.RuleContext, t0, t1 *jsontypes.Type, path apicompat.Path) {
+//		if t0.Kind != jsontypes.Struct || t1.Kind != jsontypes.Struct {
+//			return
+//		}
+//		for _, f := range t1.Fields {
+//			if t0.FieldByName(f.Name) == nil && !strings.Contains(reflect.StructTag(f.Tag).Get("json"), ",omitempty") {
+//				c.Errorf(path, "new field %s has no omitempty option", f.Name)
+//			}
+//		}
+//	}
+type Rule interface {
+	// ID returns the ID of the rule. It's recorded in the
+	// problems that the rule reports, and can be passed to
+	// options such as DisableRule and SetSeverity. It should
+	// not be the ID of a built-in rule (see Rules).
+	ID() string
+
+	// Check checks that the new type t1 is compatible with
+	// the old type t0, found at the given path, reporting any
+	// problems with c.Errorf. It's called for every pair of
+	// types compared, including the types of fields and
+	// elements, with any type references resolved, so
+	// neither type is nil.
+	Check(c *RuleContext, t0, t1 *jsontypes.Type, path Path)
+}
+
+// RuleContext holds information about the types
+// being checked by a Rule.
+type RuleContext struct {
+	// Info0 and Info1 hold the old and new APIs.
+	Info0, Info1 *jsontypes.Info
+
+	// Role holds the role of the types being checked,
+	// if known (see jsontypes.Role).
+	Role jsontypes.Role
+
+	ctxt *checkContext
+	rule string
+}
+
+// Errorf reports a problem found by the rule at the given path.
+// The problem has the rule's ID and, unless changed with
+// SetSeverity, breaking severity. Like the problems found by
+// built-in rules, it's subject to options such as AllowFunc.
+func (c *RuleContext) Errorf(path Path, format string, args ...interface{}) {
+	c.ctxt.errorf(c.rule, path, format, args...)
+}
+
+// ExtraRules returns an option that applies the given rules
+// in addition to the built-in rules. Like them, they are not
+// applied to experimental items, and each can be disabled
+// with DisableRule.
+func ExtraRules(rules ...Rule) CheckOption {
+	return func(o *checkOptions) {
+		o.extraRules = append(o.extraRules, rules...)
+	}
+}
+
+// checkExtraRules applies any rules added with ExtraRules
+// to t0 and t1, found at the given path.
+func (ctxt *checkContext) checkExtraRules(t0, t1 *jsontypes.Type, path Path) {
+	if t0 == nil || t1 == nil {
+		return
+	}
+	for _, r := range ctxt.extraRules {
+		id := r.ID()
+		if !ctxt.enabled(id, ctxt.role, ctxt.stability) {
+			continue
+		}
+		r.Check(&RuleContext{
+			Info0: ctxt.info0,
+			Info1: ctxt.info1,
+			Role:  ctxt.role,
+			ctxt:  ctxt,
+			rule:  id,
+		}, t0, t1, path)
+	}
+}
@@ -0,0 +1,226 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Exemption describes a known incompatibility that should
+// not be reported, usually because it has been accepted
+// for the time being. An exemption can be given an expiry
+// date or a target version, after which the incompatibility
+// is reported again, so that temporary exemptions
+// cannot be forgotten.
+type Exemption struct {
+	// Type holds the name of the type or function
+	// containing the problem.
+	Type jsontypes.TypeName
+
+	// Rule holds the ID of the rule that found the problem.
+	// If it's empty, problems found by any rule are exempt.
+	Rule string `json:",omitempty"`
+
+	// Path holds the path of the problem as shown in the
+	// report. If it's empty, problems at any path are exempt.
+	Path string `json:",omitempty"`
+
+	// Reason holds the reason for the exemption.
+	Reason string `json:",omitempty"`
+
+	// Expires holds the date, in the form "2006-01-02", from
+	// which the exemption no longer applies, if any.
+	Expires string `json:",omitempty"`
+
+	// Until holds the version of the new API, such as "v2.0.0",
+	// from which the exemption no longer applies, if any.
+	Until string `json:",omitempty"`
+}
+
+// ParseExemptions parses a JSON array of exemptions.
+func ParseExemptions(data []byte) ([]*Exemption, error) {
+	var exemptions []*Exemption
+	if err := json.Unmarshal(data, &exemptions); err != nil {
+		return nil, err
+	}
+	for i, e := range exemptions {
+		if e.Type == "" {
+			return nil, fmt.Errorf("exemption %d has no type", i)
+		}
+		if e.Expires != "" {
+			if _, err := time.Parse("2006-01-02", e.Expires); err != nil {
+				return nil, fmt.Errorf("exemption %d has invalid expiry date: %v", i, err)
+			}
+		}
+		if e.Until != "" && !semver.IsValid(e.Until) {
+			return nil, fmt.Errorf("exemption %d has invalid version %q", i, e.Until)
+		}
+	}
+	return exemptions, nil
+}
+
+// Expired reports whether the exemption no longer applies at the
+// given time to an API with the given version, which may be empty
+// if it's unknown.
+func (e *Exemption) Expired(now time.Time, version string) bool {
+	if e.Expires != "" {
+		expires, err := time.Parse("2006-01-02", e.Expires)
+		if err != nil || !now.Before(expires) {
+			return true
+		}
+	}
+	if e.Until != "" && version != "" && semver.Compare(version, e.Until) >= 0 {
+		return true
+	}
+	return false
+}
+
+// describe returns a description of why the exemption
+// has expired.
+func (e *Exemption) describe(version string) string {
+	what := string(e.Type)
+	if e.Rule != "" {
+		what += " (" + e.Rule + ")"
+	}
+	if e.Until != "" && version != "" && semver.Compare(version, e.Until) >= 0 {
+		return fmt.Sprintf("exemption for %s applied only before %s", what, e.Until)
+	}
+	return fmt.Sprintf("exemption for %s expired on %s", what, e.Expires)
+}
+
+// matches reports whether the exemption applies to the
+// problem with the given rule and path found in the
+// named type or function.
+func (e *Exemption) matches(name jsontypes.TypeName, rule, path string) bool {
+	return e.Type == name && (e.Rule == "" || e.Rule == rule) && (e.Path == "" || e.Path == path)
+}
+
+// Exempt removes from r all the removed types and functions and
+// all the problems found in types and functions that match one of
+// the exemptions, unless the exemption has expired at the given
+// time or for the version of the new API. A warning is added
+// to r.Warnings for each expired exemption that would
+// otherwise have applied.
+func (r *Report) Exempt(exemptions []*Exemption, now time.Time) {
+	var version string
+	if r.New != nil {
+		version = r.New.Version
+	}
+	warned := make(map[*Exemption]bool)
+	exempt := func(name jsontypes.TypeName, rule, path string) bool {
+		for _, e := range exemptions {
+			if !e.matches(name, rule, path) {
+				continue
+			}
+			if !e.Expired(now, version) {
+				return true
+			}
+			if !warned[e] {
+				warned[e] = true
+				r.Warnings = append(r.Warnings, e.describe(version))
+			}
+		}
+		return false
+	}
+	r.Removed = exemptNames(r.Removed, func(name jsontypes.TypeName) bool {
+		return exempt(name, ruleTypeRemoved, "")
+	})
+	r.RemovedFuncs = exemptNames(r.RemovedFuncs, func(name jsontypes.TypeName) bool {
+		return exempt(name, ruleFuncRemoved, "")
+	})
+	r.RemovedVars = exemptNames(r.RemovedVars, func(name jsontypes.TypeName) bool {
+		return exempt(name, ruleVarRemoved, "")
+	})
+	r.RemovedConsts = exemptNames(r.RemovedConsts, func(name jsontypes.TypeName) bool {
+		return exempt(name, ruleConstRemoved, "")
+	})
+	incompatible := r.Incompatible[:0]
+	for _, tr := range r.Incompatible {
+		errs := tr.Errors[:0]
+		for _, err := range tr.Errors {
+			var rule, path string
+			if p, ok := err.(*Problem); ok {
+				rule = p.Rule
+				path = p.formattedPath
+				if path == "" {
+					path = GoPath(p.Path)
+				}
+			}
+			if !exempt(tr.Name, rule, path) {
+				errs = append(errs, err)
+			}
+		}
+		tr.Errors = errs
+		if len(errs) > 0 {
+			incompatible = append(incompatible, tr)
+		}
+	}
+	r.Incompatible = incompatible
+}
+
+// Baseline returns an exemption for each of the removed types,
+// functions, variables and constants and each of the problems
+// in r that Exempt can remove, in the order they are reported,
+// so that they are not reported again when the exemptions are
+// passed to Exempt. It can be used to accept the existing
+// incompatibilities, as when adopting apicompat for an API
+// with known problems.
+func (r *Report) Baseline() []*Exemption {
+	exemptions := []*Exemption{}
+	seen := make(map[Exemption]bool)
+	add := func(name jsontypes.TypeName, rule, path string) {
+		e := Exemption{
+			Type: name,
+			Rule: rule,
+			Path: path,
+		}
+		if !seen[e] {
+			seen[e] = true
+			exemptions = append(exemptions, &e)
+		}
+	}
+	for _, removed := range []struct {
+		names []jsontypes.TypeName
+		rule  string
+	}{
+		{r.Removed, ruleTypeRemoved},
+		{r.RemovedFuncs, ruleFuncRemoved},
+		{r.RemovedVars, ruleVarRemoved},
+		{r.RemovedConsts, ruleConstRemoved},
+	} {
+		for _, name := range removed.names {
+			add(name, removed.rule, "")
+		}
+	}
+	for _, tr := range r.Incompatible {
+		for _, err := range tr.Errors {
+			p, ok := err.(*Problem)
+			if !ok {
+				// Other errors can only be
+				// exempted by type.
+				add(tr.Name, "", "")
+				continue
+			}
+			path := p.formattedPath
+			if path == "" {
+				path = GoPath(p.Path)
+			}
+			add(tr.Name, p.Rule, path)
+		}
+	}
+	return exemptions
+}
+
+func exemptNames(names []jsontypes.TypeName, exempt func(jsontypes.TypeName) bool) []jsontypes.TypeName {
+	var kept []jsontypes.TypeName
+	for _, name := range names {
+		if !exempt(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,130 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// FacadeReport holds the incompatibilities found
+// in a version of an RPC facade.
+type FacadeReport struct {
+	Name    string
+	Version int
+	Errors  []error
+}
+
+// CheckFacade checks that version f1 of an RPC facade (from info1)
+// is backwardly compatible with f0 (from info0), which is usually
+// the same version of the facade from an older API. All the
+// methods of f0 must be present in f1. As the new server
+// decodes parameters sent by old clients, parameter types are
+// checked as requests; similarly result types are checked as
+// responses. The options are as for Check.
+func CheckFacade(info0, info1 *jsontypes.Info, f0, f1 *jsontypes.Facade, opts ...CheckOption) error {
+	ctxt := checkContext{
+		checkOptions: newCheckOptions(opts),
+		info0:        info0,
+		info1:        info1,
+		checked:      make(map[checkKey]bool),
+	}
+	for _, name := range sortedFacadeMethods(f0) {
+		m0, m1 := f0.Methods[name], f1.Methods[name]
+		path := Path{{Kind: PathMethod, Name: name}}
+		restore := ctxt.setStability(m0.Stability)
+		if m1 == nil {
+			if !jsontypes.IsDeprecated(m0.Doc) || ctxt.deprecation == nil {
+				ctxt.errorf(ruleFacadeMethodRemoved, path, "method %s is missing", name)
+			}
+			restore()
+			continue
+		}
+		ctxt.checkFacadeValue(m0.Params, m1.Params, jsontypes.RoleRequest, path.with(PathElem{Kind: PathParam}), "parameters")
+		ctxt.checkFacadeValue(m0.Result, m1.Result, jsontypes.RoleResponse, path.with(PathElem{Kind: PathResult}), "result")
+		restore()
+	}
+	if len(ctxt.errors) > 0 {
+		return &CheckError{
+			Errors: ctxt.errors,
+		}
+	}
+	return nil
+}
+
+// checkFacadeValue checks the parameters or result of a
+// facade method, using the given role for types without one.
+func (ctxt *checkContext) checkFacadeValue(t0, t1 *jsontypes.Type, role jsontypes.Role, path Path, what string) {
+	switch {
+	case t0 == nil && t1 == nil:
+		return
+	case t0 == nil:
+		if role == jsontypes.RoleRequest {
+			ctxt.errorf(ruleFacadeSignature, path, "%s added", what)
+		}
+		return
+	case t1 == nil:
+		if role == jsontypes.RoleResponse {
+			ctxt.errorf(ruleFacadeSignature, path, "%s removed", what)
+		}
+		return
+	}
+	defer func(old jsontypes.Role) {
+		ctxt.role = old
+	}(ctxt.role)
+	ctxt.role = role
+	ctxt.check(t0, t1, path)
+}
+
+// checkFacades checks all the facades in info1 against
+// those in info0, adding the results to r.
+func (o *checkOptions) checkFacades(r *Report, info0, info1 *jsontypes.Info, opts []CheckOption) {
+	versions := make(map[string]bool)
+	for _, f := range info1.Facades {
+		versions[f.Name] = true
+	}
+	for _, f0 := range info0.Facades {
+		f1 := info1.Facade(f0.Name, f0.Version)
+		var err error
+		switch {
+		case f1 != nil:
+			err = CheckFacade(info0, info1, f0, f1, opts...)
+		case jsontypes.StabilityFromDoc(f0.Doc) == jsontypes.StabilityExperimental:
+		case versions[f0.Name]:
+			err = o.facadeError(ruleFacadeVersionRemoved, "version %d has been removed", f0.Version)
+		default:
+			err = o.facadeError(ruleFacadeVersionRemoved, "facade has been removed")
+		}
+		if err != nil {
+			r.Facades = append(r.Facades, &FacadeReport{
+				Name:    f0.Name,
+				Version: f0.Version,
+				Errors:  err.(*CheckError).Errors,
+			})
+		}
+	}
+}
+
+// facadeError returns an error about a facade, service
+// or route as a whole, or nil if the rule is disabled.
+func (o *checkOptions) facadeError(rule string, msg string, a ...interface{}) error {
+	if !o.enabled(rule, jsontypes.NoRole, jsontypes.StabilityUnknown) {
+		return nil
+	}
+	return &CheckError{
+		Errors: []error{&Problem{
+			Rule:     rule,
+			Severity: o.severity(rule),
+			Message:  fmt.Sprintf(msg, a...),
+		}},
+	}
+}
+
+func sortedFacadeMethods(f *jsontypes.Facade) []string {
+	names := make([]string, 0, len(f.Methods))
+	for name := range f.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,142 @@
+package apicompat
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// setTypeParams sets the type parameters of the generic types
+// being checked and returns a function that restores the
+// previous ones.
+func (ctxt *checkContext) setTypeParams(tparams0, tparams1 []*jsontypes.TypeParam) (restore func()) {
+	old0, old1 := ctxt.tparams0, ctxt.tparams1
+	ctxt.tparams0, ctxt.tparams1 = tparams0, tparams1
+	return func() {
+		ctxt.tparams0, ctxt.tparams1 = old0, old1
+	}
+}
+
+// checkTypeParams checks that the generic type t1 can be
+// instantiated with all the type arguments that t0 can: it
+// must have the same number of type parameters, and the
+// constraint on each must be no stricter than before.
+func (ctxt *checkContext) checkTypeParams(t0, t1 *jsontypes.Type, path Path) {
+	if len(t0.TypeParams) != len(t1.TypeParams) {
+		ctxt.errorf(ruleTypeParamCount, path, "differing type parameter count %d vs %d", len(t0.TypeParams), len(t1.TypeParams))
+		return
+	}
+	for i, tp0 := range t0.TypeParams {
+		ctxt.checkConstraint(tp0, t1.TypeParams[i], path)
+	}
+}
+
+// checkConstraint checks that the constraint on the type
+// parameter tp1 is satisfied by all the types that satisfy
+// the constraint on tp0.
+func (ctxt *checkContext) checkConstraint(tp0, tp1 *jsontypes.TypeParam, path Path) {
+	c0, c1 := lookupType(ctxt.info0, tp0.Constraint), lookupType(ctxt.info1, tp1.Constraint)
+	if c0 == nil || c1 == nil {
+		return
+	}
+	if isComparable(c1) && !isComparable(c0) {
+		ctxt.errorf(ruleConstraintNarrowed, path, "type parameter %s now requires comparable types", tp1.Name)
+	}
+	if c0.Kind != jsontypes.Interface || c1.Kind != jsontypes.Interface {
+		return
+	}
+	for _, name := range sortedMethodNames(c1) {
+		m0 := c0.Methods[name]
+		if m0 == nil || jsontypes.Format(nil, m0.Type) != jsontypes.Format(nil, c1.Methods[name].Type) {
+			ctxt.errorf(ruleConstraintNarrowed, path, "type parameter %s now requires method %s", tp1.Name, jsontypes.FormatMethod(ctxt.info1, c1, c1.Methods[name]))
+		}
+	}
+	if len(c1.Terms) == 0 {
+		return
+	}
+	if len(c0.Terms) == 0 {
+		ctxt.errorf(ruleConstraintNarrowed, path, "type parameter %s is now restricted to %s", tp1.Name, ctxt.formatTerms(ctxt.info1, c1.Terms))
+		return
+	}
+	for _, term0 := range c0.Terms {
+		if !ctxt.termIncluded(term0, c1.Terms) {
+			ctxt.errorf(ruleConstraintNarrowed, path, "type parameter %s no longer allows %s", tp1.Name, ctxt.formatTerms(ctxt.info0, []*jsontypes.Term{term0}))
+		}
+	}
+}
+
+// isComparable reports whether the constraint c is
+// the predeclared comparable interface.
+func isComparable(c *jsontypes.Type) bool {
+	return c.Name == "comparable"
+}
+
+// isTypeParamRef reports whether t refers to a type parameter,
+// or to an instantiation within the definition of a generic
+// type that has no definition of its own.
+func isTypeParamRef(info *jsontypes.Info, t *jsontypes.Type) bool {
+	if t.Kind == jsontypes.TypeParameter {
+		return true
+	}
+	return t.Kind == "" && info.Types[t.Name] == nil && len(t.Name.TypeArgs()) > 0
+}
+
+// checkTypeParamRef checks that t0 and t1, at least one of
+// which satisfies isTypeParamRef, refer to the same types.
+// Type parameters are compared by position rather than name,
+// so that they can be renamed.
+func (ctxt *checkContext) checkTypeParamRef(t0, t1 *jsontypes.Type, path Path) {
+	if t0.Kind != t1.Kind || bindTypeParams(t0.Name, ctxt.tparams0) != bindTypeParams(t1.Name, ctxt.tparams1) {
+		ctxt.errorf(ruleKindChanged, path, "incompatible types %s vs %s", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+	}
+}
+
+// bindTypeParams returns name with each occurrence of the
+// name of one of the given type parameters replaced by its
+// position, such as "$0".
+func bindTypeParams(name jsontypes.TypeName, tparams []*jsontypes.TypeParam) string {
+	if len(tparams) == 0 {
+		return string(name)
+	}
+	index := make(map[string]int)
+	for i, tp := range tparams {
+		index[tp.Name] = i
+	}
+	s := string(name)
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isIdentRune(r) {
+			buf.WriteRune(r)
+			i += size
+			continue
+		}
+		j := i
+		for j < len(s) {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if !isIdentRune(r) {
+				break
+			}
+			j += size
+		}
+		word := s[i:j]
+		// Type parameters are never qualified, so a word that is
+		// part of a package path or follows one is left alone.
+		before := i == 0 || strings.ContainsRune("[], *(", rune(s[i-1]))
+		after := j == len(s) || strings.ContainsRune("[], )", rune(s[j]))
+		if n, ok := index[word]; ok && before && after {
+			buf.WriteString("$" + strconv.Itoa(n))
+		} else {
+			buf.WriteString(word)
+		}
+		i = j
+	}
+	return buf.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,65 @@
+package apicompat
+
+import (
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// checkComparable checks that the struct or array type t1 is
+// comparable with == if t0 was, because Go code may compare
+// values of the type or use them as map keys.
+func (ctxt *checkContext) checkComparable(t0, t1 *jsontypes.Type, path Path) {
+	if !ctxt.enabled(ruleComparabilityLost, ctxt.role, ctxt.stability) {
+		return
+	}
+	if isComparableType(ctxt.info0, t0, nil) && !isComparableType(ctxt.info1, t1, nil) {
+		ctxt.errorf(ruleComparabilityLost, path, "type is no longer comparable")
+	}
+}
+
+// isComparableType reports whether values of type t can be
+// compared with ==. Only the exported fields of structs are
+// recorded, so a struct with an incomparable unexported field
+// is taken to be comparable. The seen map holds the named types
+// already being considered, so that recursive types terminate.
+func isComparableType(info *jsontypes.Info, t *jsontypes.Type, seen map[jsontypes.TypeName]bool) bool {
+	t = info.Deref(t)
+	if t == nil {
+		return true
+	}
+	if t.Name != "" {
+		if seen[t.Name] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[jsontypes.TypeName]bool)
+		}
+		seen[t.Name] = true
+	}
+	switch t.Kind {
+	case jsontypes.Slice, jsontypes.Map, jsontypes.Func:
+		return false
+	case jsontypes.Array:
+		return isComparableType(info, t.Elem, seen)
+	case jsontypes.Struct:
+		for _, f := range t.Fields {
+			if !isComparableType(info, f.Type, seen) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// implementable reports whether the old type t0 is an interface
+// that types outside its package may implement, so that adding
+// methods to it breaks them. Sealed interfaces cannot be
+// implemented elsewhere, and interfaces documented as "not for
+// implementation" (in any case) are not expected to be.
+func implementable(t0 *jsontypes.Type) bool {
+	if t0.Kind != jsontypes.Interface || t0.Sealed {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(t0.Doc), "not for implementation")
+}
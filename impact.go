@@ -0,0 +1,108 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// impact returns a map from each of the given type names to the
+// root types in info that refer to it, directly or indirectly,
+// in sorted order. A type is not included in its own entry,
+// and names that affect no other root type are omitted.
+//
+// The root types are the given roots that are present in info or,
+// if there are none, the types not referred to by any other type.
+func impact(info *jsontypes.Info, roots []jsontypes.TypeName, names []jsontypes.TypeName) map[jsontypes.TypeName][]jsontypes.TypeName {
+	// referrers maps each type name to the names of the
+	// types that refer to it directly.
+	referrers := make(map[jsontypes.TypeName][]jsontypes.TypeName)
+	for _, name := range sortedNames(info) {
+		for _, ref := range info.References(info.Types[name]) {
+			if ref != name {
+				referrers[ref] = append(referrers[ref], name)
+			}
+		}
+	}
+	isRoot := make(map[jsontypes.TypeName]bool)
+	for _, name := range roots {
+		if info.Types[name] != nil {
+			isRoot[name] = true
+		}
+	}
+	if len(isRoot) == 0 {
+		for name := range info.Types {
+			if len(referrers[name]) == 0 {
+				isRoot[name] = true
+			}
+		}
+	}
+	result := make(map[jsontypes.TypeName][]jsontypes.TypeName)
+	for _, name := range names {
+		seen := map[jsontypes.TypeName]bool{name: true}
+		var affected []jsontypes.TypeName
+		queue := []jsontypes.TypeName{name}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, ref := range referrers[n] {
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				queue = append(queue, ref)
+				if isRoot[ref] {
+					affected = append(affected, ref)
+				}
+			}
+		}
+		if len(affected) > 0 {
+			sort.Slice(affected, func(i, j int) bool {
+				return affected[i] < affected[j]
+			})
+			result[name] = affected
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// maxImpactNames holds the maximum number of affected
+// types named by each line returned by ImpactSummary.
+const maxImpactNames = 5
+
+// ImpactSummary returns a line for each entry in r.Impact
+// describing the root types affected by the change to the
+// type, for example "changing foo.Address affects 14 root types:
+// foo.CreateUserRequest, foo.User, ...", sorted by type name.
+func (r *Report) ImpactSummary() []string {
+	names := make([]jsontypes.TypeName, 0, len(r.Impact))
+	for name := range r.Impact {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	lines := make([]string, len(names))
+	for i, name := range names {
+		affected := r.Impact[name]
+		shown := make([]string, 0, maxImpactNames+1)
+		for j, a := range affected {
+			if j == maxImpactNames {
+				shown = append(shown, "...")
+				break
+			}
+			shown = append(shown, jsontypes.Format(nil, &jsontypes.Type{Name: a}))
+		}
+		noun := "types"
+		if len(affected) == 1 {
+			noun = "type"
+		}
+		lines[i] = fmt.Sprintf("changing %s affects %d root %s: %s", jsontypes.Format(nil, &jsontypes.Type{Name: name}), len(affected), noun, strings.Join(shown, ", "))
+	}
+	return lines
+}
@@ -0,0 +1,217 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// JSONReportVersion holds the version of the format of
+// JSONReport. It will be incremented if fields are removed
+// or their meaning changes; new fields may be added at
+// any time.
+const JSONReportVersion = 1
+
+// JSONReport holds a Report in a stable form suitable for
+// encoding as JSON for consumption by other tools, such as
+// CI bots and dashboards. Unlike Report, it holds a single
+// flat list of problems, each self-contained.
+type JSONReport struct {
+	// FormatVersion holds JSONReportVersion.
+	FormatVersion int
+
+	// Old and New hold the metadata from the old
+	// and new APIs, if known.
+	Old *jsontypes.Meta `json:",omitempty"`
+	New *jsontypes.Meta `json:",omitempty"`
+
+	// OK holds whether the new API is backwardly compatible
+	// with the old one (see Report.OK).
+	OK bool
+
+	// Bump holds the suggested version bump
+	// (see Report.SuggestedBump).
+	Bump VersionBump
+
+	// Counts holds the number of changes of each
+	// severity (see Report.CountBySeverity).
+	Counts map[Severity]int
+
+	// Warnings holds any warnings about the snapshots
+	// themselves (see Report.Warnings).
+	Warnings []string `json:",omitempty"`
+
+	// Added holds the names of the types, functions, variables
+	// and constants that have been added, in that order.
+	Added []string `json:",omitempty"`
+
+	// Problems holds all the problems found, with the items
+	// in the same order as in the lines returned by
+	// Report.Changes, followed by any additions to existing
+	// types. The problems for each item are sorted by path,
+	// then rule and then message, so that the report is the
+	// same each time it is generated.
+	Problems []*JSONProblem
+}
+
+// JSONProblem describes a single problem in a JSONReport.
+type JSONProblem struct {
+	// Rule holds the ID of the rule that found the problem.
+	Rule string
+
+	// Severity holds the severity of the problem.
+	Severity Severity
+
+	// Item holds the name of the type, function, variable or
+	// constant containing the problem, such as
+	// "example.com/foo#Request", or a description of the
+	// facade, service or route, such as "GET /users/{id}".
+	Item string
+
+	// Package holds the import path of the package
+	// containing Item, if any.
+	Package string `json:",omitempty"`
+
+	// Path holds the location of the problem within Item,
+	// in structured form and formatted as in messages.
+	Path          Path   `json:",omitempty"`
+	FormattedPath string `json:",omitempty"`
+
+	// Message describes the problem, not including the path.
+	Message string
+
+	// OldDecl and NewDecl hold the old and new declarations
+	// containing the problem, as for Problem.
+	OldDecl string `json:",omitempty"`
+	NewDecl string `json:",omitempty"`
+
+	// OldType and NewType hold the names of the innermost
+	// old and new types involved, or Go-like renderings of
+	// them if they are unnamed, when known.
+	OldType string `json:",omitempty"`
+	NewType string `json:",omitempty"`
+
+	// Suggestion holds a suggested remediation, if any.
+	Suggestion string `json:",omitempty"`
+}
+
+// NewJSONReport returns the JSONReport form of r.
+func NewJSONReport(r *Report) *JSONReport {
+	jr := &JSONReport{
+		FormatVersion: JSONReportVersion,
+		Old:           r.Old,
+		New:           r.New,
+		OK:            r.OK(),
+		Bump:          r.SuggestedBump(),
+		Counts:        r.CountBySeverity(),
+		Warnings:      r.Warnings,
+		Problems:      []*JSONProblem{},
+	}
+	for _, names := range [][]jsontypes.TypeName{r.Added, r.AddedFuncs, r.AddedVars, r.AddedConsts} {
+		for _, name := range names {
+			jr.Added = append(jr.Added, string(name))
+		}
+	}
+	removed := func(names []jsontypes.TypeName, rule, what string) {
+		for _, name := range names {
+			jr.Problems = append(jr.Problems, &JSONProblem{
+				Rule:     rule,
+				Severity: r.severity(rule),
+				Item:     string(name),
+				Package:  name.PkgPath(),
+				Message:  what + " has gone away",
+			})
+		}
+	}
+	removed(r.Removed, ruleTypeRemoved, "type")
+	removed(r.RemovedFuncs, ruleFuncRemoved, "function")
+	removed(r.RemovedVars, ruleVarRemoved, "variable")
+	removed(r.RemovedConsts, ruleConstRemoved, "constant")
+	add := func(item, pkg string, errs []error) {
+		problems := make([]*JSONProblem, len(errs))
+		for i, err := range errs {
+			problems[i] = newJSONProblem(item, pkg, err)
+		}
+		sortJSONProblems(problems)
+		jr.Problems = append(jr.Problems, problems...)
+	}
+	for _, tr := range r.Incompatible {
+		add(string(tr.Name), tr.Name.PkgPath(), tr.Errors)
+	}
+	for _, fr := range r.Facades {
+		add(fmt.Sprintf("facade %s v%d", fr.Name, fr.Version), "", fr.Errors)
+	}
+	for _, sr := range r.Services {
+		add("service "+sr.Name, "", sr.Errors)
+	}
+	for _, rr := range r.Routes {
+		add(rr.Method+" "+rr.Path, "", rr.Errors)
+	}
+	for _, tr := range r.Additions {
+		add(string(tr.Name), tr.Name.PkgPath(), tr.Errors)
+	}
+	return jr
+}
+
+// sortJSONProblems sorts the given problems by
+// path, then rule and then message.
+func sortJSONProblems(problems []*JSONProblem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		p0, p1 := problems[i], problems[j]
+		if p0.FormattedPath != p1.FormattedPath {
+			return p0.FormattedPath < p1.FormattedPath
+		}
+		if p0.Rule != p1.Rule {
+			return p0.Rule < p1.Rule
+		}
+		return p0.Message < p1.Message
+	})
+}
+
+// newJSONProblem returns the JSONProblem form of err,
+// found in the given item.
+func newJSONProblem(item, pkg string, err error) *JSONProblem {
+	p, ok := err.(*Problem)
+	if !ok {
+		return &JSONProblem{
+			Severity: Breaking,
+			Item:     item,
+			Package:  pkg,
+			Message:  err.Error(),
+		}
+	}
+	jp := &JSONProblem{
+		Rule:          p.Rule,
+		Severity:      p.Severity,
+		Item:          item,
+		Package:       pkg,
+		Path:          p.Path,
+		FormattedPath: p.formattedPath,
+		Message:       p.Message,
+		OldDecl:       p.OldDecl,
+		NewDecl:       p.NewDecl,
+		OldType:       typeString(p.Old),
+		NewType:       typeString(p.New),
+		Suggestion:    p.Suggestion,
+	}
+	if jp.Severity == "" {
+		jp.Severity = ruleSeverity(p.Rule)
+	}
+	if jp.FormattedPath == "" {
+		jp.FormattedPath = GoPath(p.Path)
+	}
+	return jp
+}
+
+// typeString returns the name of t, or a Go-like
+// rendering of it if it's unnamed.
+func typeString(t *jsontypes.Type) string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Name != "":
+		return string(t.Name)
+	}
+	return jsontypes.Format(nil, t)
+}
@@ -0,0 +1,221 @@
+package jsontypes
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Builder provides a concise way of constructing named types,
+// primarily for tests. For example:
+//
+//	info := jsontypes.NewInfo()
+//	info.Add(
+//		jsontypes.NewStruct("example.com/foo#T").
+//			Field("Name", jsontypes.String, `json:"name"`).
+//			Field("Items", "[]example.com/foo#Item").
+//			Method("String", "func() string").
+//			Build(),
+//	)
+//
+// Methods that take a type argument of type interface{} accept any
+// of the following:
+//
+//	Kind - a predeclared type of that kind, such as jsontypes.Int.
+//	*Type - the type itself, or a reference to it if it's a named type.
+//	*Builder - as for *Type, using the type being built.
+//	TypeName - a reference to the named type.
+//	string - a type expression as accepted by Parse.
+//
+// Builder methods panic if given invalid arguments.
+type Builder struct {
+	t *Type
+}
+
+// NewStruct returns a builder for a struct type
+// with the given name.
+func NewStruct(name TypeName) *Builder {
+	return &Builder{&Type{
+		Name: name,
+		Kind: Struct,
+	}}
+}
+
+// NewInterface returns a builder for an interface
+// type with the given name.
+func NewInterface(name TypeName) *Builder {
+	return &Builder{&Type{
+		Name: name,
+		Kind: Interface,
+	}}
+}
+
+// NewType returns a builder for a type with the given name
+// and underlying type, for example:
+//
+//	jsontypes.NewType("example.com/foo#Color", jsontypes.String)
+func NewType(name TypeName, underlying interface{}) *Builder {
+	t := *typeOf(underlying, false)
+	t.Name = name
+	return &Builder{&t}
+}
+
+// Field adds a field to the struct type. If a tag is
+// provided, it is used as the field's tag.
+func (b *Builder) Field(name string, typ interface{}, tag ...string) *Builder {
+	return b.addField(&Field{
+		Name: name,
+		Type: typeOf(typ, true),
+	}, tag)
+}
+
+// Embed adds an embedded field of the given type,
+// which must be a named type or a pointer to one.
+func (b *Builder) Embed(typ interface{}, tag ...string) *Builder {
+	t := typeOf(typ, true)
+	name := t.Name
+	if t.Kind == Ptr {
+		name = t.Elem.Name
+	}
+	if name == "" {
+		panic(fmt.Errorf("cannot embed unnamed type %v", t))
+	}
+	return b.addField(&Field{
+		Name:      name.Name(),
+		Type:      t,
+		Anonymous: true,
+	}, tag)
+}
+
+func (b *Builder) addField(f *Field, tag []string) *Builder {
+	if b.t.Kind != Struct {
+		panic(fmt.Errorf("field added to non-struct type %s", b.t.Name))
+	}
+	if len(tag) > 0 {
+		f.Tag = tag[0]
+		f.Default = reflect.StructTag(f.Tag).Get("default")
+	}
+	f.Index = len(b.t.Fields)
+	f.EncodedName = encodedName(f)
+	b.t.Fields = append(b.t.Fields, f)
+	return b
+}
+
+// Method adds a method with a value receiver and the given
+// signature, which must be a function type.
+func (b *Builder) Method(name string, sig interface{}) *Builder {
+	return b.addMethod(name, sig, false)
+}
+
+// PtrMethod adds a method with a pointer receiver
+// and the given signature.
+func (b *Builder) PtrMethod(name string, sig interface{}) *Builder {
+	return b.addMethod(name, sig, true)
+}
+
+// Union sets the terms of the union that restricts the type
+// set of an interface type. Each term is a type expression as
+// accepted by Parse, optionally preceded by ~, for example:
+//
+//	jsontypes.NewInterface("example.com/foo#Number").Union("~int", "~float64")
+func (b *Builder) Union(terms ...string) *Builder {
+	b.t.Terms = nil
+	for _, term := range terms {
+		tilde := strings.HasPrefix(term, "~")
+		b.t.Terms = append(b.t.Terms, &Term{
+			Tilde: tilde,
+			Type:  typeOf(strings.TrimPrefix(term, "~"), true),
+		})
+	}
+	return b
+}
+
+func (b *Builder) addMethod(name string, sig interface{}, ptr bool) *Builder {
+	t := typeOf(sig, true)
+	if t.Kind != Func {
+		panic(fmt.Errorf("method %s has non-function type %v", name, t))
+	}
+	if b.t.Methods == nil {
+		b.t.Methods = make(map[string]*Method)
+	}
+	b.t.Methods[name] = &Method{
+		Name:        name,
+		Type:        t,
+		PtrReceiver: ptr && b.t.Kind != Interface,
+	}
+	return b
+}
+
+// Role sets the role of the type.
+func (b *Builder) Role(role Role) *Builder {
+	b.t.Role = role
+	return b
+}
+
+// Doc sets the doc comment of the type.
+func (b *Builder) Doc(doc string) *Builder {
+	b.t.Doc = doc
+	return b
+}
+
+// Stability sets the stability level of the type.
+func (b *Builder) Stability(st Stability) *Builder {
+	b.t.Stability = st
+	return b
+}
+
+// Build returns the type that has been built.
+func (b *Builder) Build() *Type {
+	return b.t
+}
+
+// Add adds the given named types to info and returns info.
+// It panics if any of the types has no name.
+func (info *Info) Add(ts ...*Type) *Info {
+	for _, t := range ts {
+		if t.Name == "" {
+			panic(fmt.Errorf("cannot add unnamed type %v to Info", t))
+		}
+		info.Types[t.Name] = t
+	}
+	return info
+}
+
+// typeOf returns the type represented by x, as described in
+// the Builder documentation. If ref is true, named types
+// declared in packages are returned as references.
+func typeOf(x interface{}, ref bool) *Type {
+	var t *Type
+	switch x := x.(type) {
+	case Kind:
+		switch x {
+		case Struct, Array, Chan, Func, Map, Ptr, Slice, Unknown:
+			panic(fmt.Errorf("kind %s does not specify a complete type", x))
+		case Interface, UnsafePointer, Unsupported:
+			t = &Type{
+				Kind: x,
+			}
+		default:
+			t = &Type{
+				Name: TypeName(x),
+				Kind: x,
+			}
+		}
+	case *Type:
+		t = x
+	case *Builder:
+		t = x.t
+	case TypeName:
+		t = MustParse(string(x))
+	case string:
+		t = MustParse(x)
+	default:
+		panic(fmt.Errorf("unexpected type argument %#v", x))
+	}
+	if ref && t.Name.PkgPath() != "" {
+		return &Type{
+			Name: t.Name,
+		}
+	}
+	return t
+}
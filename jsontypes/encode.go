@@ -0,0 +1,133 @@
+package jsontypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Encoder writes an Info as JSON incrementally, one type at a
+// time, so that a large set of types can be written as they are
+// extracted without first encoding them all in memory.
+//
+// The types are written in the order that WriteType is called.
+// When they are written in name order, the output is identical
+// to that of json.MarshalIndent with a tab indent followed by a
+// newline, so it is stable for the same set of types.
+type Encoder struct {
+	w     io.Writer
+	err   error
+	names map[TypeName]bool
+	// closed holds whether Close has been called.
+	closed bool
+}
+
+// NewEncoder returns an encoder that writes to w, and writes
+// the start of an Info with the given metadata, which may be nil.
+func NewEncoder(w io.Writer, meta *Meta) *Encoder {
+	e := &Encoder{
+		w:     w,
+		names: make(map[TypeName]bool),
+	}
+	e.write([]byte("{\n"))
+	if meta != nil {
+		e.write([]byte("\t\"Meta\": "))
+		e.writeJSON(meta, "\t")
+		e.write([]byte(",\n"))
+	}
+	e.write([]byte("\t\"Types\": {"))
+	return e
+}
+
+// WriteType writes the named type t. It returns an error
+// if t is unnamed or a type with the same name has
+// already been written.
+func (e *Encoder) WriteType(t *Type) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	if t.Name == "" {
+		return fmt.Errorf("cannot write unnamed type %v", t)
+	}
+	if e.names[t.Name] {
+		return fmt.Errorf("type %s written twice", t.Name)
+	}
+	if len(e.names) > 0 {
+		e.write([]byte(","))
+	}
+	e.names[t.Name] = true
+	e.write([]byte("\n\t\t"))
+	e.writeJSON(t.Name, "")
+	e.write([]byte(": "))
+	e.writeJSON(t, "\t\t")
+	return e.err
+}
+
+// Close writes the rest of info other than its metadata and
+// types, which should already have been written, and finishes
+// the output. It does not close the underlying writer.
+func (e *Encoder) Close(info *Info) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	e.closed = true
+	if len(e.names) > 0 {
+		e.write([]byte("\n\t"))
+	}
+	e.write([]byte("}"))
+	// Encode the remaining fields in the usual way and
+	// splice them in after the types.
+	data, err := json.MarshalIndent(struct {
+		Funcs     map[TypeName]*Function `json:",omitempty"`
+		Vars      map[TypeName]*Var      `json:",omitempty"`
+		Consts    map[TypeName]*Const    `json:",omitempty"`
+		Facades   []*Facade              `json:",omitempty"`
+		Services  []*Service             `json:",omitempty"`
+		Routes    []*Route               `json:",omitempty"`
+		Warnings  []string               `json:",omitempty"`
+		Integrity *Integrity             `json:",omitempty"`
+	}{info.Funcs, info.Vars, info.Consts, info.Facades, info.Services, info.Routes, info.Warnings, info.Integrity}, "", "\t")
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	if len(data) > 2 {
+		// Remove the enclosing braces.
+		e.write([]byte(",\n"))
+		e.write(data[2 : len(data)-2])
+	}
+	e.write([]byte("\n}\n"))
+	return e.err
+}
+
+// writeJSON writes x as indented JSON, with
+// all lines but the first prefixed by prefix.
+func (e *Encoder) writeJSON(x interface{}, prefix string) {
+	if e.err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(x, prefix, "\t")
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.write(data)
+}
+
+func (e *Encoder) write(data []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(data)
+}
+
+// Encode writes info to w using an Encoder,
+// writing the types in name order.
+func Encode(w io.Writer, info *Info) error {
+	e := NewEncoder(w, info.Meta)
+	for _, name := range info.sortedNames() {
+		if err := e.WriteType(info.Types[name]); err != nil {
+			return err
+		}
+	}
+	return e.Close(info)
+}
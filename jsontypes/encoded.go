@@ -0,0 +1,49 @@
+package jsontypes
+
+import (
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// EncodedName returns the name under which a struct field with
+// the given name and tag is encoded by the encoding that uses
+// the given tag key, for example "json" or "yaml". The
+// embeddedStruct parameter reports whether the field is
+// an embedded struct or pointer to struct.
+//
+// The name is taken from the tag if it specifies one; otherwise
+// the field name is used, lower-cased for "yaml" as
+// gopkg.in/yaml does. EncodedName returns the empty string if
+// the field is not encoded under a name of its own: when its
+// tag name is "-", when it is unexported and not an embedded
+// struct, or when it is an embedded struct without a tag name,
+// in which case its fields are promoted into the enclosing struct.
+func EncodedName(name, tag, key string, embeddedStruct bool) string {
+	if !embeddedStruct && !token.IsExported(name) {
+		return ""
+	}
+	tagName, _, _ := strings.Cut(reflect.StructTag(tag).Get(key), ",")
+	switch {
+	case tagName == "-":
+		return ""
+	case tagName != "":
+		return tagName
+	case embeddedStruct:
+		return ""
+	case key == "yaml":
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// encodedName returns the JSON name of the field f (see EncodedName).
+// Embedded fields of named type are assumed to be structs, as
+// the definitions of named types are not available.
+func encodedName(f *Field) string {
+	t := f.Type
+	if t.Kind == Ptr {
+		t = t.Elem
+	}
+	return EncodedName(f.Name, f.Tag, "json", f.Anonymous && (t.Kind == Struct || t.Kind == ""))
+}
@@ -0,0 +1,225 @@
+package jsontypes
+
+// Equal reports whether info and other hold exactly the same
+// API: the same types, package-level functions, variables
+// and constants, facades, services and routes, with the same
+// definitions. Annotations that do not affect the structure
+// of the API, such as roles and doc comments, are not
+// compared, and neither is information about the snapshot
+// itself (Meta, Warnings and Integrity).
+func (info *Info) Equal(other *Info) bool {
+	if len(info.Types) != len(other.Types) ||
+		len(info.Funcs) != len(other.Funcs) ||
+		len(info.Vars) != len(other.Vars) ||
+		len(info.Consts) != len(other.Consts) ||
+		len(info.Facades) != len(other.Facades) ||
+		len(info.Services) != len(other.Services) ||
+		len(info.Routes) != len(other.Routes) {
+		return false
+	}
+	eq := newEqualizer(info, other)
+	for name, t0 := range info.Types {
+		t1, ok := other.Types[name]
+		if !ok || !eq.equal(t0, t1) {
+			return false
+		}
+	}
+	for name, f0 := range info.Funcs {
+		f1, ok := other.Funcs[name]
+		if !ok || !eq.ref(f0.Type, f1.Type) {
+			return false
+		}
+	}
+	for name, v0 := range info.Vars {
+		v1, ok := other.Vars[name]
+		if !ok || !eq.ref(v0.Type, v1.Type) {
+			return false
+		}
+	}
+	for name, c0 := range info.Consts {
+		c1, ok := other.Consts[name]
+		if !ok || c0.Untyped != c1.Untyped || c0.Value != c1.Value || !eq.ref(c0.Type, c1.Type) {
+			return false
+		}
+	}
+	// Facades, services and routes are kept sorted,
+	// so they can be compared in order.
+	for i, f0 := range info.Facades {
+		if !eq.facade(f0, other.Facades[i]) {
+			return false
+		}
+	}
+	for i, s0 := range info.Services {
+		if !eq.service(s0, other.Services[i]) {
+			return false
+		}
+	}
+	for i, r0 := range info.Routes {
+		if !eq.route(r0, other.Routes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (eq *equalizer) facade(f0, f1 *Facade) bool {
+	if f0.Name != f1.Name || f0.Version != f1.Version || len(f0.Methods) != len(f1.Methods) {
+		return false
+	}
+	for name, m0 := range f0.Methods {
+		m1 := f1.Methods[name]
+		if m1 == nil || !eq.ref(m0.Params, m1.Params) || !eq.ref(m0.Result, m1.Result) {
+			return false
+		}
+	}
+	return true
+}
+
+func (eq *equalizer) service(s0, s1 *Service) bool {
+	if s0.Name != s1.Name || len(s0.Methods) != len(s1.Methods) {
+		return false
+	}
+	for name, m0 := range s0.Methods {
+		m1 := s1.Methods[name]
+		if m1 == nil ||
+			m0.ClientStreaming != m1.ClientStreaming ||
+			m0.ServerStreaming != m1.ServerStreaming ||
+			!eq.ref(m0.Request, m1.Request) ||
+			!eq.ref(m0.Response, m1.Response) {
+			return false
+		}
+	}
+	return true
+}
+
+func (eq *equalizer) route(r0, r1 *Route) bool {
+	if r0.Method != r1.Method || r0.Path != r1.Path || len(r0.Query) != len(r1.Query) {
+		return false
+	}
+	for name, t0 := range r0.Query {
+		t1, ok := r1.Query[name]
+		if !ok || !eq.ref(t0, t1) {
+			return false
+		}
+	}
+	return eq.ref(r0.Request, r1.Request) && eq.ref(r0.Response, r1.Response)
+}
+
+// TypeEqual reports whether t0 (a type within info0) is
+// structurally identical to t1 (a type within info1).
+// References to named types are resolved using their
+// respective Info values; the names of any referenced types
+// must be the same, but the names of t0 and t1 themselves are
+// not compared, so a type that has been renamed without
+// otherwise changing will compare equal to its original.
+func TypeEqual(info0 *Info, t0 *Type, info1 *Info, t1 *Type) bool {
+	eq := newEqualizer(info0, info1)
+	return eq.equal(deref(info0, t0), deref(info1, t1))
+}
+
+type typePair struct {
+	t0, t1 *Type
+}
+
+type equalizer struct {
+	info0, info1 *Info
+	// visited holds all pairs that have been or
+	// are being compared. Pairs are assumed to be
+	// equal while they're being compared, which
+	// terminates recursion for cyclic types.
+	visited map[typePair]bool
+}
+
+func newEqualizer(info0, info1 *Info) *equalizer {
+	return &equalizer{
+		info0:   info0,
+		info1:   info1,
+		visited: make(map[typePair]bool),
+	}
+}
+
+// deref is like Info.Deref except that it does not panic
+// when a reference cannot be resolved and it allows
+// a nil Info or Type.
+func deref(info *Info, t *Type) *Type {
+	if info == nil || t == nil || t.Name == "" {
+		return t
+	}
+	if dt := info.Types[t.Name]; dt != nil {
+		return dt
+	}
+	return t
+}
+
+func (eq *equalizer) equal(t0, t1 *Type) bool {
+	if t0 == nil || t1 == nil {
+		return t0 == t1
+	}
+	p := typePair{t0, t1}
+	if eq.visited[p] {
+		return true
+	}
+	eq.visited[p] = true
+	if t0.Kind != t1.Kind ||
+		t0.Variadic != t1.Variadic ||
+		t0.Len != t1.Len ||
+		t0.Sealed != t1.Sealed ||
+		len(t0.Fields) != len(t1.Fields) ||
+		len(t0.Methods) != len(t1.Methods) ||
+		len(t0.Terms) != len(t1.Terms) ||
+		len(t0.TypeParams) != len(t1.TypeParams) ||
+		len(t0.In) != len(t1.In) ||
+		len(t0.Out) != len(t1.Out) {
+		return false
+	}
+	for i, f0 := range t0.Fields {
+		f1 := t1.Fields[i]
+		if f0.Name != f1.Name || f0.Anonymous != f1.Anonymous || f0.Tag != f1.Tag {
+			return false
+		}
+		if !eq.ref(f0.Type, f1.Type) {
+			return false
+		}
+	}
+	for name, m0 := range t0.Methods {
+		m1 := t1.Methods[name]
+		if m1 == nil || m0.PtrReceiver != m1.PtrReceiver || !eq.ref(m0.Type, m1.Type) {
+			return false
+		}
+	}
+	for i, tp0 := range t0.TypeParams {
+		if !eq.ref(tp0.Constraint, t1.TypeParams[i].Constraint) {
+			return false
+		}
+	}
+	for i, term0 := range t0.Terms {
+		term1 := t1.Terms[i]
+		if term0.Tilde != term1.Tilde || !eq.ref(term0.Type, term1.Type) {
+			return false
+		}
+	}
+	for i := range t0.In {
+		if !eq.ref(t0.In[i], t1.In[i]) {
+			return false
+		}
+	}
+	for i := range t0.Out {
+		if !eq.ref(t0.Out[i], t1.Out[i]) {
+			return false
+		}
+	}
+	return eq.ref(t0.Key, t1.Key) && eq.ref(t0.Elem, t1.Elem)
+}
+
+// ref compares two types that are referred to from
+// other types; unlike the top level types passed
+// to TypeEqual, their names must match.
+func (eq *equalizer) ref(t0, t1 *Type) bool {
+	if t0 == nil || t1 == nil {
+		return t0 == t1
+	}
+	if t0.Name != t1.Name {
+		return false
+	}
+	return eq.equal(deref(eq.info0, t0), deref(eq.info1, t1))
+}
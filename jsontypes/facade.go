@@ -0,0 +1,130 @@
+package jsontypes
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Facade describes a versioned RPC facade: a named set of methods,
+// each taking an optional parameters value and returning an
+// optional results value, as used by Juju's API server, for example.
+// Several versions of a facade with the same name may be
+// served at once.
+type Facade struct {
+	Name    string
+	Version int
+
+	// Methods holds the methods of the facade,
+	// indexed by method name.
+	Methods map[string]*FacadeMethod `json:",omitempty"`
+
+	Doc string `json:",omitempty"`
+}
+
+// FacadeMethod describes a method on an RPC facade.
+type FacadeMethod struct {
+	Name string
+
+	// Params holds the type of the method's parameters,
+	// or nil if it takes none.
+	Params *Type `json:",omitempty"`
+
+	// Result holds the type of the method's result,
+	// or nil if it returns none.
+	Result *Type `json:",omitempty"`
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+var reflectErrorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Facade returns the facade with the given name and
+// version, or nil if there is none.
+func (info *Info) Facade(name string, version int) *Facade {
+	for _, f := range info.Facades {
+		if f.Name == name && f.Version == version {
+			return f
+		}
+	}
+	return nil
+}
+
+// AddFacade adds f to info, replacing any existing facade
+// with the same name and version, and returns info.
+func (info *Info) AddFacade(f *Facade) *Info {
+	for i, f1 := range info.Facades {
+		if f1.Name == f.Name && f1.Version == f.Version {
+			info.Facades[i] = f
+			return info
+		}
+	}
+	info.Facades = append(info.Facades, f)
+	sort.Slice(info.Facades, func(i, j int) bool {
+		f0, f1 := info.Facades[i], info.Facades[j]
+		if f0.Name != f1.Name {
+			return f0.Name < f1.Name
+		}
+		return f0.Version < f1.Version
+	})
+	return info
+}
+
+// FacadeInfo adds the facade with the given name and version
+// implemented by the Go type t to info, and returns it. The
+// types of all parameters and results are added to info too.
+//
+// All exported methods in the method set of *t that have
+// one of the following forms are included; other methods
+// are ignored.
+//
+//	M()
+//	M() error
+//	M() Result
+//	M() (Result, error)
+//	M(Params) ...
+func (info *Info) FacadeInfo(name string, version int, t reflect.Type) *Facade {
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		t = reflect.PtrTo(t)
+	}
+	f := &Facade{
+		Name:    name,
+		Version: version,
+		Methods: make(map[string]*FacadeMethod),
+	}
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.PkgPath != "" {
+			continue
+		}
+		mt := m.Type
+		if t.Kind() != reflect.Interface {
+			mt = withoutReceiver(mt)
+		}
+		if mt.NumIn() > 1 || mt.IsVariadic() {
+			continue
+		}
+		out := make([]reflect.Type, mt.NumOut())
+		for j := range out {
+			out[j] = mt.Out(j)
+		}
+		if len(out) > 0 && out[len(out)-1] == reflectErrorType {
+			out = out[:len(out)-1]
+		}
+		if len(out) > 1 {
+			continue
+		}
+		fm := &FacadeMethod{
+			Name: m.Name,
+		}
+		if mt.NumIn() == 1 {
+			fm.Params = info.Ref(mt.In(0))
+		}
+		if len(out) == 1 {
+			fm.Result = info.Ref(out[0])
+		}
+		f.Methods[m.Name] = fm
+	}
+	info.AddFacade(f)
+	return f
+}
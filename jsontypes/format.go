@@ -0,0 +1,349 @@
+package jsontypes
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// String returns a Go-like representation of the type.
+// Named types are qualified by the last element
+// of their package path.
+func (t *Type) String() string {
+	return Format(nil, t)
+}
+
+// Format returns a Go-like representation of the given type,
+// for example "map[string][]*foo.Bar" or "func(int, ...string) error".
+//
+// Named types are qualified with the last element of their
+// package path. If info is non-nil and that would be ambiguous
+// because info holds types from several packages with the
+// same last element, the full package path is used instead.
+func Format(info *Info, t *Type) string {
+	p := &printer{
+		info: info,
+	}
+	p.typ(t)
+	return p.buf.String()
+}
+
+// FormatDecl returns a Go-like representation of the declaration
+// of the named type t, for example "type foo.Color string".
+// If t is unnamed, it returns the same as Format.
+func FormatDecl(info *Info, t *Type) string {
+	if t.Name == "" {
+		return Format(info, t)
+	}
+	p := &printer{
+		info: info,
+	}
+	p.buf.WriteString("type ")
+	p.name(t.Name)
+	p.typeParams(t.TypeParams)
+	p.buf.WriteString(" ")
+	underlying := *t
+	underlying.Name = ""
+	underlying.TypeParams = nil
+	if underlying.Kind == Unknown || underlying.Kind == "" {
+		p.buf.WriteString("?")
+	} else {
+		p.typ(&underlying)
+	}
+	return p.buf.String()
+}
+
+// FormatField returns a Go-like representation of the
+// given struct field, for example "Name string `json:"name"`".
+func FormatField(info *Info, f *Field) string {
+	p := &printer{
+		info: info,
+	}
+	if !f.Anonymous {
+		p.buf.WriteString(f.Name)
+		p.buf.WriteString(" ")
+	}
+	p.typ(f.Type)
+	if f.Tag != "" {
+		p.buf.WriteString(" ")
+		p.buf.WriteString(quoteTag(f.Tag))
+	}
+	return p.buf.String()
+}
+
+// FormatMethod returns a Go-like representation of the given
+// method on the type t, for example "func (*foo.T) Close() error".
+// If t is an interface type, the method is formatted as it would be
+// inside the interface type, for example "Close() error".
+func FormatMethod(info *Info, t *Type, m *Method) string {
+	p := &printer{
+		info: info,
+	}
+	if t.Kind != Interface {
+		p.buf.WriteString("func (")
+		if m.PtrReceiver {
+			p.buf.WriteString("*")
+		}
+		p.typ(&Type{
+			Name: t.Name,
+		})
+		p.buf.WriteString(") ")
+	}
+	p.buf.WriteString(m.Name)
+	p.signature(m.Type)
+	return p.buf.String()
+}
+
+// quoteTag returns the tag quoted as it would usually
+// be written in Go source.
+func quoteTag(tag string) string {
+	if strconv.CanBackquote(tag) {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
+
+type printer struct {
+	info *Info
+	buf  bytes.Buffer
+	// pkgNames maps package paths to the qualifier used
+	// for them. It is computed lazily from info.
+	pkgNames map[string]string
+	// active holds the unnamed types currently being
+	// printed, so that cycles can be detected.
+	active map[*Type]bool
+}
+
+func (p *printer) typ(t *Type) {
+	if t == nil {
+		p.buf.WriteString("<nil>")
+		return
+	}
+	if t.Name != "" {
+		p.name(t.Name)
+		return
+	}
+	if p.active[t] {
+		// Unnamed types can only be cyclic if constructed
+		// directly rather than extracted from Go types.
+		p.buf.WriteString("...")
+		return
+	}
+	if p.active == nil {
+		p.active = make(map[*Type]bool)
+	}
+	p.active[t] = true
+	defer delete(p.active, t)
+	switch t.Kind {
+	case Array:
+		fmt.Fprintf(&p.buf, "[%d]", t.Len)
+		p.typ(t.Elem)
+	case Slice:
+		p.buf.WriteString("[]")
+		p.typ(t.Elem)
+	case Chan:
+		p.buf.WriteString("chan ")
+		p.typ(t.Elem)
+	case Ptr:
+		p.buf.WriteString("*")
+		p.typ(t.Elem)
+	case Map:
+		p.buf.WriteString("map[")
+		p.typ(t.Key)
+		p.buf.WriteString("]")
+		p.typ(t.Elem)
+	case Func:
+		p.buf.WriteString("func")
+		p.signature(t)
+	case Struct:
+		p.buf.WriteString("struct{")
+		for i, f := range t.Fields {
+			if i > 0 {
+				p.buf.WriteString("; ")
+			}
+			if !f.Anonymous {
+				p.buf.WriteString(f.Name)
+				p.buf.WriteString(" ")
+			}
+			p.typ(f.Type)
+			if f.Tag != "" {
+				p.buf.WriteString(" ")
+				p.buf.WriteString(quoteTag(f.Tag))
+			}
+		}
+		p.buf.WriteString("}")
+	case Interface:
+		p.buf.WriteString("interface{")
+		for i, name := range sortedMethodNames(t) {
+			if i > 0 {
+				p.buf.WriteString("; ")
+			}
+			p.buf.WriteString(name)
+			p.signature(t.Methods[name].Type)
+		}
+		if len(t.Terms) > 0 {
+			if len(t.Methods) > 0 {
+				p.buf.WriteString("; ")
+			}
+			for i, term := range t.Terms {
+				if i > 0 {
+					p.buf.WriteString(" | ")
+				}
+				if term.Tilde {
+					p.buf.WriteString("~")
+				}
+				p.typ(term.Type)
+			}
+		}
+		p.buf.WriteString("}")
+	case UnsafePointer:
+		p.buf.WriteString("unsafe.Pointer")
+	default:
+		p.buf.WriteString(string(t.Kind))
+	}
+}
+
+// typeParams prints the given type parameters, if any,
+// for example "[K comparable, V any]".
+func (p *printer) typeParams(tparams []*TypeParam) {
+	if len(tparams) == 0 {
+		return
+	}
+	p.buf.WriteString("[")
+	for i, tp := range tparams {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		p.buf.WriteString(tp.Name)
+		p.buf.WriteString(" ")
+		if c := tp.Constraint; c != nil && c.Name == "" && c.Kind == Interface && len(c.Methods) == 0 && len(c.Terms) == 0 {
+			p.buf.WriteString("any")
+		} else {
+			p.typ(c)
+		}
+	}
+	p.buf.WriteString("]")
+}
+
+// signature prints the parameters and results of the
+// given function type.
+func (p *printer) signature(t *Type) {
+	if t == nil || t.Kind != Func {
+		p.buf.WriteString("(?)")
+		return
+	}
+	p.buf.WriteString("(")
+	for i, in := range t.In {
+		if i > 0 {
+			p.buf.WriteString(", ")
+		}
+		if t.Variadic && i == len(t.In)-1 && in.Kind == Slice && in.Name == "" {
+			p.buf.WriteString("...")
+			p.typ(in.Elem)
+			continue
+		}
+		p.typ(in)
+	}
+	p.buf.WriteString(")")
+	switch len(t.Out) {
+	case 0:
+	case 1:
+		p.buf.WriteString(" ")
+		p.typ(t.Out[0])
+	default:
+		p.buf.WriteString(" (")
+		for i, out := range t.Out {
+			if i > 0 {
+				p.buf.WriteString(", ")
+			}
+			p.typ(out)
+		}
+		p.buf.WriteString(")")
+	}
+}
+
+func (p *printer) name(n TypeName) {
+	// Qualify the names in any type arguments as well
+	// as the type name itself.
+	p.buf.WriteString(rewriteWords(string(n), func(word string) string {
+		pkg, name := TypeName(word).split()
+		if pkg == "" {
+			return word
+		}
+		return p.qualifier(pkg) + "." + name
+	}))
+}
+
+// qualifier returns the qualifier to use for types in the
+// given package.
+func (p *printer) qualifier(pkg string) string {
+	if p.info == nil {
+		return pkgBase(pkg)
+	}
+	if p.pkgNames == nil {
+		p.pkgNames = make(map[string]string)
+		paths := make(map[string][]string)
+		for name := range p.info.Types {
+			if pkgPath := name.PkgPath(); pkgPath != "" {
+				base := pkgBase(pkgPath)
+				if !contains(paths[base], pkgPath) {
+					paths[base] = append(paths[base], pkgPath)
+				}
+			}
+		}
+		for base, pkgPaths := range paths {
+			for _, pkgPath := range pkgPaths {
+				if len(pkgPaths) == 1 {
+					p.pkgNames[pkgPath] = base
+				} else {
+					p.pkgNames[pkgPath] = pkgPath
+				}
+			}
+		}
+	}
+	if q, ok := p.pkgNames[pkg]; ok {
+		return q
+	}
+	return pkgBase(pkg)
+}
+
+// pkgBase returns the conventional name of the package
+// with the given path. As the module path isn't known, a
+// final element that looks like a major version suffix
+// is taken to be one and ignored.
+func pkgBase(pkg string) string {
+	if i := strings.Index(pkg, "@"); i >= 0 {
+		pkg = pkg[0:i]
+	}
+	base := path.Base(pkg)
+	switch {
+	case isMajorVersion(base) && strings.Contains(pkg, "/"):
+		return path.Base(path.Dir(pkg))
+	case strings.HasPrefix(pkg, "gopkg.in/"):
+		if i := strings.LastIndex(base, "."); i >= 0 && isMajorVersion(base[i+1:]) {
+			return base[0:i]
+		}
+	}
+	return base
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedMethodNames(t *Type) []string {
+	names := make([]string, 0, len(t.Methods))
+	for name := range t.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
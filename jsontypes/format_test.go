@@ -0,0 +1,20 @@
+package jsontypes_test
+
+import (
+	"testing"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+func TestFormatCyclicUnnamedType(t *testing.T) {
+	// Go types cannot refer to themselves other than
+	// through a named type, but a Type can.
+	jt := &jsontypes.Type{Kind: jsontypes.Struct}
+	jt.Fields = []*jsontypes.Field{{
+		Name: "Next",
+		Type: &jsontypes.Type{Kind: jsontypes.Ptr, Elem: jt},
+	}}
+	if got, want := jsontypes.Format(nil, jt), "struct{Next *...}"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package jsontypes
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Function describes a package-level function.
+type Function struct {
+	// Name holds the name of the function, qualified
+	// by its package path in the same way as a TypeName,
+	// for example "example.com/foo#NewClient".
+	Name TypeName
+
+	// Type holds the function's type, of kind Func.
+	Type *Type
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+// StabilityOf returns the stability of f, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (f *Function) StabilityOf() Stability {
+	return stabilityOf(f.Stability, f.Doc)
+}
+
+// AddFunc adds the package-level function fn to info with
+// the given name, which should be in the form
+// "example.com/foo#NewClient". If name is empty, the name
+// is taken from the runtime information for fn. The types of
+// the function's parameters and results are added too.
+//
+// It panics if fn is not a function or if name is empty and
+// fn is not a top-level function (for example if it's a
+// closure or a method value).
+func (info *Info) AddFunc(name string, fn interface{}) *Function {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		panic(fmt.Errorf("AddFunc called with non-function %T", fn))
+	}
+	if name == "" {
+		name = funcName(v)
+	}
+	f := &Function{
+		Name: TypeName(name),
+		Type: info.TypeInfo(v.Type()),
+	}
+	if info.Funcs == nil {
+		info.Funcs = make(map[TypeName]*Function)
+	}
+	info.Funcs[f.Name] = f
+	return f
+}
+
+// funcName returns the name of the top-level function v.
+func funcName(v reflect.Value) string {
+	rf := runtime.FuncForPC(v.Pointer())
+	if rf == nil {
+		panic(fmt.Errorf("cannot determine name of function %v", v.Type()))
+	}
+	// The name is of the form "example.com/foo.Name".
+	full := rf.Name()
+	i := strings.LastIndex(full, "/")
+	j := strings.Index(full[i+1:], ".")
+	if j < 0 {
+		panic(fmt.Errorf("unexpected function name %q", full))
+	}
+	pkg, name := full[:i+1+j], full[i+1+j+1:]
+	if strings.ContainsAny(name, ".()*") {
+		panic(fmt.Errorf("%q is not a top-level function", full))
+	}
+	return string(MakeTypeName(pkg, name))
+}
@@ -0,0 +1,94 @@
+package jsontypes
+
+import "sort"
+
+// wellKnownInterfaces holds the definitions of the interfaces
+// recorded in Type.Implements, indexed by name.
+var wellKnownInterfaces = map[TypeName]*Type{
+	"error":                      MustParse("interface{Error() string}"),
+	"fmt#Stringer":               MustParse("interface{String() string}"),
+	"fmt#GoStringer":             MustParse("interface{GoString() string}"),
+	"encoding/json#Marshaler":    MustParse("interface{MarshalJSON() ([]uint8, error)}"),
+	"encoding/json#Unmarshaler":  MustParse("interface{UnmarshalJSON([]uint8) error}"),
+	"encoding#TextMarshaler":     MustParse("interface{MarshalText() ([]uint8, error)}"),
+	"encoding#TextUnmarshaler":   MustParse("interface{UnmarshalText([]uint8) error}"),
+	"encoding#BinaryMarshaler":   MustParse("interface{MarshalBinary() ([]uint8, error)}"),
+	"encoding#BinaryUnmarshaler": MustParse("interface{UnmarshalBinary([]uint8) error}"),
+	"database/sql#Scanner":       MustParse("interface{Scan(interface{}) error}"),
+	"database/sql/driver#Valuer": MustParse("interface{Value() (database/sql/driver#Value, error)}"),
+	"encoding/xml#Marshaler":     MustParse("interface{MarshalXML(*encoding/xml#Encoder, encoding/xml#StartElement) error}"),
+	"encoding/xml#Unmarshaler":   MustParse("interface{UnmarshalXML(*encoding/xml#Decoder, encoding/xml#StartElement) error}"),
+	"encoding/gob#GobEncoder":    MustParse("interface{GobEncode() ([]uint8, error)}"),
+	"encoding/gob#GobDecoder":    MustParse("interface{GobDecode([]uint8) error}"),
+}
+
+// WellKnownInterfaces returns the names of the well-known
+// interfaces that are recorded in Type.Implements,
+// in sorted order.
+func WellKnownInterfaces() []TypeName {
+	names := make([]TypeName, 0, len(wellKnownInterfaces))
+	for name := range wellKnownInterfaces {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// ImplementedInterfaces returns the names of the well-known
+// interfaces (see WellKnownInterfaces) implemented by t,
+// judging by its methods, sorted by interface name. When only a pointer
+// to t implements an interface, its name is prefixed with "*".
+//
+// This is used to set Type.Implements when adding types
+// to an Info.
+func ImplementedInterfaces(t *Type) []TypeName {
+	var names []TypeName
+	for _, name := range WellKnownInterfaces() {
+		switch implements(t, wellKnownInterfaces[name]) {
+		case implValue:
+			names = append(names, name)
+		case implPtr:
+			names = append(names, "*"+name)
+		}
+	}
+	return names
+}
+
+// ImplementsInterface reports whether t implements the named well-known
+// interface, as recorded in t.Implements. If ptr is true, it
+// reports whether a pointer to t implements the interface.
+func (t *Type) ImplementsInterface(name TypeName, ptr bool) bool {
+	for _, n := range t.Implements {
+		if n == name || ptr && n == "*"+name {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	implNone = iota
+	implValue
+	implPtr
+)
+
+// implements reports whether t or *t implements the
+// interface iface, by comparing method signatures.
+func implements(t, iface *Type) int {
+	if len(t.Methods) == 0 {
+		return implNone
+	}
+	result := implValue
+	for name, im := range iface.Methods {
+		m := t.Methods[name]
+		if m == nil || Format(nil, m.Type) != Format(nil, im.Type) {
+			return implNone
+		}
+		if m.PtrReceiver {
+			result = implPtr
+		}
+	}
+	return result
+}
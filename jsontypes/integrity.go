@@ -0,0 +1,85 @@
+package jsontypes
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Integrity holds a content hash of a snapshot and optionally
+// a signature, so that a snapshot that has been published can
+// be checked for tampering or truncation when it is loaded.
+type Integrity struct {
+	// Hash holds "sha256:" followed by the hex-encoded SHA-256
+	// hash of the snapshot as written by Encode, without
+	// its Integrity field.
+	Hash string
+
+	// Signature holds the base64-encoded Ed25519
+	// signature of Hash, if the snapshot is signed.
+	Signature string `json:",omitempty"`
+}
+
+// Seal sets info.Integrity to hold the hash of the rest of info,
+// signed with the given key if it is non-nil.
+func (info *Info) Seal(key ed25519.PrivateKey) error {
+	hash, err := info.contentHash()
+	if err != nil {
+		return err
+	}
+	integrity := &Integrity{
+		Hash: hash,
+	}
+	if key != nil {
+		integrity.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(hash)))
+	}
+	info.Integrity = integrity
+	return nil
+}
+
+// Verify checks that info has not changed since it was sealed.
+// If key is non-nil, it also checks that info was signed
+// with the corresponding private key. It returns an error
+// if info has not been sealed.
+func (info *Info) Verify(key ed25519.PublicKey) error {
+	if info.Integrity == nil {
+		return fmt.Errorf("snapshot has not been sealed")
+	}
+	hash, err := info.contentHash()
+	if err != nil {
+		return err
+	}
+	if hash != info.Integrity.Hash {
+		return fmt.Errorf("snapshot does not match its hash; it may have been modified or truncated")
+	}
+	if key == nil {
+		return nil
+	}
+	if info.Integrity.Signature == "" {
+		return fmt.Errorf("snapshot has not been signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(info.Integrity.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot signature: %v", err)
+	}
+	if !ed25519.Verify(key, []byte(hash), sig) {
+		return fmt.Errorf("snapshot signature is not valid for the key")
+	}
+	return nil
+}
+
+// contentHash returns the hash of info without its
+// Integrity field, as stored in Integrity.Hash.
+func (info *Info) contentHash() (string, error) {
+	unsealed := *info
+	unsealed.Integrity = nil
+	var buf bytes.Buffer
+	if err := Encode(&buf, &unsealed); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
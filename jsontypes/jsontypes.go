@@ -3,7 +3,6 @@ package jsontypes
 import (
 	"fmt"
 	"reflect"
-	"strings"
 )
 
 type Kind string
@@ -36,6 +35,17 @@ const (
 	String        Kind = "string"
 	Struct        Kind = "struct"
 	UnsafePointer Kind = "unsafepointer"
+
+	// Unsupported is used in place of the type of a struct
+	// field that cannot be serialized when non-serializable
+	// fields are being elided. See Info.SetSerializableOnly.
+	Unsupported Kind = "unsupported"
+
+	// TypeParameter is the kind of a reference to a type
+	// parameter within the definition of a generic type.
+	// The Name of such a type holds the name of the
+	// parameter, as in Type.TypeParams.
+	TypeParameter Kind = "typeparam"
 )
 
 func NewInfo() *Info {
@@ -46,7 +56,71 @@ func NewInfo() *Info {
 
 // Info holds information on a set of types.
 type Info struct {
+	// Meta holds information about where the
+	// types came from, if known.
+	Meta *Meta `json:",omitempty"`
+
 	Types map[TypeName]*Type
+
+	// Funcs holds any package-level functions,
+	// indexed by name. See Function for details.
+	Funcs map[TypeName]*Function `json:",omitempty"`
+
+	// Vars and Consts hold any package-level variables
+	// and constants, indexed by name.
+	Vars   map[TypeName]*Var   `json:",omitempty"`
+	Consts map[TypeName]*Const `json:",omitempty"`
+
+	// Facades holds any RPC facades, sorted by
+	// name and version. See Facade for details.
+	Facades []*Facade `json:",omitempty"`
+
+	// Services holds any gRPC services, sorted
+	// by name. See Service for details.
+	Services []*Service `json:",omitempty"`
+
+	// Routes holds any HTTP routes, sorted by
+	// path and method. See Route for details.
+	Routes []*Route `json:",omitempty"`
+
+	// Warnings holds any warnings produced when
+	// the types were extracted.
+	Warnings []string `json:",omitempty"`
+
+	// Integrity holds a hash and optional signature of
+	// the rest of the snapshot, if it has been sealed.
+	// See Info.Seal.
+	Integrity *Integrity `json:",omitempty"`
+
+	// serializableOnly holds whether non-serializable struct
+	// fields are recorded as Unsupported.
+	serializableOnly bool
+
+	// progress is used to report progress when
+	// adding Go types.
+	progress *ProgressReporter
+}
+
+// TypeParam represents a type parameter of a
+// generic type. See Type.TypeParams.
+type TypeParam struct {
+	// Name holds the name of the parameter.
+	Name string
+
+	// Constraint holds the constraint on the parameter,
+	// which is an interface type.
+	Constraint *Type
+}
+
+// Term represents a term in the union of a constraint
+// interface. See Type.Terms.
+type Term struct {
+	// Tilde holds whether the term is of the form ~T,
+	// which includes all types with underlying type T.
+	Tilde bool `json:",omitempty"`
+
+	// Type holds the type T.
+	Type *Type
 }
 
 type Type struct {
@@ -54,10 +128,41 @@ type Type struct {
 
 	Kind Kind `json:",omitempty"`
 
+	// Role holds the role of the type, if known.
+	// See the Role documentation for details.
+	Role Role `json:",omitempty"`
+
 	// Methods holds any methods defined on the type,
 	// indexed by the method name.
 	Methods map[string]*Method `json:",omitempty"`
 
+	// Implements holds the names of the well-known interfaces
+	// (see WellKnownInterfaces) that the type implements,
+	// prefixed with "*" when only a pointer to the type does.
+	// It is recorded when the type is added, so it remains
+	// valid even if methods are pruned later.
+	Implements []TypeName `json:",omitempty"`
+
+	// Sealed holds whether the interface has unexported
+	// methods, so that it can only be implemented inside
+	// its own package; valid only when Kind is interface.
+	Sealed bool `json:",omitempty"`
+
+	// Terms holds the terms of the union that restricts the
+	// type set of a constraint interface, such as ~int | string.
+	// If it's empty, the type set is not restricted other than by
+	// the interface's methods. Valid only when Kind is interface.
+	Terms []*Term `json:",omitempty"`
+
+	// TypeParams holds the type parameters of a generic type
+	// declaration, whose name has no type arguments. Each
+	// instantiation of a generic type that is used is recorded
+	// as a separate type whose name includes the type
+	// arguments (see MakeTypeName); instantiations within the
+	// definition of a generic type that involve its type
+	// parameters are recorded only as references.
+	TypeParams []*TypeParam `json:",omitempty"`
+
 	// Fields holds any fields in the struct; valid only when Kind is struct.
 	Fields []*Field `json:",omitempty"`
 
@@ -65,6 +170,10 @@ type Type struct {
 	// is array, chan, map, ptr or slice.
 	Elem *Type `json:",omitempty"`
 
+	// Len holds the length of the array; valid only when
+	// kind is array.
+	Len int `json:",omitempty"`
+
 	// Key holds the type's kind; valid only when kind is map.
 	Key *Type `json:",omitempty"`
 
@@ -77,6 +186,34 @@ type Type struct {
 	// Variadic  holds whether the function is variadic; valid only when kind is func.
 	Variadic bool `json:",omitempty"`
 
+	// Doc holds the doc comment for a named type, if known.
+	Doc string `json:",omitempty"`
+
+	// Stability holds the stability level of the type, if known.
+	// If empty, the stability is taken from any stability
+	// marker in Doc.
+	Stability Stability `json:",omitempty"`
+
+	// Ignored holds whether the type is excluded from
+	// compatibility checks, as marked by an
+	// //apicompat:ignore directive (see srcload.Load).
+	Ignored bool `json:",omitempty"`
+
+	// Platforms holds the platforms on which the type is
+	// present, when it is not present on all of them.
+	// See MergePlatforms.
+	Platforms []string `json:",omitempty"`
+
+	// Constraint holds the build constraint of the file
+	// that declares the type, if any, in //go:build syntax,
+	// including any constraint implied by the file name.
+	Constraint string `json:",omitempty"`
+
+	// Pos holds the source position of the declaration of
+	// a named type, in the form "file:line", if known.
+	// See srcload.Config.Positions.
+	Pos string `json:",omitempty"`
+
 	// goType records the Go type that was used to
 	// create the type. Valid only when adding Go types.
 	goType reflect.Type
@@ -97,8 +234,38 @@ func (t *Type) FieldByName(name string) *Field {
 type Field struct {
 	Name      string
 	Type      *Type
-	Anonymous bool   `json:",omitempty"`
-	Tag       string `json:",omitempty"`
+	Anonymous bool      `json:",omitempty"`
+	Tag       string    `json:",omitempty"`
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+
+	// Ignored holds whether the field is excluded
+	// from compatibility checks, as for Type.Ignored.
+	Ignored bool `json:",omitempty"`
+
+	// Default holds the default value of the field, as
+	// specified by a "default" struct tag. It is empty if
+	// there is none.
+	Default string `json:",omitempty"`
+
+	// EncodedName holds the name of the field when encoded
+	// as JSON. It is empty if the field is not encoded under a
+	// name of its own. See the EncodedName function for details.
+	EncodedName string `json:",omitempty"`
+
+	// Index holds the index of the field within
+	// its struct, including any unexported fields
+	// that are not recorded.
+	Index int `json:",omitempty"`
+
+	// Platforms holds the platforms on which the field
+	// is present, when it is not present on all the
+	// platforms that its struct type is.
+	Platforms []string `json:",omitempty"`
+
+	// Pos holds the source position of the field,
+	// if known, as for Type.Pos.
+	Pos string `json:",omitempty"`
 }
 
 type Method struct {
@@ -106,7 +273,26 @@ type Method struct {
 	Name        string
 	// Type holds the function type of the method, without
 	// its receiver argument.
-	Type *Type
+	Type      *Type
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+
+	// Ignored holds whether the method is excluded
+	// from compatibility checks, as for Type.Ignored.
+	Ignored bool `json:",omitempty"`
+
+	// Platforms holds the platforms on which the method
+	// is present, when it is not present on all the
+	// platforms that its type is.
+	Platforms []string `json:",omitempty"`
+
+	// Constraint holds the build constraint of the file
+	// that declares the method, if any, as for Type.Constraint.
+	Constraint string `json:",omitempty"`
+
+	// Pos holds the source position of the method,
+	// if known, as for Type.Pos.
+	Pos string `json:",omitempty"`
 }
 
 func (info *Info) Deref(t *Type) *Type {
@@ -122,7 +308,7 @@ func (info *Info) Deref(t *Type) *Type {
 func (info *Info) TypeInfo(t reflect.Type) *Type {
 	var name TypeName
 	if t.Name() != "" {
-		name = mkName(t.PkgPath(), t.Name())
+		name = reflectTypeName(t.PkgPath(), t.Name())
 	}
 	inPackage := t.PkgPath() != ""
 	if inPackage && name != "" {
@@ -139,12 +325,20 @@ func (info *Info) TypeInfo(t reflect.Type) *Type {
 		goType: t,
 	}
 	if inPackage && name != "" {
-		// Add the type to the info first to prevent infinite recursion.
+		// Add the type to the info first to prevent infinite recursion,
+		// but report it only when it's complete.
 		info.Types[name] = jt
+		defer info.progress.Report(string(name))
 	}
 	info.addMethods(jt, t)
+	if jt.Name.PkgPath() != "" {
+		jt.Implements = ImplementedInterfaces(jt)
+	}
 	switch t.Kind() {
-	case reflect.Array, reflect.Chan, reflect.Ptr, reflect.Slice:
+	case reflect.Array:
+		jt.Elem = info.Ref(t.Elem())
+		jt.Len = t.Len()
+	case reflect.Chan, reflect.Ptr, reflect.Slice:
 		jt.Elem = info.Ref(t.Elem())
 	case reflect.Map:
 		jt.Key, jt.Elem = info.Ref(t.Key()), info.Ref(t.Elem())
@@ -186,6 +380,9 @@ func (info *Info) addMethods(jt *Type, t reflect.Type) {
 	for i := 0; i < t.NumMethod(); i++ {
 		m := t.Method(i)
 		if m.PkgPath != "" {
+			if t.Kind() == reflect.Interface {
+				jt.Sealed = true
+			}
 			continue
 		}
 		if t.Kind() != reflect.Interface {
@@ -214,42 +411,21 @@ func (info *Info) addFields(jt *Type, t reflect.Type) {
 		}
 		jf := Field{
 			Name:      f.Name,
-			Type:      info.Ref(f.Type),
+			Type:      info.fieldRef(jt, f),
 			Anonymous: f.Anonymous,
 			Tag:       string(f.Tag),
+			Default:   f.Tag.Get("default"),
+			Index:     i,
 		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		jf.EncodedName = EncodedName(f.Name, string(f.Tag), "json", f.Anonymous && ft.Kind() == reflect.Struct)
 		jt.Fields = append(jt.Fields, &jf)
 	}
 }
 
-type TypeName string
-
-func mkName(pkgName, name string) TypeName {
-	if pkgName == "" {
-		return TypeName(name)
-	}
-	return TypeName(pkgName + "#" + name)
-}
-
-func (n TypeName) PkgPath() string {
-	p, _ := n.split()
-	return p
-}
-
-func (n TypeName) Name() string {
-	_, name := n.split()
-	return name
-}
-
-func (n TypeName) split() (string, string) {
-	sn := string(n)
-	i := strings.LastIndex(sn, "#")
-	if i == -1 {
-		return "", sn
-	}
-	return sn[0:i], sn[i+1:]
-}
-
 func withoutReceiver(t reflect.Type) reflect.Type {
 	if t.Kind() != reflect.Func || t.NumIn() < 1 {
 		panic("non-method type")
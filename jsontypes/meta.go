@@ -0,0 +1,191 @@
+package jsontypes
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Meta holds information about where a snapshot of an API
+// came from.
+type Meta struct {
+	// Module holds the path of the module that
+	// the API was extracted from.
+	Module string `json:",omitempty"`
+
+	// Version holds the version of that module.
+	Version string `json:",omitempty"`
+
+	// Commit holds the VCS commit that the
+	// API was extracted from.
+	Commit string `json:",omitempty"`
+
+	// Time holds when the API was extracted.
+	Time time.Time `json:",omitzero"`
+
+	// Tool holds the version of this package
+	// that extracted the API.
+	Tool string `json:",omitempty"`
+
+	// GoVersion holds the version of Go that
+	// the API was extracted with.
+	GoVersion string `json:",omitempty"`
+
+	// BuildTags holds any build tags that were
+	// in effect when the API was extracted.
+	BuildTags []string `json:",omitempty"`
+}
+
+// String returns a short description of the snapshot,
+// such as "v1.4.0 / abc123", suitable for including in
+// messages. It returns the empty string if m is nil
+// or holds no version or commit information.
+func (m *Meta) String() string {
+	if m == nil {
+		return ""
+	}
+	var parts []string
+	if m.Version != "" {
+		parts = append(parts, m.Version)
+	}
+	if m.Commit != "" {
+		commit := m.Commit
+		if len(commit) > 12 {
+			commit = commit[0:12]
+		}
+		parts = append(parts, commit)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// Describe returns a full description of the snapshot on
+// a single line, including all the information known about
+// it, for example "example.com/m v1.4.0 / abc123 at
+// 2024-01-02T15:04:05Z (go1.22.0, tags foo,bar, apicompat v0.3.0)".
+// It returns "unknown snapshot" if m is nil.
+func (m *Meta) Describe() string {
+	if m == nil {
+		return "unknown snapshot"
+	}
+	var buf strings.Builder
+	buf.WriteString(m.Module)
+	if s := m.String(); s != "" {
+		if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(s)
+	}
+	if !m.Time.IsZero() {
+		if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString("at " + m.Time.Format(time.RFC3339))
+	}
+	var extra []string
+	if m.GoVersion != "" {
+		extra = append(extra, m.GoVersion)
+	}
+	if len(m.BuildTags) > 0 {
+		extra = append(extra, "tags "+strings.Join(m.BuildTags, ","))
+	}
+	if m.Tool != "" {
+		extra = append(extra, "apicompat "+m.Tool)
+	}
+	if len(extra) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString("(" + strings.Join(extra, ", ") + ")")
+	}
+	if buf.Len() == 0 {
+		return "unknown snapshot"
+	}
+	return buf.String()
+}
+
+// Mismatches returns descriptions of any ways in which the
+// snapshots described by m and m1 look inconsistent when
+// m is an older snapshot of the same API as m1: for example,
+// when they are of different modules, when m1 was taken before
+// m, or when they were taken with different build tags.
+// Information missing from either is not reported.
+func (m *Meta) Mismatches(m1 *Meta) []string {
+	if m == nil || m1 == nil {
+		return nil
+	}
+	var msgs []string
+	if m.Module != "" && m1.Module != "" && m.Module != m1.Module {
+		msgs = append(msgs, fmt.Sprintf("snapshots are of different modules (%s vs %s)", m.Module, m1.Module))
+	}
+	if m.Version != "" && m1.Version != "" && semver.IsValid(m.Version) && semver.IsValid(m1.Version) && semver.Compare(m.Version, m1.Version) > 0 {
+		msgs = append(msgs, fmt.Sprintf("old snapshot has a later version than the new one (%s vs %s)", m.Version, m1.Version))
+	}
+	if !m.Time.IsZero() && !m1.Time.IsZero() && m1.Time.Before(m.Time) {
+		msgs = append(msgs, fmt.Sprintf("old snapshot was taken after the new one (%s vs %s)", m.Time.Format(time.RFC3339), m1.Time.Format(time.RFC3339)))
+	}
+	if m.GoVersion != "" && m1.GoVersion != "" && m.GoVersion != m1.GoVersion {
+		msgs = append(msgs, fmt.Sprintf("snapshots were taken with different Go versions (%s vs %s)", m.GoVersion, m1.GoVersion))
+	}
+	// Build tags are only known to be recorded when the Go version is.
+	if tags0, tags1 := strings.Join(m.BuildTags, ","), strings.Join(m1.BuildTags, ","); m.GoVersion != "" && m1.GoVersion != "" && tags0 != tags1 {
+		msgs = append(msgs, fmt.Sprintf("snapshots were taken with different build tags (%q vs %q)", tags0, tags1))
+	}
+	if m.Tool != "" && m1.Tool != "" && m.Tool != m1.Tool {
+		msgs = append(msgs, fmt.Sprintf("snapshots were taken with different versions of apicompat (%s vs %s)", m.Tool, m1.Tool))
+	}
+	return msgs
+}
+
+// toolModule holds the module path of this package.
+const toolModule = "github.com/rogpeppe/apicompat"
+
+// NewMeta returns metadata for a snapshot of the given
+// module taken by the current program, using the
+// build information in the running binary to
+// determine the module's version and commit, when
+// available. The time is left unset, so that snapshots
+// of the same API are identical; callers that want
+// to record it should set it themselves.
+func NewMeta(module string) *Meta {
+	m := &Meta{
+		Module:    module,
+		GoVersion: runtime.Version(),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return m
+	}
+	if bi.Main.Path == toolModule {
+		m.Tool = bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == toolModule {
+			m.Tool = dep.Version
+		}
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "-tags" && s.Value != "" {
+			m.BuildTags = strings.Split(s.Value, ",")
+		}
+	}
+	if bi.Main.Path == module {
+		m.Version = bi.Main.Version
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				m.Commit = s.Value
+			}
+		}
+		return m
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == module {
+			m.Version = dep.Version
+			break
+		}
+	}
+	return m
+}
@@ -0,0 +1,335 @@
+package jsontypes
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/mod/module"
+)
+
+// TypeName holds the name of a type. Named types declared in a
+// package are named by the package path and the type name
+// separated by a hash (for example "example.com/foo#Bar");
+// predeclared types such as "int" have no package path.
+//
+// When an Info holds several versions of the same module,
+// the package path may be qualified by the module version,
+// as in "example.com/foo@v1.2.3#Bar".
+//
+// The name of an instantiated generic type includes its type
+// arguments in square brackets, separated by commas.
+// Each argument is a type expression in the syntax
+// accepted by Parse, for example:
+//
+//	example.com/foo#List[example.com/bar#T]
+//	example.com/foo#Map[string,[]*example.com/bar#T]
+type TypeName string
+
+// MakeTypeName returns the name of the type with the given name
+// declared in the given package, instantiated with the given
+// type arguments, if any.
+func MakeTypeName(pkgPath, name string, typeArgs ...string) TypeName {
+	if len(typeArgs) > 0 {
+		name += "[" + strings.Join(typeArgs, ",") + "]"
+	}
+	if pkgPath == "" {
+		return TypeName(name)
+	}
+	return TypeName(pkgPath + "#" + name)
+}
+
+// ParseTypeName parses a type name, checking that it is
+// well formed and removing any white space from its
+// type arguments.
+func ParseTypeName(s string) (TypeName, error) {
+	n := TypeName(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s))
+	base := n.Origin().Name()
+	if base == "" {
+		return "", fmt.Errorf("invalid type name %q: no name", s)
+	}
+	for _, r := range base {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return "", fmt.Errorf("invalid type name %q: invalid character %q in name", s, r)
+		}
+	}
+	i := strings.Index(string(n), "[")
+	if i == -1 {
+		return n, nil
+	}
+	if !strings.HasSuffix(string(n), "]") {
+		return "", fmt.Errorf("invalid type name %q: unterminated type arguments", s)
+	}
+	for _, arg := range n.TypeArgs() {
+		if _, err := Parse(arg); err != nil {
+			return "", fmt.Errorf("invalid type name %q: bad type argument: %v", s, err)
+		}
+	}
+	return n, nil
+}
+
+// PkgPath returns the package path of the type,
+// or the empty string for a predeclared type.
+// Any module version is not included.
+func (n TypeName) PkgPath() string {
+	p, _ := n.split()
+	if i := strings.Index(p, "@"); i >= 0 {
+		return p[0:i]
+	}
+	return p
+}
+
+// Version returns the module version that qualifies
+// the type's package path, or the empty string if
+// there is none.
+func (n TypeName) Version() string {
+	p, _ := n.split()
+	if i := strings.Index(p, "@"); i >= 0 {
+		return p[i+1:]
+	}
+	return ""
+}
+
+// WithVersion returns n with its package path qualified
+// by the given module version. If version is empty, any
+// existing version is removed. Names of predeclared types
+// are returned unchanged. Type arguments are not affected.
+func (n TypeName) WithVersion(version string) TypeName {
+	pkg, name := n.split()
+	if pkg == "" {
+		return n
+	}
+	if i := strings.Index(pkg, "@"); i >= 0 {
+		pkg = pkg[0:i]
+	}
+	if version != "" {
+		pkg += "@" + version
+	}
+	return TypeName(pkg + "#" + name)
+}
+
+// WithoutVersions returns n with all module versions
+// removed, including those in its type arguments.
+func (n TypeName) WithoutVersions() TypeName {
+	return n.mapNames(func(n TypeName) TypeName {
+		return n.WithVersion("")
+	})
+}
+
+// Name returns the name of the type without its package path.
+// For an instantiated type, it includes the type arguments.
+func (n TypeName) Name() string {
+	_, name := n.split()
+	return name
+}
+
+// Origin returns the name of the generic type that n
+// is an instantiation of. If n has no type arguments,
+// it returns n.
+func (n TypeName) Origin() TypeName {
+	if i := strings.Index(string(n), "["); i >= 0 {
+		return n[0:i]
+	}
+	return n
+}
+
+// TypeArgs returns the type arguments of an instantiated
+// generic type, or nil if there are none.
+func (n TypeName) TypeArgs() []string {
+	sn := string(n)
+	i := strings.Index(sn, "[")
+	if i == -1 || !strings.HasSuffix(sn, "]") {
+		return nil
+	}
+	var args []string
+	depth := 0
+	start := i + 1
+	for j := start; j < len(sn)-1; j++ {
+		switch sn[j] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, sn[start:j])
+				start = j + 1
+			}
+		}
+	}
+	return append(args, sn[start:len(sn)-1])
+}
+
+// MajorVersion returns the major version suffix of the module
+// with path modPath if it holds the type's package (for example
+// "v2" for a type in example.com/m/v2/foo when modPath is
+// example.com/m/v2), or the empty string otherwise.
+func (n TypeName) MajorVersion(modPath string) string {
+	_, major := SplitPathVersion(n.PkgPath(), modPath)
+	return major
+}
+
+// WithoutMajorVersion returns n with the major version suffix
+// of the module with path modPath removed from its package path
+// and the package paths of its type arguments, so that, for
+// example, with modPath example.com/m/v2, example.com/m/v2#T
+// maps to example.com/m#T. Packages in other modules are
+// not affected.
+func (n TypeName) WithoutMajorVersion(modPath string) TypeName {
+	return n.mapNames(func(n TypeName) TypeName {
+		pkg, name := n.split()
+		if pkg == "" {
+			return n
+		}
+		version := n.Version()
+		prefix, _ := SplitPathVersion(n.PkgPath(), modPath)
+		return MakeTypeName(prefix, name).WithVersion(version)
+	})
+}
+
+// WithPackagePath returns n with the package path of n and
+// those of its type arguments changed from oldPath to newPath.
+// Packages inside oldPath are moved to the corresponding
+// path inside newPath, so that, for example, with oldPath
+// example.com/m and newPath example.org/m/v2, the name
+// example.com/m/foo#T maps to example.org/m/v2/foo#T.
+// Any module version is retained.
+func (n TypeName) WithPackagePath(oldPath, newPath string) TypeName {
+	return n.mapNames(func(n TypeName) TypeName {
+		pkg, name := n.split()
+		if pkg == "" {
+			return n
+		}
+		pkgPath := n.PkgPath()
+		if !inModule(pkgPath, oldPath) {
+			return n
+		}
+		return MakeTypeName(newPath+pkgPath[len(oldPath):], name).WithVersion(n.Version())
+	})
+}
+
+// SplitPathVersion splits the path of a package in the module
+// with path modPath into the package path without the module's
+// major version suffix and the major version itself. Both the
+// usual "/vN" form and the gopkg.in ".vN" form are recognized,
+// for N >= 2. If the package is not in the module or the module
+// has no such suffix, it returns pkgPath, "".
+//
+// For example, with modPath "example.com/m/v2", the package path
+// "example.com/m/v2/foo" splits into "example.com/m/foo" and
+// "v2". Path elements that look like major versions but are not
+// part of the module path, such as the final element of
+// k8s.io/api/autoscaling/v2 in the module k8s.io/api, are
+// left alone.
+func SplitPathVersion(pkgPath, modPath string) (prefix, major string) {
+	if !inModule(pkgPath, modPath) {
+		return pkgPath, ""
+	}
+	modPrefix, pathMajor, ok := module.SplitPathVersion(modPath)
+	major = strings.TrimLeft(pathMajor, "./")
+	if !ok || !isMajorVersion(major) {
+		return pkgPath, ""
+	}
+	return modPrefix + pkgPath[len(modPath):], major
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' || s[1] < '1' || s[1] > '9' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != "v1"
+}
+
+func (n TypeName) split() (string, string) {
+	sn := string(n)
+	// Type arguments may themselves contain hashes,
+	// so look only at the name before them.
+	base := sn
+	if i := strings.Index(sn, "["); i >= 0 {
+		base = sn[0:i]
+	}
+	i := strings.LastIndex(base, "#")
+	if i == -1 {
+		return "", sn
+	}
+	return sn[0:i], sn[i+1:]
+}
+
+// mapNames returns the result of calling f on the origin
+// of n and on all type names found in its type arguments.
+func (n TypeName) mapNames(f func(TypeName) TypeName) TypeName {
+	origin := f(n.Origin())
+	args := n.TypeArgs()
+	if args == nil {
+		return origin
+	}
+	for i, arg := range args {
+		args[i] = rewriteWords(arg, func(word string) string {
+			if !strings.Contains(word, "#") {
+				return word
+			}
+			return string(TypeName(word).mapNames(f))
+		})
+	}
+	return MakeTypeName(origin.PkgPath(), origin.Name(), args...)
+}
+
+// reflectTypeName returns the name for a type with the
+// given package path and name as returned by reflect.
+// The reflect package formats type arguments with
+// qualified names of the form "example.com/foo.Bar",
+// which are converted into the TypeName syntax.
+func reflectTypeName(pkgPath, name string) TypeName {
+	i := strings.Index(name, "[")
+	if i == -1 {
+		return MakeTypeName(pkgPath, name)
+	}
+	args := TypeName(name).TypeArgs()
+	for j, arg := range args {
+		args[j] = rewriteWords(arg, func(word string) string {
+			if k := strings.LastIndex(word, "."); k >= 0 {
+				return word[0:k] + "#" + word[k+1:]
+			}
+			return word
+		})
+	}
+	return MakeTypeName(pkgPath, name[0:i], args...)
+}
+
+// rewriteWords calls f on each maximal sequence of
+// characters in s that can make up a qualified type name
+// and replaces it with the result.
+func rewriteWords(s string, f func(string) string) string {
+	var buf strings.Builder
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if !isWordRune(r) {
+				break
+			}
+			i += size
+		}
+		if i > 0 {
+			buf.WriteString(f(s[0:i]))
+			s = s[i:]
+			continue
+		}
+		// Copy the non-word character.
+		_, size := utf8.DecodeRuneInString(s)
+		buf.WriteString(s[0:size])
+		s = s[size:]
+	}
+	return buf.String()
+}
@@ -0,0 +1,56 @@
+package jsontypes_test
+
+import (
+	"testing"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var splitPathVersionTests = []struct {
+	pkgPath, modPath string
+	prefix, major    string
+}{{
+	pkgPath: "example.com/m/v2/foo",
+	modPath: "example.com/m/v2",
+	prefix:  "example.com/m/foo",
+	major:   "v2",
+}, {
+	pkgPath: "example.com/m/v2",
+	modPath: "example.com/m/v2",
+	prefix:  "example.com/m",
+	major:   "v2",
+}, {
+	pkgPath: "example.com/m/foo",
+	modPath: "example.com/m",
+	prefix:  "example.com/m/foo",
+}, {
+	// A final element that looks like a major version
+	// but isn't part of the module path is left alone.
+	pkgPath: "k8s.io/api/autoscaling/v2",
+	modPath: "k8s.io/api",
+	prefix:  "k8s.io/api/autoscaling/v2",
+}, {
+	pkgPath: "gopkg.in/yaml.v3",
+	modPath: "gopkg.in/yaml.v3",
+	prefix:  "gopkg.in/yaml",
+	major:   "v3",
+}, {
+	pkgPath: "example.com/other/v2",
+	modPath: "example.com/m/v2",
+	prefix:  "example.com/other/v2",
+}, {
+	// A package path that merely starts with the module
+	// path is not in the module.
+	pkgPath: "example.com/m/v23/foo",
+	modPath: "example.com/m/v2",
+	prefix:  "example.com/m/v23/foo",
+}}
+
+func TestSplitPathVersion(t *testing.T) {
+	for _, test := range splitPathVersionTests {
+		prefix, major := jsontypes.SplitPathVersion(test.pkgPath, test.modPath)
+		if prefix != test.prefix || major != test.major {
+			t.Errorf("SplitPathVersion(%q, %q): got %q, %q want %q, %q", test.pkgPath, test.modPath, prefix, major, test.prefix, test.major)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package jsontypes
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse parses a Go type expression into a Type.
+// Named types are written as the package path and the
+// type name separated by a hash, as in TypeName,
+// and are returned as references (with only the Name
+// field set), as created by Info.Ref.
+//
+// For example:
+//
+//	map[string][]*example.com/foo#Bar
+//	func(int, ...string) error
+//	struct{Name string `json:"name"`}
+//	interface{Close() error}
+//	interface{~int | ~float64}
+func Parse(s string) (*Type, error) {
+	p := &parser{
+		s: s,
+	}
+	t, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %q: %v", s, err)
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error.
+func MustParse(s string) *Type {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var predeclared = map[string]Kind{
+	"bool":       Bool,
+	"int":        Int,
+	"int8":       Int8,
+	"int16":      Int16,
+	"int32":      Int32,
+	"int64":      Int64,
+	"uint":       Uint,
+	"uint8":      Uint8,
+	"uint16":     Uint16,
+	"uint32":     Uint32,
+	"uint64":     Uint64,
+	"uintptr":    Uintptr,
+	"float32":    Float32,
+	"float64":    Float64,
+	"complex64":  Complex64,
+	"complex128": Complex128,
+	"string":     String,
+	"byte":       Uint8,
+	"rune":       Int32,
+}
+
+// parseError is used to abort parsing; it is
+// recovered by parser.parse.
+type parseError struct {
+	err error
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) parse() (t *Type, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			perr, ok := e.(parseError)
+			if !ok {
+				panic(e)
+			}
+			t, err = nil, perr.err
+		}
+	}()
+	t = p.typ()
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		p.fail("unexpected %q", p.s[p.pos:])
+	}
+	return t, nil
+}
+
+func (p *parser) fail(f string, a ...interface{}) {
+	panic(parseError{fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(f, a...))})
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// accept reports whether the input continues with tok,
+// after any white space, and consumes it if so.
+func (p *parser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(tok string) {
+	if !p.accept(tok) {
+		p.fail("expected %q", tok)
+	}
+}
+
+// peek reports whether the input continues with tok
+// after any white space, without consuming it.
+func (p *parser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.s[p.pos:], tok)
+}
+
+func (p *parser) typ() *Type {
+	p.skipSpace()
+	switch {
+	case p.accept("[]"):
+		return &Type{
+			Kind: Slice,
+			Elem: p.typ(),
+		}
+	case p.accept("["):
+		n := p.number()
+		p.expect("]")
+		return &Type{
+			Kind: Array,
+			Len:  n,
+			Elem: p.typ(),
+		}
+	case p.accept("*"):
+		return &Type{
+			Kind: Ptr,
+			Elem: p.typ(),
+		}
+	case p.accept("<-"):
+		p.expect("chan")
+		return &Type{
+			Kind: Chan,
+			Elem: p.typ(),
+		}
+	}
+	word := p.word()
+	switch word {
+	case "":
+		p.fail("expected type")
+	case "map":
+		p.expect("[")
+		key := p.typ()
+		p.expect("]")
+		return &Type{
+			Kind: Map,
+			Key:  key,
+			Elem: p.typ(),
+		}
+	case "chan":
+		p.accept("<-")
+		return &Type{
+			Kind: Chan,
+			Elem: p.typ(),
+		}
+	case "func":
+		return p.signature()
+	case "struct":
+		return p.structType()
+	case "interface":
+		return p.interfaceType()
+	case "any":
+		return &Type{
+			Kind: Interface,
+		}
+	case "error":
+		return errorType()
+	case "unsafe.Pointer":
+		return &Type{
+			Kind: UnsafePointer,
+		}
+	case "unsupported":
+		return &Type{
+			Kind: Unsupported,
+		}
+	}
+	if kind, ok := predeclared[word]; ok {
+		return &Type{
+			Name: TypeName(word),
+			Kind: kind,
+		}
+	}
+	if !strings.Contains(word, "#") {
+		p.fail("unknown type %q", word)
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == '[' {
+		word += p.typeArgs()
+	}
+	name, err := ParseTypeName(word)
+	if err != nil {
+		p.fail("%v", err)
+	}
+	return &Type{
+		Name: name,
+	}
+}
+
+// typeArgs scans the bracketed type arguments
+// following a generic type name.
+func (p *parser) typeArgs() string {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return p.s[start:p.pos]
+			}
+		}
+	}
+	p.fail("unterminated type arguments")
+	return ""
+}
+
+// errorType returns the representation of the
+// predeclared error type.
+func errorType() *Type {
+	return &Type{
+		Name: "error",
+		Kind: Interface,
+		Methods: map[string]*Method{
+			"Error": {
+				Name: "Error",
+				Type: &Type{
+					Kind: Func,
+					Out: []*Type{{
+						Name: "string",
+						Kind: String,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// word scans a keyword, identifier or type name,
+// including its package path if present.
+func (p *parser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, n := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !isWordRune(r) {
+			break
+		}
+		p.pos += n
+	}
+	return p.s[start:p.pos]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_./#~-@+", r)
+}
+
+func (p *parser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, n := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		p.pos += n
+	}
+	if start == p.pos {
+		p.fail("expected identifier")
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) number() int {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.fail("expected number")
+	}
+	return n
+}
+
+// signature parses the parameters and results of a
+// function type, after the func keyword or method name.
+func (p *parser) signature() *Type {
+	t := &Type{
+		Kind: Func,
+		In:   []*Type{},
+		Out:  []*Type{},
+	}
+	p.expect("(")
+	for !p.accept(")") {
+		if len(t.In) > 0 {
+			p.expect(",")
+		}
+		if p.accept("...") {
+			t.Variadic = true
+			t.In = append(t.In, &Type{
+				Kind: Slice,
+				Elem: p.typ(),
+			})
+			p.expect(")")
+			break
+		}
+		t.In = append(t.In, p.typ())
+	}
+	switch {
+	case p.accept("("):
+		for !p.accept(")") {
+			if len(t.Out) > 0 {
+				p.expect(",")
+			}
+			t.Out = append(t.Out, p.typ())
+		}
+	case p.atTypeStart():
+		t.Out = append(t.Out, p.typ())
+	}
+	return t
+}
+
+// atTypeStart reports whether the input looks like
+// it continues with a type.
+func (p *parser) atTypeStart() bool {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(p.s[p.pos:])
+	return r == '[' || r == '*' || r == '<' || unicode.IsLetter(r)
+}
+
+func (p *parser) structType() *Type {
+	t := &Type{
+		Kind: Struct,
+	}
+	p.expect("{")
+	for !p.accept("}") {
+		f := &Field{}
+		start := p.pos
+		if !p.peek("*") {
+			name := p.word()
+			if !p.peek(";") && !p.peek("}") && !p.peek("`") && !p.peek(`"`) {
+				f.Name = name
+				f.Type = p.typ()
+			}
+		}
+		if f.Type == nil {
+			// Embedded field.
+			p.pos = start
+			f.Anonymous = true
+			f.Type = p.typ()
+			if f.Type.Kind == Ptr {
+				f.Name = f.Type.Elem.Name.Name()
+			} else {
+				f.Name = f.Type.Name.Name()
+			}
+			if f.Name == "" {
+				p.fail("invalid embedded field type")
+			}
+		}
+		if p.peek("`") || p.peek(`"`) {
+			f.Tag = p.tag()
+			f.Default = reflect.StructTag(f.Tag).Get("default")
+		}
+		f.Index = len(t.Fields)
+		f.EncodedName = encodedName(f)
+		t.Fields = append(t.Fields, f)
+		p.accept(";")
+	}
+	return t
+}
+
+func (p *parser) tag() string {
+	p.skipSpace()
+	quote := p.s[p.pos]
+	end := p.pos + 1
+	for end < len(p.s) && p.s[end] != quote {
+		if quote == '"' && p.s[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.s) {
+		p.fail("unterminated struct tag")
+	}
+	lit := p.s[p.pos : end+1]
+	p.pos = end + 1
+	tag, err := strconv.Unquote(lit)
+	if err != nil {
+		p.fail("invalid struct tag %s", lit)
+	}
+	return tag
+}
+
+func (p *parser) interfaceType() *Type {
+	t := &Type{
+		Kind: Interface,
+	}
+	p.expect("{")
+	for !p.accept("}") {
+		if !p.atMethod() {
+			for {
+				tilde := p.accept("~")
+				t.Terms = append(t.Terms, &Term{
+					Tilde: tilde,
+					Type:  p.typ(),
+				})
+				if !p.accept("|") {
+					break
+				}
+			}
+			p.accept(";")
+			continue
+		}
+		name := p.ident()
+		if t.Methods == nil {
+			t.Methods = make(map[string]*Method)
+		}
+		t.Methods[name] = &Method{
+			Name: name,
+			Type: p.signature(),
+		}
+		p.accept(";")
+	}
+	return t
+}
+
+// atMethod reports whether the input continues with
+// a method name followed by its signature.
+func (p *parser) atMethod() bool {
+	p.skipSpace()
+	i := p.pos
+	for i < len(p.s) {
+		r, n := utf8.DecodeRuneInString(p.s[i:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		i += n
+	}
+	return i > p.pos && p.s[p.pos:i] != "func" && strings.HasPrefix(p.s[i:], "(")
+}
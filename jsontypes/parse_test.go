@@ -0,0 +1,121 @@
+package jsontypes_test
+
+import (
+	"testing"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+var parseTests = []struct {
+	s string
+	// want holds the formatted type if it's
+	// different from s. Formatting without an Info
+	// abbreviates package paths, and Type does not
+	// record channel directions.
+	want string
+}{
+	{s: "int"},
+	{s: "[]string"},
+	{s: "[4]uint8"},
+	{s: "[0]int"},
+	{s: "*example.com/foo#Bar", want: "*foo.Bar"},
+	{s: "map[string][]*example.com/foo#Bar", want: "map[string][]*foo.Bar"},
+	{s: "map[example.com/foo#Key]map[int]bool", want: "map[foo.Key]map[int]bool"},
+	{s: "chan int"},
+	{s: "chan<- int", want: "chan int"},
+	{s: "<-chan []byte", want: "chan []byte"},
+	{s: "func()"},
+	{s: "func(int, string) error"},
+	{s: "func(int) (string, error)"},
+	{s: "func(string, ...int)"},
+	{s: "func(...interface{}) (int, error)"},
+	{s: "struct{}"},
+	{s: "struct{A int; B string}"},
+	{s: "struct{Name string `json:\"name,omitempty\"`}"},
+	{s: "struct{A int `json:\"a\" yaml:\"a\"`; B bool}"},
+	{s: "struct{example.com/foo#Bar; *example.com/foo#Baz}", want: "struct{foo.Bar; *foo.Baz}"},
+	{s: "interface{}"},
+	{s: "any", want: "interface{}"},
+	{s: "interface{Close() error; Read([]uint8) (int, error)}"},
+	{s: "interface{~int | ~float64}"},
+	{s: "example.com/foo#List[int]", want: "foo.List[int]"},
+	{s: "example.com/foo#Map[string, example.com/foo#List[int]]", want: "foo.Map[string,foo.List[int]]"},
+	{s: "map[string]example.com/foo#Pair[int, bool]", want: "map[string]foo.Pair[int,bool]"},
+	{s: "unsafe.Pointer"},
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range parseTests {
+		jt, err := jsontypes.Parse(test.s)
+		if err != nil {
+			t.Errorf("%s: %v", test.s, err)
+			continue
+		}
+		want := test.want
+		if want == "" {
+			want = test.s
+		}
+		got := jsontypes.Format(nil, jt)
+		if got != want {
+			t.Errorf("%s: got %q want %q", test.s, got, want)
+			continue
+		}
+		if test.want != "" {
+			continue
+		}
+		// The formatted form should parse to the same type.
+		jt1, err := jsontypes.Parse(got)
+		if err != nil {
+			t.Errorf("%s: cannot reparse: %v", test.s, err)
+			continue
+		}
+		if got1 := jsontypes.Format(nil, jt1); got1 != got {
+			t.Errorf("%s: reparsed as %q", test.s, got1)
+		}
+	}
+}
+
+var parseErrorTests = []struct {
+	s    string
+	want string
+}{{
+	s:    "",
+	want: `cannot parse "": at offset 0: expected type`,
+}, {
+	s:    "foo",
+	want: `cannot parse "foo": at offset 3: unknown type "foo"`,
+}, {
+	s:    "[]",
+	want: `cannot parse "[]": at offset 2: expected type`,
+}, {
+	s:    "[x]int",
+	want: `cannot parse "[x]int": at offset 1: expected number`,
+}, {
+	s:    "map[string",
+	want: `cannot parse "map[string": at offset 10: expected "]"`,
+}, {
+	s:    "func(int",
+	want: `cannot parse "func(int": at offset 8: expected ","`,
+}, {
+	s:    "struct{A int",
+	want: `cannot parse "struct{A int": at offset 12: expected type`,
+}, {
+	s:    "int string",
+	want: `cannot parse "int string": at offset 4: unexpected "string"`,
+}, {
+	s:    "example.com/foo#List[int",
+	want: `cannot parse "example.com/foo#List[int": at offset 24: unterminated type arguments`,
+}}
+
+func TestParseError(t *testing.T) {
+	for _, test := range parseErrorTests {
+		_, err := jsontypes.Parse(test.s)
+		if err == nil {
+			t.Errorf("%s: unexpected success", test.s)
+			continue
+		}
+		if err.Error() != test.want {
+			t.Errorf("%s: got error %q want %q", test.s, err, test.want)
+		}
+	}
+}
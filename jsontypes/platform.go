@@ -0,0 +1,124 @@
+package jsontypes
+
+import "fmt"
+
+// MergePlatforms merges the given Info values, each holding the
+// types of the same packages as built for a different platform
+// (for example a GOOS/GOARCH pair), into a single Info.
+// The name of the platform for infos[i] is held in platforms[i].
+//
+// Named types that are present only on some of the platforms have
+// their Platforms field set to the names of those platforms; similarly
+// fields and methods that are present only on some of the platforms
+// that their type is present on have their Platforms field set.
+// When the definitions of a named type differ in other ways, the
+// definition from the first platform that holds the type is used.
+// Package-level functions, variables and constants are similarly
+// taken from the first platform that holds them.
+//
+// The returned Info shares no types with the originals, but
+// the metadata is taken from infos[0].
+func MergePlatforms(platforms []string, infos []*Info) *Info {
+	if len(platforms) != len(infos) {
+		panic(fmt.Errorf("mismatched platform count; got %d names for %d infos", len(platforms), len(infos)))
+	}
+	merged := NewInfo()
+	if len(infos) == 0 {
+		return merged
+	}
+	merged.Meta = infos[0].Meta
+	merged.Facades = infos[0].Facades
+	merged.Services = infos[0].Services
+	merged.Routes = infos[0].Routes
+	seenWarnings := make(map[string]bool)
+	for _, info := range infos {
+		for _, w := range info.Warnings {
+			if !seenWarnings[w] {
+				seenWarnings[w] = true
+				merged.Warnings = append(merged.Warnings, w)
+			}
+		}
+	}
+	for _, info := range infos {
+		for name, fn := range info.Funcs {
+			if merged.Funcs == nil {
+				merged.Funcs = make(map[TypeName]*Function)
+			}
+			if merged.Funcs[name] == nil {
+				merged.Funcs[name] = fn
+			}
+		}
+		for name, v := range info.Vars {
+			if merged.Vars == nil {
+				merged.Vars = make(map[TypeName]*Var)
+			}
+			if merged.Vars[name] == nil {
+				merged.Vars[name] = v
+			}
+		}
+		for name, c := range info.Consts {
+			if merged.Consts == nil {
+				merged.Consts = make(map[TypeName]*Const)
+			}
+			if merged.Consts[name] == nil {
+				merged.Consts[name] = c
+			}
+		}
+	}
+	typePlatforms := make(map[*Type][]string)
+	fieldPlatforms := make(map[*Field][]string)
+	methodPlatforms := make(map[*Method][]string)
+	for i, info := range infos {
+		platform := platforms[i]
+		for _, name := range info.sortedNames() {
+			t := info.Types[name]
+			mt := merged.Types[name]
+			if mt == nil {
+				t1 := *t
+				t1.Fields = nil
+				t1.Methods = nil
+				mt = &t1
+				merged.Types[name] = mt
+			}
+			typePlatforms[mt] = append(typePlatforms[mt], platform)
+			for _, f := range t.Fields {
+				mf := mt.FieldByName(f.Name)
+				if mf == nil {
+					f1 := *f
+					mf = &f1
+					mt.Fields = append(mt.Fields, mf)
+				}
+				fieldPlatforms[mf] = append(fieldPlatforms[mf], platform)
+			}
+			for mname, m := range t.Methods {
+				mm := mt.Methods[mname]
+				if mm == nil {
+					m1 := *m
+					mm = &m1
+					if mt.Methods == nil {
+						mt.Methods = make(map[string]*Method)
+					}
+					mt.Methods[mname] = mm
+				}
+				methodPlatforms[mm] = append(methodPlatforms[mm], platform)
+			}
+		}
+	}
+	for _, t := range merged.Types {
+		tps := typePlatforms[t]
+		if len(tps) < len(platforms) {
+			t.Platforms = tps
+		}
+		for _, f := range t.Fields {
+			if fps := fieldPlatforms[f]; len(fps) < len(tps) {
+				f.Platforms = fps
+			}
+		}
+		for _, m := range t.Methods {
+			if mps := methodPlatforms[m]; len(mps) < len(tps) {
+				m.Platforms = mps
+			}
+		}
+	}
+	return merged
+}
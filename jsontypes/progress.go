@@ -0,0 +1,98 @@
+package jsontypes
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Progress describes the progress of a long-running
+// operation such as extracting or checking types.
+type Progress struct {
+	// Stage names the operation in progress, for
+	// example "extract" or "check".
+	Stage string
+
+	// Item names the item that has just been processed,
+	// such as a type or package name.
+	Item string
+
+	// Done holds the number of items processed so far.
+	Done int
+
+	// Total holds the total number of items to process,
+	// or zero if that is not known.
+	Total int
+
+	// Elapsed holds the time since the operation started.
+	Elapsed time.Duration
+}
+
+// ProgressFunc is called to report progress.
+type ProgressFunc func(Progress)
+
+// SlogProgress returns a ProgressFunc that logs
+// progress to the given logger at the given level.
+func SlogProgress(logger *slog.Logger, level slog.Level) ProgressFunc {
+	return func(p Progress) {
+		attrs := []slog.Attr{
+			slog.String("item", p.Item),
+			slog.Int("done", p.Done),
+		}
+		if p.Total > 0 {
+			attrs = append(attrs, slog.Int("total", p.Total))
+		}
+		attrs = append(attrs, slog.Duration("elapsed", p.Elapsed))
+		logger.LogAttrs(context.Background(), level, p.Stage, attrs...)
+	}
+}
+
+// ProgressReporter makes it straightforward to
+// call a ProgressFunc for successive items.
+// The zero value (or a nil *ProgressReporter)
+// discards all progress reports.
+type ProgressReporter struct {
+	f     ProgressFunc
+	stage string
+	total int
+	done  int
+	start time.Time
+}
+
+// NewProgressReporter returns a ProgressReporter that calls f,
+// which may be nil, for items processed within the given
+// stage. The total may be zero if unknown.
+func NewProgressReporter(f ProgressFunc, stage string, total int) *ProgressReporter {
+	return &ProgressReporter{
+		f:     f,
+		stage: stage,
+		total: total,
+		start: time.Now(),
+	}
+}
+
+// Report reports that the given item has been processed.
+func (r *ProgressReporter) Report(item string) {
+	if r == nil || r.f == nil {
+		return
+	}
+	r.done++
+	r.f(Progress{
+		Stage:   r.stage,
+		Item:    item,
+		Done:    r.done,
+		Total:   r.total,
+		Elapsed: time.Since(r.start),
+	})
+}
+
+// SetProgress sets a function to be called after each named type
+// has been added to info by TypeInfo or Ref, including its fields
+// and methods. If f is nil, progress is not reported.
+func (info *Info) SetProgress(f ProgressFunc) {
+	if f == nil {
+		info.progress = nil
+		return
+	}
+	info.progress = NewProgressReporter(f, "extract", 0)
+}
@@ -0,0 +1,181 @@
+// Package protoload builds jsontypes.Info values describing
+// gRPC services from protobuf descriptors. It's separate from
+// package jsontypes so that programs that don't use protobuf
+// don't depend on it.
+package protoload
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// AddServices adds all the services declared in the given
+// protobuf file to info, and returns info.
+func AddServices(info *jsontypes.Info, fd protoreflect.FileDescriptor) *jsontypes.Info {
+	services := fd.Services()
+	for i := 0; i < services.Len(); i++ {
+		ServiceInfo(info, services.Get(i))
+	}
+	return info
+}
+
+// ServiceInfo adds the gRPC service with the given descriptor
+// to info, and returns it. The request and response message
+// types, and all message and enum types they use, are
+// added to info too.
+//
+// Message types are named by their protobuf package and
+// their name within it, for example "helloworld#HelloRequest".
+// Fields are named by their JSON name, and each one has a
+// "protobuf" tag holding its field number, so that fields
+// that have been renumbered are reported as changed.
+// Enum types have kind int32.
+func ServiceInfo(info *jsontypes.Info, sd protoreflect.ServiceDescriptor) *jsontypes.Service {
+	s := &jsontypes.Service{
+		Name:    string(sd.FullName()),
+		Methods: make(map[string]*jsontypes.ServiceMethod),
+		Doc:     docOf(sd),
+	}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		m := &jsontypes.ServiceMethod{
+			Name:            string(md.Name()),
+			Request:         messageType(info, md.Input()),
+			Response:        messageType(info, md.Output()),
+			ClientStreaming: md.IsStreamingClient(),
+			ServerStreaming: md.IsStreamingServer(),
+			Doc:             docOf(md),
+		}
+		m.Stability = jsontypes.StabilityFromDoc(m.Doc)
+		s.Methods[m.Name] = m
+	}
+	info.AddService(s)
+	return s
+}
+
+// messageType adds the given message type to info
+// and returns a reference to it.
+func messageType(info *jsontypes.Info, md protoreflect.MessageDescriptor) *jsontypes.Type {
+	name := typeName(md)
+	if info.Types[name] == nil {
+		t := &jsontypes.Type{
+			Name: name,
+			Kind: jsontypes.Struct,
+			Doc:  docOf(md),
+		}
+		// Add the type to the info first to prevent infinite recursion.
+		info.Types[name] = t
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			f := &jsontypes.Field{
+				Name: fd.JSONName(),
+				Type: fieldType(info, fd),
+				Tag:  `protobuf:"` + strconv.Itoa(int(fd.Number())) + `"`,
+				Doc:  docOf(fd),
+			}
+			t.Fields = append(t.Fields, f)
+		}
+	}
+	return &jsontypes.Type{
+		Name: name,
+	}
+}
+
+// fieldType returns the type of the given field.
+func fieldType(info *jsontypes.Info, fd protoreflect.FieldDescriptor) *jsontypes.Type {
+	switch {
+	case fd.IsMap():
+		return &jsontypes.Type{
+			Kind: jsontypes.Map,
+			Key:  valueType(info, fd.MapKey()),
+			Elem: valueType(info, fd.MapValue()),
+		}
+	case fd.IsList():
+		return &jsontypes.Type{
+			Kind: jsontypes.Slice,
+			Elem: valueType(info, fd),
+		}
+	case fd.HasPresence() && fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind:
+		return &jsontypes.Type{
+			Kind: jsontypes.Ptr,
+			Elem: valueType(info, fd),
+		}
+	}
+	return valueType(info, fd)
+}
+
+// valueType returns the type of a single value
+// of the given field, ignoring its cardinality.
+func valueType(info *jsontypes.Info, fd protoreflect.FieldDescriptor) *jsontypes.Type {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return &jsontypes.Type{
+			Kind: jsontypes.Ptr,
+			Elem: messageType(info, fd.Message()),
+		}
+	case protoreflect.EnumKind:
+		ed := fd.Enum()
+		name := typeName(ed)
+		if info.Types[name] == nil {
+			info.Types[name] = &jsontypes.Type{
+				Name: name,
+				Kind: jsontypes.Int32,
+				Doc:  docOf(ed),
+			}
+		}
+		return &jsontypes.Type{
+			Name: name,
+		}
+	case protoreflect.BytesKind:
+		return &jsontypes.Type{
+			Kind: jsontypes.Slice,
+			Elem: &jsontypes.Type{Kind: jsontypes.Uint8},
+		}
+	}
+	return &jsontypes.Type{
+		Kind: kinds[fd.Kind()],
+	}
+}
+
+var kinds = map[protoreflect.Kind]jsontypes.Kind{
+	protoreflect.BoolKind:     jsontypes.Bool,
+	protoreflect.Int32Kind:    jsontypes.Int32,
+	protoreflect.Sint32Kind:   jsontypes.Int32,
+	protoreflect.Sfixed32Kind: jsontypes.Int32,
+	protoreflect.Int64Kind:    jsontypes.Int64,
+	protoreflect.Sint64Kind:   jsontypes.Int64,
+	protoreflect.Sfixed64Kind: jsontypes.Int64,
+	protoreflect.Uint32Kind:   jsontypes.Uint32,
+	protoreflect.Fixed32Kind:  jsontypes.Uint32,
+	protoreflect.Uint64Kind:   jsontypes.Uint64,
+	protoreflect.Fixed64Kind:  jsontypes.Uint64,
+	protoreflect.FloatKind:    jsontypes.Float32,
+	protoreflect.DoubleKind:   jsontypes.Float64,
+	protoreflect.StringKind:   jsontypes.String,
+}
+
+// typeName returns the name to use for the
+// given message or enum type. Nested types are
+// named with dots, as in protobuf.
+func typeName(d protoreflect.Descriptor) jsontypes.TypeName {
+	pkg := string(d.ParentFile().Package())
+	name := strings.TrimPrefix(string(d.FullName()), pkg+".")
+	if pkg == "" {
+		// There's no package name, so use the file
+		// path to avoid clashing with predeclared types.
+		pkg = d.ParentFile().Path()
+	}
+	return jsontypes.MakeTypeName(pkg, name)
+}
+
+// docOf returns the leading comment of the given
+// descriptor, if known.
+func docOf(d protoreflect.Descriptor) string {
+	return strings.TrimSpace(d.ParentFile().SourceLocations().ByDescriptor(d).LeadingComments)
+}
@@ -0,0 +1,149 @@
+package protoload_test
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/protoload"
+)
+
+// testFile describes the following protobuf file:
+//
+//	syntax = "proto3";
+//	package helloworld;
+//
+//	service Greeter {
+//		rpc SayHello(HelloRequest) returns (stream HelloReply);
+//	}
+//	enum Mood { HAPPY = 0; }
+//	message HelloRequest {
+//		string name = 1;
+//		repeated Mood moods = 2;
+//		map<string, int64> counts = 3;
+//	}
+//	message HelloReply {
+//		bytes message = 1;
+//		optional int32 n = 2;
+//		HelloRequest req = 3;
+//	}
+var testFile = &descriptorpb.FileDescriptorProto{
+	Name:    proto.String("helloworld.proto"),
+	Package: proto.String("helloworld"),
+	Syntax:  proto.String("proto3"),
+	EnumType: []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("Mood"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{{
+			Name:   proto.String("HAPPY"),
+			Number: proto.Int32(0),
+		}},
+	}},
+	MessageType: []*descriptorpb.DescriptorProto{{
+		Name: proto.String("HelloRequest"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			repeated(field("moods", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".helloworld.Mood")),
+			repeated(field("counts", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".helloworld.HelloRequest.CountsEntry")),
+		},
+		NestedType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("CountsEntry"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+			},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}},
+	}, {
+		Name: proto.String("HelloReply"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""),
+			optional(field("n", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""), 0),
+			field("req", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".helloworld.HelloRequest"),
+		},
+		OneofDecl: []*descriptorpb.OneofDescriptorProto{{
+			Name: proto.String("_n"),
+		}},
+	}},
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Greeter"),
+		Method: []*descriptorpb.MethodDescriptorProto{{
+			Name:            proto.String("SayHello"),
+			InputType:       proto.String(".helloworld.HelloRequest"),
+			OutputType:      proto.String(".helloworld.HelloReply"),
+			ServerStreaming: proto.Bool(true),
+		}},
+	}},
+}
+
+func field(name string, n int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(n),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+func optional(f *descriptorpb.FieldDescriptorProto, oneof int32) *descriptorpb.FieldDescriptorProto {
+	f.Proto3Optional = proto.Bool(true)
+	f.OneofIndex = proto.Int32(oneof)
+	return f
+}
+
+func TestAddServices(t *testing.T) {
+	fd, err := protodesc.NewFile(testFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := protoload.AddServices(jsontypes.NewInfo(), fd)
+	s := info.Service("helloworld.Greeter")
+	if s == nil {
+		t.Fatalf("service not found")
+	}
+	m := s.Methods["SayHello"]
+	if m == nil {
+		t.Fatalf("method not found")
+	}
+	if got, want := m.StreamingKind(), "server streaming"; got != want {
+		t.Errorf("got streaming kind %q want %q", got, want)
+	}
+	if m.Request.Name != "helloworld#HelloRequest" || m.Response.Name != "helloworld#HelloReply" {
+		t.Errorf("got request %q, response %q", m.Request.Name, m.Response.Name)
+	}
+	tests := []struct {
+		name jsontypes.TypeName
+		want string
+	}{{
+		name: "helloworld#HelloRequest",
+		want: "struct{name string `protobuf:\"1\"`; moods []helloworld.Mood `protobuf:\"2\"`; counts map[string]int64 `protobuf:\"3\"`}",
+	}, {
+		name: "helloworld#HelloReply",
+		want: "struct{message []uint8 `protobuf:\"1\"`; n *int32 `protobuf:\"2\"`; req *helloworld.HelloRequest `protobuf:\"3\"`}",
+	}, {
+		name: "helloworld#Mood",
+		want: "int32",
+	}}
+	for _, test := range tests {
+		jt := info.Types[test.name]
+		if jt == nil {
+			t.Errorf("%s: not found", test.name)
+			continue
+		}
+		if got := jsontypes.Format(info, &jsontypes.Type{Kind: jt.Kind, Fields: jt.Fields}); got != test.want {
+			t.Errorf("%s: got %s want %s", test.name, got, test.want)
+		}
+	}
+}
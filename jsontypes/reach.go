@@ -0,0 +1,72 @@
+package jsontypes
+
+import "fmt"
+
+// Reachable returns the names of all the types in info that
+// can be reached from the given root types, including the roots
+// themselves, through struct fields, element and key types,
+// function parameters and results, and methods.
+// It returns an error if any of the roots is not in info.
+func (info *Info) Reachable(roots ...TypeName) (map[TypeName]bool, error) {
+	reached := make(map[TypeName]bool)
+	for _, root := range roots {
+		t := info.Types[root]
+		if t == nil {
+			return nil, fmt.Errorf("type %s not found", root)
+		}
+		WalkType(info, t, VisitorFuncs{
+			Type: func(t *Type) bool {
+				if t.Name == "" || info.Types[t.Name] != t {
+					return true
+				}
+				if reached[t.Name] {
+					return false
+				}
+				reached[t.Name] = true
+				return true
+			},
+		})
+	}
+	return reached, nil
+}
+
+// Prune returns a copy of info holding only the types that are
+// reachable from the given roots (see Reachable), suitable for
+// sharing as a minimal description of the API that the roots
+// define. Functions, facades, services and routes are not
+// included. The types themselves are shared with info.
+func (info *Info) Prune(roots ...TypeName) (*Info, error) {
+	reached, err := info.Reachable(roots...)
+	if err != nil {
+		return nil, err
+	}
+	pruned := NewInfo()
+	pruned.Meta = info.Meta
+	pruned.serializableOnly = info.serializableOnly
+	for name := range reached {
+		pruned.Types[name] = info.Types[name]
+	}
+	return pruned, nil
+}
+
+// References returns the names of the named types in info
+// that t refers to directly, other than through another named
+// type, each once, in the order in which they are first
+// encountered.
+// Element and key types, struct fields, function parameters
+// and results, methods and type set terms are all included.
+func (info *Info) References(t *Type) []TypeName {
+	var refs []TypeName
+	WalkType(info, t, VisitorFuncs{
+		Type: func(t1 *Type) bool {
+			if t1 == t || t1.Name == "" {
+				return true
+			}
+			if info.Types[t1.Name] == t1 {
+				refs = append(refs, t1.Name)
+			}
+			return false
+		},
+	})
+	return refs
+}
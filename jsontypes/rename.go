@@ -0,0 +1,118 @@
+package jsontypes
+
+// RenameTypes renames all the types in info, and all references
+// to them, including those inside type arguments, by calling f
+// on each name. Package-level functions, variables and
+// constants are renamed too.
+// If f returns the same name for two types, one of them
+// will be lost.
+func (info *Info) RenameTypes(f func(TypeName) TypeName) {
+	rename := func(n TypeName) TypeName {
+		if n == "" {
+			return n
+		}
+		return n.mapNames(f)
+	}
+	visited := make(map[*Type]bool)
+	var visit func(t *Type)
+	visit = func(t *Type) {
+		if t == nil || visited[t] {
+			return
+		}
+		visited[t] = true
+		t.Name = rename(t.Name)
+		for _, f := range t.Fields {
+			visit(f.Type)
+		}
+		for _, m := range t.Methods {
+			visit(m.Type)
+		}
+		for _, tp := range t.TypeParams {
+			visit(tp.Constraint)
+		}
+		for _, term := range t.Terms {
+			visit(term.Type)
+		}
+		visit(t.Key)
+		visit(t.Elem)
+		for _, in := range t.In {
+			visit(in)
+		}
+		for _, out := range t.Out {
+			visit(out)
+		}
+	}
+	types := make(map[TypeName]*Type)
+	for _, t := range info.Types {
+		visit(t)
+		types[t.Name] = t
+	}
+	info.Types = types
+	if info.Funcs != nil {
+		funcs := make(map[TypeName]*Function)
+		for _, fn := range info.Funcs {
+			fn.Name = rename(fn.Name)
+			visit(fn.Type)
+			funcs[fn.Name] = fn
+		}
+		info.Funcs = funcs
+	}
+	if info.Vars != nil {
+		vars := make(map[TypeName]*Var)
+		for _, v := range info.Vars {
+			v.Name = rename(v.Name)
+			visit(v.Type)
+			vars[v.Name] = v
+		}
+		info.Vars = vars
+	}
+	if info.Consts != nil {
+		consts := make(map[TypeName]*Const)
+		for _, c := range info.Consts {
+			c.Name = rename(c.Name)
+			visit(c.Type)
+			consts[c.Name] = c
+		}
+		info.Consts = consts
+	}
+	for _, fc := range info.Facades {
+		for _, m := range fc.Methods {
+			visit(m.Params)
+			visit(m.Result)
+		}
+	}
+	for _, s := range info.Services {
+		for _, m := range s.Methods {
+			visit(m.Request)
+			visit(m.Response)
+		}
+	}
+	for _, r := range info.Routes {
+		visit(r.Request)
+		visit(r.Response)
+		for _, t := range r.Query {
+			visit(t)
+		}
+	}
+}
+
+// SetModuleVersion qualifies the names of all the types in
+// packages inside the given module with the given version,
+// so that the types can be held in the same Info as types from
+// other versions of the module.
+func (info *Info) SetModuleVersion(modulePath, version string) {
+	info.RenameTypes(func(n TypeName) TypeName {
+		if inModule(n.PkgPath(), modulePath) {
+			return n.WithVersion(version)
+		}
+		return n
+	})
+}
+
+// inModule reports whether the package with the given
+// path is inside the given module. Nested modules
+// are not taken into account.
+func inModule(pkgPath, modulePath string) bool {
+	return pkgPath == modulePath ||
+		len(pkgPath) > len(modulePath) && pkgPath[len(modulePath)] == '/' && pkgPath[0:len(modulePath)] == modulePath
+}
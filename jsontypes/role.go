@@ -0,0 +1,104 @@
+package jsontypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role describes how values of a type are used, which
+// determines which changes to the type are compatible.
+type Role string
+
+const (
+	// NoRole is the zero Role. Types without a role
+	// take on the role of any type that refers to them.
+	NoRole Role = ""
+
+	// RoleRequest is used for types that are sent
+	// by clients and decoded by the server.
+	RoleRequest Role = "request"
+
+	// RoleResponse is used for types that are sent
+	// by the server and decoded by clients.
+	RoleResponse Role = "response"
+
+	// RoleEvent is used for types that are published
+	// by the server and decoded by subscribers.
+	RoleEvent Role = "event"
+
+	// RoleStored is used for types that are persisted,
+	// and so must be readable by both older and
+	// newer versions of the code.
+	RoleStored Role = "stored"
+)
+
+// ParseRole parses a role name as used in configuration files.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case NoRole, RoleRequest, RoleResponse, RoleEvent, RoleStored:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// SetRole sets the role of the named type in info.
+func (info *Info) SetRole(name TypeName, role Role) error {
+	t := info.Types[name]
+	if t == nil {
+		return fmt.Errorf("type %s not found", name)
+	}
+	t.Role = role
+	return nil
+}
+
+// InferRoles sets the role of each type in info that does not
+// already have a role, using the role returned by f. If f is nil,
+// RoleFromName is used.
+func (info *Info) InferRoles(f func(t *Type) Role) {
+	if f == nil {
+		f = func(t *Type) Role {
+			return RoleFromName(t.Name)
+		}
+	}
+	for _, t := range info.Types {
+		if t.Role == NoRole {
+			t.Role = f(t)
+		}
+	}
+}
+
+var roleSuffixes = []struct {
+	suffix string
+	role   Role
+}{
+	{"Request", RoleRequest},
+	{"Req", RoleRequest},
+	{"Params", RoleRequest},
+	{"Args", RoleRequest},
+	{"Input", RoleRequest},
+	{"Response", RoleResponse},
+	{"Resp", RoleResponse},
+	{"Results", RoleResponse},
+	{"Result", RoleResponse},
+	{"Reply", RoleResponse},
+	{"Output", RoleResponse},
+	{"Event", RoleEvent},
+	{"Record", RoleStored},
+	{"Doc", RoleStored},
+}
+
+// RoleFromName infers a role from the naming conventions commonly
+// used for types, such as a "Request" or "Params" suffix for
+// request types, "Response" or "Result" for response types, "Event"
+// for event types and "Record" or "Doc" for stored types.
+// It returns NoRole if the name does not follow any of
+// the conventions.
+func RoleFromName(name TypeName) Role {
+	n := string(name.Origin().Name())
+	for _, s := range roleSuffixes {
+		if strings.HasSuffix(n, s.suffix) && len(n) > len(s.suffix) {
+			return s.role
+		}
+	}
+	return NoRole
+}
@@ -0,0 +1,186 @@
+package jsontypes
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Route describes an HTTP endpoint.
+type Route struct {
+	// Method holds the HTTP method, for example "GET".
+	Method string
+
+	// Path holds the path template of the endpoint. Path
+	// parameters may be written as "{name}" (as used by
+	// net/http, chi and gorilla/mux, optionally followed by
+	// a colon and a pattern) or ":name" (as used by gin, echo
+	// and httprouter); a trailing "*" or "{name...}"
+	// matches the rest of the path.
+	Path string
+
+	// Request and Response hold the types of the request
+	// and response bodies, or nil if there is none.
+	Request  *Type `json:",omitempty"`
+	Response *Type `json:",omitempty"`
+
+	// Query holds the types of any query parameters,
+	// indexed by parameter name.
+	Query map[string]*Type `json:",omitempty"`
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+// String returns the method and path of the route,
+// for example "GET /users/{id}".
+func (r *Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Key returns a key that identifies the route independently of
+// the names and syntax of its path parameters, so that, for
+// example, "GET /users/{id}" and "GET /users/:userID"
+// have the same key.
+func (r *Route) Key() string {
+	segs, _ := r.segments()
+	return r.Method + " " + strings.Join(segs, "/")
+}
+
+// PathParams returns the names of the path parameters of r,
+// in order, with any patterns attached, for example
+// "id" or "id:[0-9]+".
+func (r *Route) PathParams() []string {
+	_, params := r.segments()
+	return params
+}
+
+// segments returns the segments of the route's path with
+// path parameters replaced by "{}" (or "{...}" for
+// parameters that match the rest of the path), and the
+// parameters themselves.
+func (r *Route) segments() (segs, params []string) {
+	segs = strings.Split(r.Path, "/")
+	for i, seg := range segs {
+		switch {
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			param := seg[1 : len(seg)-1]
+			if strings.HasSuffix(param, "...") {
+				segs[i] = "{...}"
+				param = strings.TrimSuffix(param, "...")
+			} else {
+				segs[i] = "{}"
+			}
+			if param == "$" {
+				// The net/http end-of-path marker.
+				segs[i] = seg
+				continue
+			}
+			params = append(params, param)
+		case strings.HasPrefix(seg, ":"):
+			segs[i] = "{}"
+			params = append(params, seg[1:])
+		case strings.HasPrefix(seg, "*"):
+			segs[i] = "{...}"
+			params = append(params, seg[1:])
+		}
+	}
+	return segs, params
+}
+
+// Route returns the route in info with the same key
+// (see Route.Key) as the given method and path,
+// or nil if there is none.
+func (info *Info) Route(method, path string) *Route {
+	key := (&Route{Method: method, Path: path}).Key()
+	for _, r := range info.Routes {
+		if r.Key() == key {
+			return r
+		}
+	}
+	return nil
+}
+
+// AddRoute adds r to info, replacing any existing route
+// with the same key, and returns info.
+func (info *Info) AddRoute(r *Route) *Info {
+	for i, r1 := range info.Routes {
+		if r1.Key() == r.Key() {
+			info.Routes[i] = r
+			return info
+		}
+	}
+	info.Routes = append(info.Routes, r)
+	sort.Slice(info.Routes, func(i, j int) bool {
+		r0, r1 := info.Routes[i], info.Routes[j]
+		if r0.Path != r1.Path {
+			return r0.Path < r1.Path
+		}
+		return r0.Method < r1.Method
+	})
+	return info
+}
+
+// RouteInfo adds a route to info and returns it. The pattern
+// holds the method and path separated by a space, as
+// used by net/http.ServeMux, for example "GET /users/{id}".
+// The request and response hold values of the request and
+// response body types, or nil if there is none. The query
+// holds a struct value whose fields are the query parameters,
+// named by their "query", "form" or "schema" tags if present,
+// or nil if there are none. The types of all of them are
+// added to info too.
+//
+// For example, to add all the routes from a chi router:
+//
+//	chi.Walk(router, func(method, route string, h http.Handler, _ ...func(http.Handler) http.Handler) error {
+//		req, resp := bodyTypes(h)
+//		info.RouteInfo(method+" "+route, req, resp, nil)
+//		return nil
+//	})
+func (info *Info) RouteInfo(pattern string, request, response, query interface{}) *Route {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		panic(fmt.Errorf("route pattern %q has no method", pattern))
+	}
+	r := &Route{
+		Method: method,
+		Path:   strings.TrimSpace(path),
+	}
+	if request != nil {
+		r.Request = info.Ref(reflect.TypeOf(request))
+	}
+	if response != nil {
+		r.Response = info.Ref(reflect.TypeOf(response))
+	}
+	if query != nil {
+		t := reflect.TypeOf(query)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			panic(fmt.Errorf("query parameters of %s have non-struct type %v", pattern, t))
+		}
+		r.Query = make(map[string]*Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := f.Name
+			for _, key := range []string{"query", "form", "schema"} {
+				if tag, ok := f.Tag.Lookup(key); ok {
+					name, _, _ = strings.Cut(tag, ",")
+					break
+				}
+			}
+			if name == "-" {
+				continue
+			}
+			r.Query[name] = info.Ref(f.Type)
+		}
+	}
+	info.AddRoute(r)
+	return r
+}
@@ -0,0 +1,77 @@
+package jsontypes
+
+import "sort"
+
+// Service describes a gRPC service.
+type Service struct {
+	// Name holds the fully qualified name of the
+	// service, for example "helloworld.Greeter".
+	Name string
+
+	// Methods holds the methods of the service,
+	// indexed by method name.
+	Methods map[string]*ServiceMethod `json:",omitempty"`
+
+	Doc string `json:",omitempty"`
+}
+
+// ServiceMethod describes a method on a gRPC service.
+type ServiceMethod struct {
+	Name string
+
+	// Request and Response hold the request
+	// and response message types.
+	Request  *Type
+	Response *Type
+
+	// ClientStreaming and ServerStreaming hold whether
+	// the client sends a stream of requests and whether
+	// the server sends a stream of responses.
+	ClientStreaming bool `json:",omitempty"`
+	ServerStreaming bool `json:",omitempty"`
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+// StreamingKind returns a description of the streaming
+// mode of m: one of "unary", "client streaming",
+// "server streaming" or "bidirectional streaming".
+func (m *ServiceMethod) StreamingKind() string {
+	switch {
+	case m.ClientStreaming && m.ServerStreaming:
+		return "bidirectional streaming"
+	case m.ClientStreaming:
+		return "client streaming"
+	case m.ServerStreaming:
+		return "server streaming"
+	}
+	return "unary"
+}
+
+// Service returns the service with the given name,
+// or nil if there is none.
+func (info *Info) Service(name string) *Service {
+	for _, s := range info.Services {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddService adds s to info, replacing any existing
+// service with the same name, and returns info.
+func (info *Info) AddService(s *Service) *Info {
+	for i, s1 := range info.Services {
+		if s1.Name == s.Name {
+			info.Services[i] = s
+			return info
+		}
+	}
+	info.Services = append(info.Services, s)
+	sort.Slice(info.Services, func(i, j int) bool {
+		return info.Services[i].Name < info.Services[j].Name
+	})
+	return info
+}
@@ -0,0 +1,53 @@
+package jsontypes
+
+import (
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// SinceFromDoc returns the version in which an item was introduced,
+// as indicated by a line of the form "Since: v1.3" in its doc comment,
+// or the empty string if there is none. The "v" prefix may be
+// omitted; the returned version always has it.
+func SinceFromDoc(doc string) string {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		rest := strings.TrimPrefix(line, "Since:")
+		if rest == line {
+			continue
+		}
+		v := strings.TrimSpace(rest)
+		if !strings.HasPrefix(v, "v") {
+			v = "v" + v
+		}
+		if semver.IsValid(v) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Since returns the version in which t was introduced,
+// as recorded in its doc comment (see SinceFromDoc).
+func (t *Type) Since() string {
+	return SinceFromDoc(t.Doc)
+}
+
+// Since returns the version in which f was introduced,
+// as recorded in its doc comment (see SinceFromDoc).
+func (f *Field) Since() string {
+	return SinceFromDoc(f.Doc)
+}
+
+// Since returns the version in which m was introduced,
+// as recorded in its doc comment (see SinceFromDoc).
+func (m *Method) Since() string {
+	return SinceFromDoc(m.Doc)
+}
+
+// Since returns the version in which f was introduced,
+// as recorded in its doc comment (see SinceFromDoc).
+func (f *Function) Since() string {
+	return SinceFromDoc(f.Doc)
+}
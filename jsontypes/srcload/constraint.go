@@ -0,0 +1,76 @@
+package srcload
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// fileConstraint returns the effective build constraint of the
+// given file, combining any //go:build line with the constraints
+// implied by the file name (for example "_windows.go").
+// It returns the empty string if the file is unconstrained.
+func fileConstraint(filename string, f *ast.File) string {
+	var terms []string
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(c.Text); err == nil {
+				terms = append(terms, expr.String())
+			}
+		}
+	}
+	terms = append(terms, nameConstraints(filename)...)
+	for i, term := range terms {
+		if len(terms) > 1 && strings.Contains(term, "||") {
+			terms[i] = "(" + term + ")"
+		}
+	}
+	return strings.Join(terms, " && ")
+}
+
+// nameConstraints returns the GOOS and GOARCH constraints implied
+// by the given file name, following the rules of the go command.
+func nameConstraints(filename string) []string {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	if i := strings.Index(name, "_"); i >= 0 {
+		name = name[i:]
+	} else {
+		return nil
+	}
+	parts := strings.Split(name, "_")
+	parts = parts[1:]
+	if n := len(parts); n > 0 && parts[n-1] == "test" {
+		parts = parts[:n-1]
+	}
+	n := len(parts)
+	switch {
+	case n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]]:
+		return []string{parts[n-2], parts[n-1]}
+	case n >= 1 && knownOS[parts[n-1]]:
+		return []string{parts[n-1]}
+	case n >= 1 && knownArch[parts[n-1]]:
+		return []string{parts[n-1]}
+	}
+	return nil
+}
+
+// knownOS and knownArch hold the operating systems and
+// architectures recognized in file names by the go command.
+var knownOS = setOf("aix android darwin dragonfly freebsd hurd illumos ios js linux nacl netbsd openbsd plan9 solaris wasip1 windows zos")
+
+var knownArch = setOf("386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64 mips64le mips64p32 mips64p32le ppc ppc64 ppc64le riscv riscv64 s390 s390x sparc sparc64 wasm")
+
+func setOf(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, f := range strings.Fields(s) {
+		m[f] = true
+	}
+	return m
+}
@@ -0,0 +1,881 @@
+// Package srcload builds jsontypes.Info values from Go source code,
+// so that the API of a package can be recorded without
+// running a program that imports it.
+package srcload
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Config holds configuration for Load.
+type Config struct {
+	// Dir holds the directory in which to run the build
+	// tool. If it's empty, the current directory is used.
+	Dir string
+
+	// Platforms holds the build configurations to load the
+	// packages under. If there are several, the results are
+	// merged with jsontypes.MergePlatforms, so that declarations
+	// that exist only on some platforms are marked as such.
+	// If it's empty, the packages are loaded for the host
+	// platform only.
+	Platforms []Platform
+
+	// SerializableOnly holds whether struct fields that cannot
+	// be serialized are recorded as unsupported.
+	// See jsontypes.Info.SetSerializableOnly.
+	SerializableOnly bool
+
+	// Positions holds whether the source positions of named
+	// types, fields and methods are recorded (see
+	// jsontypes.Type.Pos). File names are relative to the root
+	// of the module containing them, with forward slashes.
+	// Positions are not recorded by default because they
+	// change whenever code is moved, which makes snapshots
+	// kept under version control noisy.
+	Positions bool
+}
+
+// Platform describes a build configuration.
+type Platform struct {
+	GOOS   string
+	GOARCH string
+
+	// Tags holds any additional build tags.
+	Tags []string
+}
+
+// ParsePlatform parses a platform in the form
+// "goos/goarch", optionally followed by a comma-separated
+// list of build tags, for example "linux/amd64,netgo".
+// The form returned by Platform.String is also accepted.
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, ",")
+	goos, goarch, ok := strings.Cut(parts[0], "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: want goos/goarch", s)
+	}
+	p := Platform{
+		GOOS:   goos,
+		GOARCH: goarch,
+	}
+	for _, tag := range parts[1:] {
+		if tag != "" {
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+	return p, nil
+}
+
+// String returns the platform in the form accepted
+// by ParsePlatform.
+func (p Platform) String() string {
+	s := p.GOOS + "/" + p.GOARCH
+	if len(p.Tags) > 0 {
+		s += "," + strings.Join(p.Tags, ",")
+	}
+	return s
+}
+
+// Load loads the packages matching the given patterns (as
+// accepted by the go command) and returns an Info holding all
+// their exported named types, along with any types they refer to,
+// and their exported functions, variables and constants.
+// Generic types are recorded with their type parameters,
+// and their instantiations are included when used.
+//
+// The types are as would be created by jsontypes.Info.TypeInfo
+// for the same types, with doc comments added.
+//
+// A type, field or method whose doc comment (or, for a field,
+// line comment) includes the directive
+//
+//	//apicompat:ignore
+//
+// is marked as Ignored, so that it is excluded from compatibility
+// checks, and one with the directive //apicompat:unstable is
+// marked as experimental (see jsontypes.StabilityExperimental).
+func Load(cfg *Config, patterns ...string) (*jsontypes.Info, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if len(cfg.Platforms) == 0 {
+		return load(cfg, nil, patterns)
+	}
+	names := make([]string, len(cfg.Platforms))
+	infos := make([]*jsontypes.Info, len(cfg.Platforms))
+	for i, p := range cfg.Platforms {
+		p := p
+		info, err := load(cfg, &p, patterns)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load for %v: %v", p, err)
+		}
+		names[i], infos[i] = p.String(), info
+	}
+	if len(infos) == 1 {
+		return infos[0], nil
+	}
+	merged := jsontypes.MergePlatforms(names, infos)
+	// The build tags differ between platforms.
+	merged.Meta.BuildTags = nil
+	return merged, nil
+}
+
+// meta returns the metadata for a snapshot of the given
+// packages loaded for the given platform. The module is only
+// recorded when all the packages are in the same one. The commit
+// and time are left unset, so that the snapshot depends only on
+// the source.
+func meta(pkgs []*packages.Package, p *Platform) *jsontypes.Meta {
+	var mod *packages.Module
+	for i, pkg := range pkgs {
+		if i > 0 && (mod == nil || pkg.Module == nil || pkg.Module.Path != mod.Path) {
+			mod = nil
+			break
+		}
+		mod = pkg.Module
+	}
+	var path, version string
+	if mod != nil {
+		path, version = mod.Path, mod.Version
+	}
+	m := jsontypes.NewMeta(path)
+	// The version, commit and build tags found by NewMeta
+	// are those of the running binary, not of the source.
+	m.Version, m.Commit, m.BuildTags = version, "", nil
+	if p != nil {
+		m.BuildTags = p.Tags
+	}
+	return m
+}
+
+// load loads the packages for the given platform,
+// or the host platform if it's nil.
+func load(cfg *Config, p *Platform, patterns []string) (*jsontypes.Info, error) {
+	pcfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+		Dir:  cfg.Dir,
+	}
+	if p != nil {
+		pcfg.Env = append(os.Environ(), "GOOS="+p.GOOS, "GOARCH="+p.GOARCH)
+		if len(p.Tags) > 0 {
+			pcfg.BuildFlags = []string{"-tags=" + strings.Join(p.Tags, ",")}
+		}
+	}
+	pkgs, err := packages.Load(pcfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	x := &extractor{
+		info:        jsontypes.NewInfo(),
+		docs:        make(map[token.Pos]string),
+		directives:  make(map[token.Pos][]string),
+		constraints: make(map[string]string),
+		positions:   cfg.Positions,
+	}
+	x.info.Meta = meta(pkgs, p)
+	x.info.SetSerializableOnly(cfg.SerializableOnly)
+	for _, pkg := range pkgs {
+		x.addPackage(pkg)
+	}
+	return x.info, nil
+}
+
+type extractor struct {
+	info *jsontypes.Info
+	// docs holds the doc comments for declarations,
+	// indexed by the position of their names.
+	docs map[token.Pos]string
+	// directives holds the names of the apicompat directives,
+	// such as "ignore", in the comments on declarations,
+	// indexed by the position of their names.
+	directives map[token.Pos][]string
+	// fset holds the file set of the loaded packages.
+	fset *token.FileSet
+	// constraints holds the build constraints of
+	// source files, indexed by file name.
+	constraints map[string]string
+	// tparams holds the type parameters of the generic
+	// type currently being added, if any.
+	tparams *types.TypeParamList
+	// positions holds whether source positions are recorded,
+	// and moduleDir holds the root directory of the module
+	// of the package being added, if known.
+	positions bool
+	moduleDir string
+}
+
+func (x *extractor) addPackage(pkg *packages.Package) {
+	x.fset = pkg.Fset
+	x.moduleDir = ""
+	if pkg.Module != nil {
+		x.moduleDir = pkg.Module.Dir
+	}
+	for _, f := range pkg.Syntax {
+		x.addDocs(f)
+		filename := pkg.Fset.Position(f.Package).Filename
+		x.constraints[filename] = fileConstraint(filename, f)
+	}
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.TypeName:
+			if !obj.IsAlias() {
+				x.typeInfo(obj.Type())
+			}
+		case *types.Func:
+			x.addFunc(obj)
+		case *types.Var:
+			x.addVar(obj)
+		case *types.Const:
+			x.addConst(obj)
+		}
+	}
+}
+
+// addFunc adds the package-level function f to the info.
+// Generic functions are omitted.
+func (x *extractor) addFunc(f *types.Func) {
+	sig := f.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 {
+		return
+	}
+	if x.info.Funcs == nil {
+		x.info.Funcs = make(map[jsontypes.TypeName]*jsontypes.Function)
+	}
+	name := jsontypes.MakeTypeName(f.Pkg().Path(), f.Name())
+	x.info.Funcs[name] = &jsontypes.Function{
+		Name: name,
+		Type: &jsontypes.Type{
+			Kind:     jsontypes.Func,
+			Variadic: sig.Variadic(),
+			In:       x.tuple(sig.Params()),
+			Out:      x.tuple(sig.Results()),
+		},
+		Doc: x.docs[f.Pos()],
+	}
+}
+
+// addVar adds the package-level variable v to the info.
+func (x *extractor) addVar(v *types.Var) {
+	if x.info.Vars == nil {
+		x.info.Vars = make(map[jsontypes.TypeName]*jsontypes.Var)
+	}
+	name := jsontypes.MakeTypeName(v.Pkg().Path(), v.Name())
+	x.info.Vars[name] = &jsontypes.Var{
+		Name: name,
+		Type: x.ref(v.Type()),
+		Doc:  x.docs[v.Pos()],
+	}
+}
+
+// addConst adds the package-level constant c to the info.
+func (x *extractor) addConst(c *types.Const) {
+	if x.info.Consts == nil {
+		x.info.Consts = make(map[jsontypes.TypeName]*jsontypes.Const)
+	}
+	name := jsontypes.MakeTypeName(c.Pkg().Path(), c.Name())
+	t := c.Type()
+	untyped := false
+	if b, ok := t.(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		t, untyped = types.Default(t), true
+	}
+	x.info.Consts[name] = &jsontypes.Const{
+		Name:    name,
+		Type:    x.ref(t),
+		Untyped: untyped,
+		Value:   c.Val().ExactString(),
+		Doc:     x.docs[c.Pos()],
+	}
+}
+
+// addDocs records the doc comments in f.
+func (x *extractor) addDocs(f *ast.File) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GenDecl:
+			if n.Tok == token.VAR || n.Tok == token.CONST {
+				for _, spec := range n.Specs {
+					spec := spec.(*ast.ValueSpec)
+					doc := spec.Doc
+					if doc == nil && !n.Lparen.IsValid() {
+						doc = n.Doc
+					}
+					for _, name := range spec.Names {
+						x.addDoc(name.Pos(), doc)
+					}
+				}
+				return false
+			}
+			if n.Tok != token.TYPE {
+				return false
+			}
+			for _, spec := range n.Specs {
+				spec := spec.(*ast.TypeSpec)
+				doc := spec.Doc
+				if doc == nil && !n.Lparen.IsValid() {
+					doc = n.Doc
+				}
+				x.addDoc(spec.Name.Pos(), doc)
+			}
+		case *ast.FuncDecl:
+			x.addDoc(n.Name.Pos(), n.Doc)
+			return false
+		case *ast.Field:
+			for _, name := range n.Names {
+				x.addDoc(name.Pos(), n.Doc)
+				x.addDirectives(name.Pos(), n.Comment)
+			}
+			if len(n.Names) == 0 {
+				// The position of an embedded field is that
+				// of its type name.
+				x.addDoc(embeddedName(n.Type).Pos(), n.Doc)
+				x.addDirectives(embeddedName(n.Type).Pos(), n.Comment)
+			}
+		}
+		return true
+	})
+}
+
+func (x *extractor) addDoc(pos token.Pos, doc *ast.CommentGroup) {
+	if doc != nil {
+		x.docs[pos] = strings.TrimSuffix(doc.Text(), "\n")
+		x.addDirectives(pos, doc)
+	}
+}
+
+// addDirectives records any apicompat directives in the
+// given comments, which are omitted from doc comment text.
+func (x *extractor) addDirectives(pos token.Pos, comments *ast.CommentGroup) {
+	if comments == nil {
+		return
+	}
+	for _, c := range comments.List {
+		if name := strings.TrimPrefix(c.Text, "//apicompat:"); name != c.Text {
+			x.directives[pos] = append(x.directives[pos], strings.TrimSpace(name))
+		}
+	}
+}
+
+// hasDirective reports whether the declaration whose name
+// is at pos has the apicompat directive with the given name.
+func (x *extractor) hasDirective(pos token.Pos, name string) bool {
+	for _, d := range x.directives[pos] {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// stability returns the stability level given by any
+// //apicompat:unstable directive on the declaration
+// whose name is at pos.
+func (x *extractor) stability(pos token.Pos) jsontypes.Stability {
+	if x.hasDirective(pos, "unstable") {
+		return jsontypes.StabilityExperimental
+	}
+	return jsontypes.StabilityUnknown
+}
+
+// pos returns the position of pos in the form recorded
+// in Type.Pos, or the empty string if positions
+// are not being recorded.
+func (x *extractor) pos(pos token.Pos) string {
+	if !x.positions || !pos.IsValid() {
+		return ""
+	}
+	p := x.fset.Position(pos)
+	filename := p.Filename
+	if x.moduleDir != "" {
+		if rel, err := filepath.Rel(x.moduleDir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(filename), p.Line)
+}
+
+// embeddedName returns the name of the type
+// in an embedded field type expression.
+func embeddedName(e ast.Expr) ast.Expr {
+	for {
+		switch e1 := e.(type) {
+		case *ast.StarExpr:
+			e = e1.X
+		case *ast.SelectorExpr:
+			return e1.Sel
+		case *ast.IndexExpr:
+			e = e1.X
+		case *ast.IndexListExpr:
+			e = e1.X
+		default:
+			return e
+		}
+	}
+}
+
+// constraint returns the build constraint of the
+// file containing the given position, if known.
+func (x *extractor) constraint(pos token.Pos) string {
+	if x.fset == nil || !pos.IsValid() {
+		return ""
+	}
+	return x.constraints[x.fset.Position(pos).Filename]
+}
+
+// ref is the same as typeInfo except that it
+// returns a type reference for named types.
+func (x *extractor) ref(t types.Type) *jsontypes.Type {
+	jt := x.typeInfo(t)
+	if jt.Name.PkgPath() != "" {
+		return &jsontypes.Type{
+			Name: jt.Name,
+		}
+	}
+	return jt
+}
+
+// typeInfo returns the jsontypes representation of t, adding
+// it to the info if it's a named type declared in a package.
+func (x *extractor) typeInfo(t types.Type) *jsontypes.Type {
+	t = types.Unalias(t)
+	var name jsontypes.TypeName
+	var pkg *types.Package
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return &jsontypes.Type{
+			Name: jsontypes.TypeName(x.typeParamName(t)),
+			Kind: jsontypes.TypeParameter,
+		}
+	case *types.Named:
+		pkg = t.Obj().Pkg()
+		name = x.typeName(t)
+		if hasTypeParam(t) {
+			// An instantiation within the definition of a
+			// generic type is recorded as a reference only,
+			// because its type arguments are meaningful only
+			// within that definition.
+			return &jsontypes.Type{
+				Name: name,
+			}
+		}
+	case *types.Basic:
+		// Use the canonical name, so that byte
+		// and rune are named uint8 and int32,
+		// as they are by reflect.
+		name = jsontypes.TypeName(types.Typ[t.Kind()].Name())
+	}
+	if pkg != nil {
+		if jt := x.info.Types[name]; jt != nil {
+			return jt
+		}
+	}
+	jt := &jsontypes.Type{
+		Name: name,
+		Kind: kindOf(t),
+	}
+	if pkg != nil {
+		// Add the type to the info first to prevent infinite recursion.
+		x.info.Types[name] = jt
+		if named, ok := t.(*types.Named); ok {
+			jt.Doc = x.docs[named.Obj().Pos()]
+			jt.Stability = x.stability(named.Obj().Pos())
+			jt.Ignored = x.hasDirective(named.Obj().Pos(), "ignore")
+			jt.Constraint = x.constraint(named.Obj().Pos())
+			jt.Pos = x.pos(named.Obj().Pos())
+			if tparams := named.TypeParams(); tparams.Len() > 0 && named.TypeArgs().Len() == 0 {
+				defer func(old *types.TypeParamList) {
+					x.tparams = old
+				}(x.tparams)
+				x.tparams = tparams
+				for i := 0; i < tparams.Len(); i++ {
+					tp := tparams.At(i)
+					jt.TypeParams = append(jt.TypeParams, &jsontypes.TypeParam{
+						Name:       tp.Obj().Name(),
+						Constraint: x.ref(tp.Constraint()),
+					})
+				}
+			}
+		}
+	}
+	x.addMethods(jt, t)
+	if pkg != nil {
+		jt.Implements = jsontypes.ImplementedInterfaces(jt)
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		jt.Elem = x.ref(u.Elem())
+		jt.Len = int(u.Len())
+	case *types.Slice:
+		jt.Elem = x.ref(u.Elem())
+	case *types.Chan:
+		jt.Elem = x.ref(u.Elem())
+	case *types.Pointer:
+		jt.Elem = x.ref(u.Elem())
+	case *types.Map:
+		jt.Key, jt.Elem = x.ref(u.Key()), x.ref(u.Elem())
+	case *types.Struct:
+		x.addFields(jt, u)
+	case *types.Signature:
+		jt.Variadic = u.Variadic()
+		jt.In = x.tuple(u.Params())
+		jt.Out = x.tuple(u.Results())
+	case *types.Interface:
+		terms, _ := typeSetTerms(u)
+		for _, term := range terms {
+			jt.Terms = append(jt.Terms, &jsontypes.Term{
+				Tilde: term.Tilde(),
+				Type:  x.ref(term.Type()),
+			})
+		}
+	}
+	return jt
+}
+
+// typeSetTerms returns the terms of the union that restricts the
+// type set of iface, taking into account all the unions and
+// interfaces embedded in it, and reports whether the type set
+// is restricted at all.
+func typeSetTerms(iface *types.Interface) (terms []*types.Term, restricted bool) {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var ts []*types.Term
+		switch e := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			var ok bool
+			ts, ok = unionTerms(e)
+			if !ok {
+				continue
+			}
+		default:
+			if eiface, ok := e.Underlying().(*types.Interface); ok {
+				var r bool
+				ts, r = typeSetTerms(eiface)
+				if !r {
+					continue
+				}
+			} else {
+				ts = []*types.Term{types.NewTerm(false, e)}
+			}
+		}
+		if !restricted {
+			terms, restricted = ts, true
+		} else {
+			terms = intersectTerms(terms, ts)
+		}
+	}
+	return terms, restricted
+}
+
+// unionTerms returns the terms of u, flattening any interfaces
+// in it. It reports false if u does not restrict the
+// type set because one of its terms is unrestricted.
+func unionTerms(u *types.Union) ([]*types.Term, bool) {
+	var terms []*types.Term
+	for i := 0; i < u.Len(); i++ {
+		term := u.Term(i)
+		iface, ok := term.Type().Underlying().(*types.Interface)
+		if !ok || term.Tilde() {
+			terms = append(terms, term)
+			continue
+		}
+		ts, restricted := typeSetTerms(iface)
+		if !restricted {
+			return nil, false
+		}
+		terms = append(terms, ts...)
+	}
+	return terms, true
+}
+
+// intersectTerms returns the terms of the intersection
+// of the type sets with terms a and b.
+func intersectTerms(a, b []*types.Term) []*types.Term {
+	var terms []*types.Term
+	for _, x := range a {
+		for _, y := range b {
+			if z := intersectTerm(x, y); z != nil && !containsTerm(terms, z) {
+				terms = append(terms, z)
+			}
+		}
+	}
+	return terms
+}
+
+// intersectTerm returns the intersection of x and y,
+// or nil if it is empty.
+func intersectTerm(x, y *types.Term) *types.Term {
+	switch {
+	case x.Tilde() && y.Tilde():
+		if types.Identical(x.Type(), y.Type()) {
+			return x
+		}
+	case x.Tilde():
+		if types.Identical(x.Type(), y.Type().Underlying()) {
+			return y
+		}
+	case y.Tilde():
+		if types.Identical(x.Type().Underlying(), y.Type()) {
+			return x
+		}
+	default:
+		if types.Identical(x.Type(), y.Type()) {
+			return x
+		}
+	}
+	return nil
+}
+
+func containsTerm(terms []*types.Term, t *types.Term) bool {
+	for _, term := range terms {
+		if term.Tilde() == t.Tilde() && types.Identical(term.Type(), t.Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *extractor) tuple(t *types.Tuple) []*jsontypes.Type {
+	ts := make([]*jsontypes.Type, t.Len())
+	for i := range ts {
+		ts[i] = x.ref(t.At(i).Type())
+	}
+	return ts
+}
+
+func (x *extractor) addMethods(jt *jsontypes.Type, t types.Type) {
+	if _, ok := t.Underlying().(*types.Pointer); ok {
+		return
+	}
+	if iface, ok := t.Underlying().(*types.Interface); ok {
+		for i := 0; i < iface.NumMethods(); i++ {
+			m := iface.Method(i)
+			if !m.Exported() {
+				jt.Sealed = true
+			}
+			x.addMethod(jt, m, false)
+		}
+		return
+	}
+	vset := types.NewMethodSet(t)
+	pset := types.NewMethodSet(types.NewPointer(t))
+	for i := 0; i < pset.Len(); i++ {
+		f := pset.At(i).Obj().(*types.Func)
+		x.addMethod(jt, f, vset.Lookup(f.Pkg(), f.Name()) == nil)
+	}
+}
+
+func (x *extractor) addMethod(jt *jsontypes.Type, f *types.Func, ptrReceiver bool) {
+	if !f.Exported() {
+		return
+	}
+	sig := f.Type().(*types.Signature)
+	m := &jsontypes.Method{
+		Name:        f.Name(),
+		PtrReceiver: ptrReceiver,
+		Type: &jsontypes.Type{
+			Kind:     jsontypes.Func,
+			Variadic: sig.Variadic(),
+			In:       x.tuple(sig.Params()),
+			Out:      x.tuple(sig.Results()),
+		},
+		Doc:        x.docs[f.Pos()],
+		Stability:  x.stability(f.Pos()),
+		Ignored:    x.hasDirective(f.Pos(), "ignore"),
+		Constraint: x.constraint(f.Pos()),
+		Pos:        x.pos(f.Pos()),
+	}
+	if jt.Methods == nil {
+		jt.Methods = make(map[string]*jsontypes.Method)
+	}
+	jt.Methods[m.Name] = m
+}
+
+func (x *extractor) addFields(jt *jsontypes.Type, t *types.Struct) {
+	for i := 0; i < t.NumFields(); i++ {
+		f := t.Field(i)
+		if !f.Exported() && !f.Embedded() {
+			continue
+		}
+		ft := f.Type()
+		if p, ok := ft.Underlying().(*types.Pointer); ok {
+			ft = p.Elem()
+		}
+		_, isStruct := ft.Underlying().(*types.Struct)
+		jt.Fields = append(jt.Fields, &jsontypes.Field{
+			Name:        f.Name(),
+			Type:        x.fieldRef(jt, f),
+			Anonymous:   f.Embedded(),
+			Tag:         t.Tag(i),
+			Doc:         x.docs[f.Pos()],
+			Stability:   x.stability(f.Pos()),
+			Ignored:     x.hasDirective(f.Pos(), "ignore"),
+			Default:     reflect.StructTag(t.Tag(i)).Get("default"),
+			EncodedName: jsontypes.EncodedName(f.Name(), t.Tag(i), "json", f.Embedded() && isStruct),
+			Index:       i,
+			Pos:         x.pos(f.Pos()),
+		})
+	}
+}
+
+// fieldRef returns the type to record for the field f of jt.
+func (x *extractor) fieldRef(jt *jsontypes.Type, f *types.Var) *jsontypes.Type {
+	if x.info.SerializableOnly() {
+		if kind := kindOf(f.Type()); !jsontypes.IsSerializable(kind) {
+			return x.info.Unsupported(jt, f.Name(), kind)
+		}
+	}
+	return x.ref(f.Type())
+}
+
+// kindOf returns the kind of t.
+func kindOf(t types.Type) jsontypes.Kind {
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return jsontypes.TypeParameter
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() == types.UnsafePointer {
+			return jsontypes.UnsafePointer
+		}
+		return jsontypes.Kind(types.Typ[u.Kind()].Name())
+	case *types.Array:
+		return jsontypes.Array
+	case *types.Slice:
+		return jsontypes.Slice
+	case *types.Chan:
+		return jsontypes.Chan
+	case *types.Pointer:
+		return jsontypes.Ptr
+	case *types.Map:
+		return jsontypes.Map
+	case *types.Struct:
+		return jsontypes.Struct
+	case *types.Signature:
+		return jsontypes.Func
+	case *types.Interface:
+		return jsontypes.Interface
+	}
+	return jsontypes.Unknown
+}
+
+// typeParamName returns the name of the type parameter t.
+// Within the generic type being added, the name declared by
+// the type is used even when a method's receiver declares it
+// with a different name.
+func (x *extractor) typeParamName(t *types.TypeParam) string {
+	if x.tparams != nil && t.Index() < x.tparams.Len() {
+		return x.tparams.At(t.Index()).Obj().Name()
+	}
+	return t.Obj().Name()
+}
+
+// hasTypeParam reports whether t refers to any type
+// parameters.
+func hasTypeParam(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if hasTypeParam(args.At(i)) {
+				return true
+			}
+		}
+	case *types.Pointer:
+		return hasTypeParam(t.Elem())
+	case *types.Slice:
+		return hasTypeParam(t.Elem())
+	case *types.Array:
+		return hasTypeParam(t.Elem())
+	case *types.Chan:
+		return hasTypeParam(t.Elem())
+	case *types.Map:
+		return hasTypeParam(t.Key()) || hasTypeParam(t.Elem())
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if hasTypeParam(tuple.At(i).Type()) {
+					return true
+				}
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasTypeParam(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeName returns the name of the given named type,
+// including any type arguments.
+func (x *extractor) typeName(t *types.Named) jsontypes.TypeName {
+	obj := t.Obj()
+	var pkgPath string
+	if obj.Pkg() != nil {
+		pkgPath = obj.Pkg().Path()
+	}
+	args := t.TypeArgs()
+	typeArgs := make([]string, args.Len())
+	for i := range typeArgs {
+		typeArgs[i] = x.typeExpr(args.At(i))
+	}
+	return jsontypes.MakeTypeName(pkgPath, obj.Name(), typeArgs...)
+}
+
+// typeExpr returns t as a type expression in the
+// syntax accepted by jsontypes.Parse.
+func (x *extractor) typeExpr(t types.Type) string {
+	switch t := types.Unalias(t).(type) {
+	case *types.TypeParam:
+		return x.typeParamName(t)
+	case *types.Named:
+		return string(x.typeName(t))
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return "unsafe.Pointer"
+		}
+		return types.Typ[t.Kind()].Name()
+	case *types.Pointer:
+		return "*" + x.typeExpr(t.Elem())
+	case *types.Slice:
+		return "[]" + x.typeExpr(t.Elem())
+	case *types.Array:
+		return "[" + strconv.FormatInt(t.Len(), 10) + "]" + x.typeExpr(t.Elem())
+	case *types.Map:
+		return "map[" + x.typeExpr(t.Key()) + "]" + x.typeExpr(t.Elem())
+	case *types.Chan:
+		return "chan " + x.typeExpr(t.Elem())
+	}
+	return types.TypeString(t, func(p *types.Package) string {
+		return p.Path()
+	})
+}
@@ -0,0 +1,96 @@
+package srcload_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/jsontypes/srcload"
+)
+
+const testModule = `
+-- go.mod --
+module example.com/m
+
+go 1.21
+-- m.go --
+package m
+
+// T is a test type.
+type T struct {
+	A int    ` + "`json:\"a\"`" + `
+	B []*U
+	//apicompat:ignore
+	C string
+}
+
+type U struct {
+	M map[string]T
+}
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, testModule)
+	info, err := srcload.Load(&srcload.Config{Dir: dir}, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name jsontypes.TypeName
+		decl string
+		doc  string
+	}{{
+		name: "example.com/m#T",
+		decl: "type m.T struct{A int `json:\"a\"`; B []*m.U; C string}",
+		doc:  "T is a test type.",
+	}, {
+		name: "example.com/m#U",
+		decl: "type m.U struct{M map[string]m.T}",
+	}}
+	for _, test := range tests {
+		jt := info.Types[test.name]
+		if jt == nil {
+			t.Errorf("%s: not found", test.name)
+			continue
+		}
+		if got := jsontypes.FormatDecl(info, jt); got != test.decl {
+			t.Errorf("%s: got decl\n%s\nwant\n%s", test.name, got, test.decl)
+		}
+		if jt.Doc != test.doc {
+			t.Errorf("%s: got doc %q want %q", test.name, jt.Doc, test.doc)
+		}
+	}
+	if m := info.Meta; m == nil || m.Module != "example.com/m" || m.GoVersion == "" || !m.Time.IsZero() {
+		t.Errorf("unexpected metadata %#v", m)
+	}
+	if f := info.Types["example.com/m#T"].Fields[2]; !f.Ignored {
+		t.Errorf("field %s is not ignored", f.Name)
+	}
+}
+
+// writeFiles writes the files in the given txtar-like
+// archive to dir.
+func writeFiles(t *testing.T, dir, archive string) {
+	var name string
+	var data []byte
+	flush := func() {
+		if name == "" {
+			return
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, line := range strings.SplitAfter(archive, "\n") {
+		if strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --\n") {
+			flush()
+			name, data = strings.TrimSuffix(strings.TrimPrefix(line, "-- "), " --\n"), nil
+			continue
+		}
+		data = append(data, line...)
+	}
+	flush()
+}
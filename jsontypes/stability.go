@@ -0,0 +1,133 @@
+package jsontypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stability describes how stable an API item is, and
+// so how strictly changes to it should be checked.
+type Stability string
+
+const (
+	// StabilityUnknown is the zero Stability.
+	// Items without a known stability take on the
+	// stability of their enclosing type, and are
+	// otherwise treated as stable.
+	StabilityUnknown Stability = ""
+
+	// StabilityExperimental is used for items that
+	// may change or be removed at any time.
+	StabilityExperimental Stability = "experimental"
+
+	// StabilityBeta is used for items that
+	// may be removed but should not otherwise
+	// change incompatibly.
+	StabilityBeta Stability = "beta"
+
+	// StabilityStable is used for items that
+	// are subject to all compatibility checks.
+	StabilityStable Stability = "stable"
+)
+
+// ParseStability parses a stability level name
+// as used in configuration files and doc comments.
+func ParseStability(s string) (Stability, error) {
+	switch st := Stability(strings.ToLower(s)); st {
+	case StabilityUnknown, StabilityExperimental, StabilityBeta, StabilityStable:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown stability level %q", s)
+}
+
+var stabilityMarkers = []struct {
+	marker    string
+	stability Stability
+}{
+	{"Experimental:", StabilityExperimental},
+	{"Beta:", StabilityBeta},
+	{"Stable:", StabilityStable},
+}
+
+// StabilityFromDoc returns the stability level indicated by
+// a marker in the given doc comment, or StabilityUnknown if
+// there is none. A marker is a line starting with
+// "Experimental:", "Beta:" or "Stable:", or of the form
+// "Stability: level".
+func StabilityFromDoc(doc string) Stability {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if rest := strings.TrimPrefix(line, "Stability:"); rest != line {
+			if st, err := ParseStability(strings.TrimSpace(rest)); err == nil {
+				return st
+			}
+			continue
+		}
+		for _, m := range stabilityMarkers {
+			if strings.HasPrefix(line, m.marker) {
+				return m.stability
+			}
+		}
+	}
+	return StabilityUnknown
+}
+
+// StabilityOf returns the stability of t, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (t *Type) StabilityOf() Stability {
+	return stabilityOf(t.Stability, t.Doc)
+}
+
+// StabilityOf returns the stability of f, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (f *Field) StabilityOf() Stability {
+	return stabilityOf(f.Stability, f.Doc)
+}
+
+// StabilityOf returns the stability of m, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (m *Method) StabilityOf() Stability {
+	return stabilityOf(m.Stability, m.Doc)
+}
+
+func stabilityOf(st Stability, doc string) Stability {
+	if st != StabilityUnknown {
+		return st
+	}
+	return StabilityFromDoc(doc)
+}
+
+// SetStability sets the stability level of the named
+// type in info or, if field is non-empty, of the named
+// field or method within the type.
+func (info *Info) SetStability(name TypeName, field string, st Stability) error {
+	t := info.Types[name]
+	if t == nil {
+		return fmt.Errorf("type %s not found", name)
+	}
+	if field == "" {
+		t.Stability = st
+		return nil
+	}
+	if f := t.FieldByName(field); f != nil {
+		f.Stability = st
+		return nil
+	}
+	if m := t.Methods[field]; m != nil {
+		m.Stability = st
+		return nil
+	}
+	return fmt.Errorf("no field or method %s in type %s", field, name)
+}
+
+// IsDeprecated reports whether the given doc comment marks
+// its item as deprecated, using the Go convention of a
+// paragraph starting with "Deprecated: ".
+func IsDeprecated(doc string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package jsontypes
+
+// Remove removes the named types from info and returns info.
+// Any references to them from other types are left unchanged.
+// Names that are not in info are ignored.
+func (info *Info) Remove(names ...TypeName) *Info {
+	for _, name := range names {
+		delete(info.Types, name)
+	}
+	return info
+}
+
+// Subtract returns a new Info holding the named types in a
+// whose names are not in b, for example to find the types that
+// have been added in a newer snapshot of an API. The types
+// themselves are shared with a, and the result has a's
+// metadata. Functions, facades, services and routes
+// are not included.
+func Subtract(a, b *Info) *Info {
+	diff := NewInfo()
+	diff.Meta = a.Meta
+	diff.serializableOnly = a.serializableOnly
+	for name, t := range a.Types {
+		if b.Types[name] == nil {
+			diff.Types[name] = t
+		}
+	}
+	return diff
+}
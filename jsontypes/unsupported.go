@@ -0,0 +1,65 @@
+package jsontypes
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetSerializableOnly sets whether struct fields of channel, function
+// or unsafe.Pointer type, which cannot be serialized, are recorded
+// by TypeInfo and Ref with a type of kind Unsupported rather than
+// their actual type. When such a field is elided, a warning
+// is added to info.Warnings.
+//
+// This is useful when only the serializable structure of
+// the types matters, as the types of such fields often
+// refer to large numbers of unrelated types.
+func (info *Info) SetSerializableOnly(serializableOnly bool) {
+	info.serializableOnly = serializableOnly
+}
+
+// SerializableOnly reports whether non-serializable struct
+// fields are being elided. See SetSerializableOnly.
+func (info *Info) SerializableOnly() bool {
+	return info.serializableOnly
+}
+
+// IsSerializable reports whether values of the given kind can
+// be serialized. It returns false for channels, functions and
+// unsafe pointers.
+func IsSerializable(kind Kind) bool {
+	switch kind {
+	case Chan, Func, UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// Unsupported returns the type used in place of the type of
+// the field with the given name in t, and adds a warning to
+// info. The type has the given kind.
+func (info *Info) Unsupported(t *Type, field string, kind Kind) *Type {
+	where := field
+	if t.Name != "" {
+		where = t.Name.Name() + "." + field
+	}
+	info.Warnings = append(info.Warnings, fmt.Sprintf("%s: %s field recorded as unsupported", where, kind))
+	return &Type{
+		Kind: Unsupported,
+	}
+}
+
+// fieldRef returns the type to record for the field f of t.
+func (info *Info) fieldRef(jt *Type, f reflect.StructField) *Type {
+	if info.serializableOnly {
+		switch f.Type.Kind() {
+		case reflect.Chan:
+			return info.Unsupported(jt, f.Name, Chan)
+		case reflect.Func:
+			return info.Unsupported(jt, f.Name, Func)
+		case reflect.UnsafePointer:
+			return info.Unsupported(jt, f.Name, UnsafePointer)
+		}
+	}
+	return info.Ref(f.Type)
+}
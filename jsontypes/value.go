@@ -0,0 +1,50 @@
+package jsontypes
+
+// Var describes a package-level variable.
+type Var struct {
+	// Name holds the name of the variable, qualified
+	// by its package path in the same way as a TypeName,
+	// for example "example.com/foo#DefaultClient".
+	Name TypeName
+
+	// Type holds the variable's type.
+	Type *Type
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+// StabilityOf returns the stability of v, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (v *Var) StabilityOf() Stability {
+	return stabilityOf(v.Stability, v.Doc)
+}
+
+// Const describes a package-level constant.
+type Const struct {
+	// Name holds the name of the constant, qualified
+	// by its package path in the same way as a TypeName,
+	// for example "example.com/foo#MaxSize".
+	Name TypeName
+
+	// Type holds the constant's type. For an untyped
+	// constant, it holds the constant's default type,
+	// such as int for an untyped integer constant.
+	Type *Type
+
+	// Untyped holds whether the constant is untyped.
+	Untyped bool `json:",omitempty"`
+
+	// Value holds the exact value of the constant in Go
+	// syntax, for example 42 or "active".
+	Value string `json:",omitempty"`
+
+	Doc       string    `json:",omitempty"`
+	Stability Stability `json:",omitempty"`
+}
+
+// StabilityOf returns the stability of c, as recorded in its
+// Stability field or in a marker in its doc comment.
+func (c *Const) StabilityOf() Stability {
+	return stabilityOf(c.Stability, c.Doc)
+}
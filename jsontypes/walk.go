@@ -0,0 +1,145 @@
+package jsontypes
+
+import "sort"
+
+// Visitor is implemented by values passed to Walk.
+type Visitor interface {
+	// VisitType is called for each type. If it returns
+	// false, the contents of the type (its fields,
+	// methods and element types) are not visited.
+	VisitType(t *Type) bool
+
+	// VisitField is called for each field of a struct
+	// type t, before the field's type is visited.
+	VisitField(t *Type, f *Field)
+
+	// VisitMethod is called for each method of t,
+	// before the method's type is visited.
+	VisitMethod(t *Type, m *Method)
+}
+
+// VisitorFuncs implements Visitor by calling its
+// fields. Any nil field is treated as a no-op.
+// If Type is nil, all types are descended into.
+type VisitorFuncs struct {
+	Type   func(t *Type) bool
+	Field  func(t *Type, f *Field)
+	Method func(t *Type, m *Method)
+}
+
+func (v VisitorFuncs) VisitType(t *Type) bool {
+	if v.Type == nil {
+		return true
+	}
+	return v.Type(t)
+}
+
+func (v VisitorFuncs) VisitField(t *Type, f *Field) {
+	if v.Field != nil {
+		v.Field(t, f)
+	}
+}
+
+func (v VisitorFuncs) VisitMethod(t *Type, m *Method) {
+	if v.Method != nil {
+		v.Method(t, m)
+	}
+}
+
+// Walk traverses all the types in info in name order, calling
+// v for every type, field and method, including anonymous
+// types nested inside other types.
+//
+// Each type is visited exactly once. References to named
+// types in info are not visited themselves, as the
+// named type is visited in its own right.
+func Walk(info *Info, v Visitor) {
+	w := &walker{
+		info:    info,
+		v:       v,
+		visited: make(map[*Type]bool),
+	}
+	for _, name := range info.sortedNames() {
+		w.walk(info.Types[name])
+	}
+}
+
+// WalkType is like Walk but visits only t and the types
+// reachable from it. Named types referred to by t are
+// resolved using info, which may be nil.
+func WalkType(info *Info, t *Type, v Visitor) {
+	w := &walker{
+		info:    info,
+		v:       v,
+		visited: make(map[*Type]bool),
+		follow:  true,
+	}
+	w.walk(t)
+}
+
+type walker struct {
+	info    *Info
+	v       Visitor
+	visited map[*Type]bool
+	// follow holds whether to follow references
+	// to named types.
+	follow bool
+}
+
+func (w *walker) walk(t *Type) {
+	if t == nil {
+		return
+	}
+	if w.info != nil && t.Name != "" {
+		if dt := w.info.Types[t.Name]; dt != nil && dt != t {
+			// It's a reference to a named type.
+			if w.follow {
+				w.walk(dt)
+			}
+			return
+		}
+	}
+	if w.visited[t] {
+		return
+	}
+	w.visited[t] = true
+	if !w.v.VisitType(t) {
+		return
+	}
+	for _, f := range t.Fields {
+		w.v.VisitField(t, f)
+		w.walk(f.Type)
+	}
+	for _, name := range sortedMethodNames(t) {
+		m := t.Methods[name]
+		w.v.VisitMethod(t, m)
+		w.walk(m.Type)
+	}
+	for _, tp := range t.TypeParams {
+		w.walk(tp.Constraint)
+	}
+	for _, term := range t.Terms {
+		w.walk(term.Type)
+	}
+	w.walk(t.Key)
+	w.walk(t.Elem)
+	for _, in := range t.In {
+		w.walk(in)
+	}
+	for _, out := range t.Out {
+		w.walk(out)
+	}
+}
+
+// sortedNames returns the names of all the types
+// in info in sorted order.
+func (info *Info) sortedNames() []TypeName {
+	names := make([]TypeName, 0, len(info.Types))
+	for name := range info.Types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
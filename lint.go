@@ -0,0 +1,164 @@
+package apicompat
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// encodingTagKeys holds the struct tag keys of the encodings
+// whose field names and options are checked for consistency
+// by Lint, in order of preference as the reference encoding.
+var encodingTagKeys = []string{"json", "yaml", "msgpack"}
+
+// TagNamePolicy returns an option that declares the expected
+// relationship between the names used for struct fields by the
+// encoding with the given tag key, such as "yaml", and those used
+// by JSON: Lint expects the name under key to be name(jsonName)
+// rather than identical to the JSON name. For example,
+//
+//	TagNamePolicy("yaml", NormalizeCase)
+//
+// declares that YAML field names are the snake_case forms
+// of the JSON names.
+func TagNamePolicy(key string, name func(jsonName string) string) CheckOption {
+	return func(o *checkOptions) {
+		if o.tagNames == nil {
+			o.tagNames = make(map[string]func(string) string)
+		}
+		o.tagNames[key] = name
+	}
+}
+
+// Lint checks the types in a single API snapshot for problems
+// that don't depend on any earlier version. Currently it checks
+// that when a struct field has tags for more than one encoding
+// (json, yaml and msgpack), they agree on the field's name (subject
+// to any TagNamePolicy options) and on whether it's omitted when
+// empty, so that the encodings of the same struct cannot drift
+// apart unnoticed.
+//
+// It returns an entry for each named type with problems,
+// sorted by name. Options such as Ignore, DisableRule and
+// AllowFunc apply as for CheckAll.
+func Lint(info *jsontypes.Info, opts ...CheckOption) []*TypeReport {
+	ctxt := &checkContext{
+		checkOptions: newCheckOptions(opts),
+		info0:        info,
+		info1:        info,
+	}
+	var reports []*TypeReport
+	for _, name := range sortedNames(info) {
+		t := info.Types[name]
+		if ctxt.ignore(info, t) {
+			continue
+		}
+		ctxt.errors = nil
+		ctxt.typeName = name
+		ctxt.role = roleOf(t, t)
+		ctxt.stability = t.StabilityOf()
+		ctxt.lintType(t, nil)
+		if len(ctxt.errors) > 0 {
+			reports = append(reports, &TypeReport{
+				Name:   name,
+				Errors: ctxt.errors,
+			})
+		}
+	}
+	return reports
+}
+
+// lintType lints the struct types within t, not including
+// the definitions of other named types that it refers to.
+func (ctxt *checkContext) lintType(t *jsontypes.Type, path Path) {
+	if t == nil || (path != nil && t.Name != "") {
+		return
+	}
+	switch t.Kind {
+	case jsontypes.Ptr:
+		ctxt.lintType(t.Elem, path.with(PathElem{Kind: PathDeref}))
+	case jsontypes.Slice, jsontypes.Array, jsontypes.Map:
+		ctxt.lintType(t.Elem, path.with(PathElem{Kind: PathElemType}))
+	case jsontypes.Struct:
+		for _, f := range t.Fields {
+			fpath := path.with(PathElem{
+				Kind:        PathField,
+				Name:        f.Name,
+				EncodedName: f.EncodedName,
+			})
+			restore := ctxt.setDecls(func() (string, string) {
+				return "", jsontypes.FormatField(ctxt.info1, f)
+			})
+			ctxt.lintTags(f, fpath)
+			restore()
+			ctxt.lintType(f.Type, fpath)
+		}
+	}
+}
+
+// lintTags checks that the encoding tags of the field f agree
+// with one another.
+func (ctxt *checkContext) lintTags(f *jsontypes.Field, path Path) {
+	tags := reflect.StructTag(f.Tag)
+	var keys []string
+	for _, key := range encodingTagKeys {
+		if _, ok := tags.Lookup(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < 2 {
+		return
+	}
+	embedded := isEmbeddedStruct(ctxt.info1, f)
+	ref := keys[0]
+	refName := jsontypes.EncodedName(f.Name, f.Tag, ref, embedded)
+	refOmit := hasTagOption(tags.Get(ref), "omitempty")
+	for _, key := range keys[1:] {
+		name := jsontypes.EncodedName(f.Name, f.Tag, key, embedded)
+		want := refName
+		if convert := ctxt.tagNames[key]; convert != nil && want != "" && ref == "json" {
+			want = convert(want)
+		}
+		switch {
+		case name == want:
+		case want == "":
+			ctxt.errorf(ruleEncodingNameMismatch, path, "field is not encoded by %s but is encoded by %s as %q", ref, key, name)
+		case name == "":
+			ctxt.errorf(ruleEncodingNameMismatch, path, "field is encoded by %s as %q but is not encoded by %s", ref, refName, key)
+		default:
+			ctxt.errorf(ruleEncodingNameMismatch, path, "field is encoded by %s as %q but by %s as %q", ref, refName, key, name)
+		}
+		if name == "" || refName == "" {
+			continue
+		}
+		if omit := hasTagOption(tags.Get(key), "omitempty"); omit != refOmit {
+			with, without := ref, key
+			if omit {
+				with, without = key, ref
+			}
+			ctxt.errorf(ruleOmitEmptyMismatch, path, "field has omitempty for %s but not for %s", with, without)
+		}
+	}
+}
+
+// hasTagOption reports whether the struct tag value
+// has the given option after its name.
+func hasTagOption(value, option string) bool {
+	_, opts, _ := strings.Cut(value, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// derefPtr returns the element type of t if it's
+// an unnamed pointer type, or t otherwise.
+func derefPtr(t *jsontypes.Type) *jsontypes.Type {
+	if t.Kind == jsontypes.Ptr && t.Name == "" {
+		return t.Elem
+	}
+	return t
+}
@@ -0,0 +1,80 @@
+package apicompat
+
+import "github.com/rogpeppe/apicompat/jsontypes"
+
+// MarshalMethodNames holds the names of the methods
+// that can change the way that a type is marshaled.
+var MarshalMethodNames = []string{
+	"MarshalJSON",
+	"UnmarshalJSON",
+	"MarshalText",
+	"UnmarshalText",
+}
+
+// MarshalMethods returns an option that causes any type with
+// a custom marshaler to be treated as compatible, as
+// Ignore(HasCustomMarshaler) does, where the methods that
+// count as marshalers are those named in MarshalMethodNames
+// and any of the given names.
+func MarshalMethods(names ...string) CheckOption {
+	return func(o *checkOptions) {
+		o.ignoreMarshalers = true
+		o.marshalMethods = append(o.marshalMethods, names...)
+	}
+}
+
+// IsMarshalMethod reports whether m is one of the
+// methods named in MarshalMethodNames.
+func IsMarshalMethod(t *jsontypes.Type, m *jsontypes.Method) bool {
+	var o checkOptions
+	return o.isMarshalMethod(m.Name)
+}
+
+// isMarshalMethod reports whether the method with the given
+// name is named in MarshalMethodNames or passed to MarshalMethods.
+func (o *checkOptions) isMarshalMethod(name string) bool {
+	for _, names := range [][]string{MarshalMethodNames, o.marshalMethods} {
+		for _, name1 := range names {
+			if name == name1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// marshalInterfaces holds the well-known interfaces
+// that correspond to MarshalMethodNames.
+var marshalInterfaces = []jsontypes.TypeName{
+	"encoding/json#Marshaler",
+	"encoding/json#Unmarshaler",
+	"encoding#TextMarshaler",
+	"encoding#TextUnmarshaler",
+}
+
+// HasCustomMarshaler reports whether t has any of the methods
+// named in MarshalMethodNames, or is recorded as implementing
+// any of the corresponding interfaces (see jsontypes.Type.Implements).
+// It can be used with Ignore to treat types that implement their
+// own marshaling as opaque.
+func HasCustomMarshaler(info *jsontypes.Info, t *jsontypes.Type) bool {
+	var o checkOptions
+	return o.hasCustomMarshaler(t)
+}
+
+// hasCustomMarshaler is like HasCustomMarshaler except that
+// it also takes account of the names passed to MarshalMethods.
+func (o *checkOptions) hasCustomMarshaler(t *jsontypes.Type) bool {
+	for name := range t.Methods {
+		if o.isMarshalMethod(name) {
+			// TODO check sig too
+			return true
+		}
+	}
+	for _, name := range marshalInterfaces {
+		if t.ImplementsInterface(name, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package apicompat
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// MatchNames returns an option that causes fields, methods and
+// types to be matched between the old and new APIs when their
+// names are the same after applying normalize, for example
+// NormalizeCase. This is useful when comparing APIs derived from
+// schemas that use different naming conventions, so that a
+// consistent difference in convention is not reported as many
+// removals and additions. A name that matches exactly is
+// always preferred.
+func MatchNames(normalize func(name string) string) CheckOption {
+	return func(o *checkOptions) {
+		o.normalizeName = normalize
+	}
+}
+
+// NormalizeCase returns name converted to lower-case snake_case,
+// so that names that differ only in their naming convention, such
+// as "UserID", "userId", "user_id" and "USER_ID", are the same.
+// Word boundaries are taken to be underscores, hyphens and
+// spaces, changes from lower to upper case, and the last
+// upper-case letter of an initialism followed by a lower-case
+// letter, as in "HTTPServer".
+func NormalizeCase(name string) string {
+	var buf strings.Builder
+	rs := []rune(name)
+	for i, r := range rs {
+		if r == '_' || r == '-' || r == ' ' {
+			if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "_") {
+				buf.WriteByte('_')
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && buf.Len() > 0 && !strings.HasSuffix(buf.String(), "_") {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				buf.WriteByte('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return strings.TrimSuffix(buf.String(), "_")
+}
+
+// nameKey returns the key used to match the given
+// field or method name between APIs.
+func (o *checkOptions) nameKey(name string) string {
+	if o.normalizeName == nil {
+		return name
+	}
+	return o.normalizeName(name)
+}
+
+// fieldByName returns the field in t that matches the
+// field with the given name, or nil if there is none.
+func (o *checkOptions) fieldByName(t *jsontypes.Type, name string) *jsontypes.Field {
+	if f := t.FieldByName(name); f != nil || o.normalizeName == nil {
+		return f
+	}
+	key := o.nameKey(name)
+	var found *jsontypes.Field
+	for _, f := range t.Fields {
+		if o.nameKey(f.Name) == key {
+			if found != nil {
+				// Ambiguous.
+				return nil
+			}
+			found = f
+		}
+	}
+	return found
+}
+
+// promotedFieldByName is like fieldByName, but also finds fields
+// promoted from structs embedded in t, directly or through
+// pointers, as Go does: a field at a shallower depth takes
+// precedence, and a name that is ambiguous at the shallowest
+// depth at which it's found matches no field. Embedded fields
+// with a JSON name are not followed, because encoding/json
+// encodes them as nested objects rather than promoting their
+// fields.
+func (o *checkOptions) promotedFieldByName(info *jsontypes.Info, t *jsontypes.Type, name string) *jsontypes.Field {
+	if f := o.fieldByName(t, name); f != nil {
+		return f
+	}
+	seen := map[*jsontypes.Type]bool{t: true}
+	level := []*jsontypes.Type{t}
+	for len(level) > 0 {
+		var next []*jsontypes.Type
+		for _, st := range level {
+			for _, f := range st.Fields {
+				if !f.Anonymous || f.EncodedName != "" {
+					continue
+				}
+				et := info.Deref(f.Type)
+				if et != nil && et.Kind == jsontypes.Ptr {
+					et = info.Deref(et.Elem)
+				}
+				if et != nil && et.Kind == jsontypes.Struct && !seen[et] {
+					seen[et] = true
+					next = append(next, et)
+				}
+			}
+		}
+		var found *jsontypes.Field
+		for _, st := range next {
+			if f := o.fieldByName(st, name); f != nil {
+				if found != nil {
+					// Ambiguous.
+					return nil
+				}
+				found = f
+			}
+		}
+		if found != nil {
+			return found
+		}
+		level = next
+	}
+	return nil
+}
+
+// methodByName returns the method in t that matches the
+// method with the given name, or nil if there is none.
+func (o *checkOptions) methodByName(t *jsontypes.Type, name string) *jsontypes.Method {
+	if m := t.Methods[name]; m != nil || o.normalizeName == nil {
+		return m
+	}
+	key := o.nameKey(name)
+	var found *jsontypes.Method
+	for name1, m := range t.Methods {
+		if o.nameKey(name1) == key {
+			if found != nil {
+				return nil
+			}
+			found = m
+		}
+	}
+	return found
+}
+
+// normalizeTypeName returns name without any module versions
+// and with its unqualified name, excluding any type arguments,
+// normalized as for nameKey.
+func (o *checkOptions) normalizeTypeName(name jsontypes.TypeName) jsontypes.TypeName {
+	name = name.WithoutVersions()
+	pkg, local, ok := strings.Cut(string(name), "#")
+	if !ok {
+		return name
+	}
+	args := ""
+	if i := strings.Index(local, "["); i >= 0 {
+		local, args = local[:i], local[i:]
+	}
+	return jsontypes.TypeName(pkg + "#" + o.nameKey(local) + args)
+}
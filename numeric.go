@@ -0,0 +1,130 @@
+package apicompat
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// LenientNumbers returns an option that allows numeric types
+// to change to wider types of the same kind of number, for
+// example from int32 to int64 or from float32 to float64, as
+// all the old values remain representable and are encoded
+// the same way in JSON. Narrowing conversions and changes
+// between integers and floating point numbers are still reported.
+func LenientNumbers() CheckOption {
+	return func(o *checkOptions) {
+		o.lenientNumbers = true
+	}
+}
+
+// numberClass classifies numeric kinds.
+type numberClass int
+
+const (
+	notNumber numberClass = iota
+	signedInt
+	unsignedInt
+	floatNumber
+)
+
+// numberKinds holds the class and size in bits of each
+// numeric kind. Int, Uint and Uintptr are assumed
+// to be 64 bits.
+var numberKinds = map[jsontypes.Kind]struct {
+	class numberClass
+	bits  int
+}{
+	jsontypes.Int:     {signedInt, 64},
+	jsontypes.Int8:    {signedInt, 8},
+	jsontypes.Int16:   {signedInt, 16},
+	jsontypes.Int32:   {signedInt, 32},
+	jsontypes.Int64:   {signedInt, 64},
+	jsontypes.Uint:    {unsignedInt, 64},
+	jsontypes.Uint8:   {unsignedInt, 8},
+	jsontypes.Uint16:  {unsignedInt, 16},
+	jsontypes.Uint32:  {unsignedInt, 32},
+	jsontypes.Uint64:  {unsignedInt, 64},
+	jsontypes.Uintptr: {unsignedInt, 64},
+	jsontypes.Float32: {floatNumber, 32},
+	jsontypes.Float64: {floatNumber, 64},
+}
+
+// checkNumber checks whether t0 and t1 are different numeric
+// kinds, and if so, reports any change that can lose
+// information, and returns true.
+func (ctxt *checkContext) checkNumber(t0, t1 *jsontypes.Type, path Path) bool {
+	n0, ok0 := numberKinds[t0.Kind]
+	n1, ok1 := numberKinds[t1.Kind]
+	if !ok0 || !ok1 || t0.Kind == t1.Kind {
+		return false
+	}
+	switch {
+	case (n0.class == floatNumber) != (n1.class == floatNumber):
+		ctxt.errorf(ruleNumberRepresentation, path, "number representation changed (%s vs %s)", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+	case n0.class == n1.class && n1.bits > n0.bits,
+		n0.class == unsignedInt && n1.class == signedInt && n1.bits > n0.bits:
+		// All the old values can be represented.
+		if !ctxt.lenientNumbers {
+			ctxt.errorf(ruleKindChanged, path, "incompatible types %s vs %s", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+		}
+	default:
+		ctxt.errorf(ruleNumberNarrowed, path, "lossy numeric conversion (%s vs %s)", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+	}
+	return true
+}
+
+// JSSafeIntegers returns an option that reports struct fields
+// holding 64-bit integers that are encoded as JSON numbers,
+// which cannot all be represented exactly by JavaScript clients,
+// and fields from which the ",string" JSON tag option has been
+// removed. Only fields in the new API are reported.
+func JSSafeIntegers() CheckOption {
+	return func(o *checkOptions) {
+		o.setRule(ruleJSUnsafeInteger, true)
+	}
+}
+
+// checkJSSafe checks that the field f1, which was f0 in
+// the old API, or is new if f0 is nil, is not a 64-bit integer
+// encoded as a JSON number.
+func (ctxt *checkContext) checkJSSafe(f0, f1 *jsontypes.Field, path Path) {
+	if !ctxt.enabled(ruleJSUnsafeInteger, ctxt.role, ctxt.stability) {
+		return
+	}
+	if f1.EncodedName == "" || hasStringOption(f1.Tag) {
+		return
+	}
+	kind := integerKind(ctxt.info1, f1.Type)
+	if kind != jsontypes.Int64 && kind != jsontypes.Uint64 && kind != jsontypes.Int && kind != jsontypes.Uint {
+		return
+	}
+	if f0 != nil && hasStringOption(f0.Tag) {
+		ctxt.errorf(ruleJSUnsafeInteger, path, "%s field is no longer encoded as a string", kind)
+		return
+	}
+	ctxt.errorf(ruleJSUnsafeInteger, path, "%s field is encoded as a JSON number", kind)
+}
+
+// integerKind returns the kind of t, after following any
+// pointers.
+func integerKind(info *jsontypes.Info, t *jsontypes.Type) jsontypes.Kind {
+	t = info.Deref(t)
+	for t.Kind == jsontypes.Ptr {
+		t = info.Deref(t.Elem)
+	}
+	return t.Kind
+}
+
+// hasStringOption reports whether the given struct tag has
+// the ",string" JSON option.
+func hasStringOption(tag string) bool {
+	_, opts, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "string" {
+			return true
+		}
+	}
+	return false
+}
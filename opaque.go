@@ -0,0 +1,51 @@
+package apicompat
+
+import (
+	"path"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Opaque returns an option that causes named types matching any
+// of the given patterns to be treated as opaque values, compared
+// by name only: a type is compatible with an opaque type only if
+// it has the same name (after any package renames), regardless
+// of the contents of either type or any marshaling methods.
+// This is useful for types such as time.Time whose encoding
+// is fixed by their marshalers, and for pinning down exactly
+// which types are compared by identity rather than relying on
+// Ignore(HasCustomMarshaler).
+//
+// A pattern is matched against the type name without any
+// module version, for example "time#Time", using path.Match,
+// so "example.com/money#*" matches all the types in that package.
+func Opaque(patterns ...string) CheckOption {
+	return func(o *checkOptions) {
+		o.opaque = append(o.opaque, patterns...)
+	}
+}
+
+// isOpaque reports whether t is a named type
+// matched by a pattern passed to Opaque.
+func (o *checkOptions) isOpaque(t *jsontypes.Type) bool {
+	if t == nil || t.Name == "" || len(o.opaque) == 0 {
+		return false
+	}
+	name := string(t.Name.WithoutVersions())
+	for _, pattern := range o.opaque {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOpaque checks that t1 is the same named type as t0,
+// at least one of which is opaque.
+func (ctxt *checkContext) checkOpaque(t0, t1 *jsontypes.Type, path Path) {
+	name0, name1 := t0.Name.WithoutVersions(), t1.Name.WithoutVersions()
+	if name0 == name1 || ctxt.renamed(t0.Name).WithoutVersions() == name1 {
+		return
+	}
+	ctxt.errorf(ruleOpaqueChanged, path, "opaque type %s has changed to %s", describe(ctxt.info0, t0), describe(ctxt.info1, t1))
+}
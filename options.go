@@ -0,0 +1,571 @@
+package apicompat
+
+import (
+	"path"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// CheckOption represents an option that can be
+// passed to Check and related functions.
+type CheckOption func(*checkOptions)
+
+type checkOptions struct {
+	ignores         []func(info *jsontypes.Info, t *jsontypes.Type) bool
+	kindComparators map[jsontypes.Kind][]Comparator
+	typeComparators map[jsontypes.TypeName][]Comparator
+	deprecation     *deprecationPolicy
+	progress        jsontypes.ProgressFunc
+	pathFormatter   PathFormatter
+
+	// rules holds rules that have been explicitly
+	// enabled (true) or disabled (false).
+	rules map[string]bool
+
+	// models holds whether struct types with ORM
+	// tags are checked as database models.
+	models bool
+
+	// positional holds the predicate used to determine
+	// whether a struct type is encoded positionally.
+	positional func(info *jsontypes.Info, t *jsontypes.Type) bool
+
+	// packageRenames holds the package paths declared
+	// as renamed by PackageRename.
+	packageRenames []packageRename
+
+	// lenientNumbers holds whether numeric types
+	// may be widened.
+	lenientNumbers bool
+
+	// lenientFuncs holds whether function parameters and
+	// results are checked according to their variance.
+	lenientFuncs bool
+
+	// roots holds the root types set by Roots.
+	roots []jsontypes.TypeName
+
+	// allowFuncs holds the functions set by AllowFunc.
+	allowFuncs []func(*Problem, *ProblemContext) bool
+
+	// normalizeName holds the function set by MatchNames.
+	normalizeName func(string) string
+
+	// since holds the version set by SinceVersion.
+	since string
+
+	// opaque holds the patterns set by Opaque.
+	opaque []string
+
+	// tagNames holds the name conversions declared
+	// by TagNamePolicy, by tag key.
+	tagNames map[string]func(string) string
+
+	// additions holds whether added fields and
+	// methods are reported (see ReportAdditions).
+	additions bool
+
+	// ignoreMarshalers holds whether types with custom
+	// marshalers are ignored, and marshalMethods holds
+	// the method names passed to MarshalMethods.
+	ignoreMarshalers bool
+	marshalMethods   []string
+
+	// encodingTags holds the tag keys added by EncodingTags.
+	encodingTags []string
+
+	// checkedTags and ignoredTags hold the tag keys
+	// passed to CheckTags and IgnoreTags.
+	checkedTags map[string]bool
+	ignoredTags map[string]bool
+
+	// ignoreNames holds the patterns set by IgnoreTypes.
+	ignoreNames []string
+
+	// encodedOnly holds whether struct fields that are not
+	// encoded as JSON are ignored (see Profile).
+	encodedOnly bool
+
+	// noRoles holds whether the roles of types are ignored
+	// when deciding which rules apply (see Profile).
+	noRoles bool
+
+	// extraRules holds the rules added by ExtraRules.
+	extraRules []Rule
+
+	// severities holds the severities set by SetSeverity.
+	severities map[string]Severity
+}
+
+type packageRename struct {
+	oldPath, newPath string
+}
+
+type deprecationPolicy struct {
+	minVersions int
+	history     []*jsontypes.Info
+}
+
+// setRule enables or disables the rule with the given ID.
+func (o *checkOptions) setRule(id string, on bool) {
+	if o.rules == nil {
+		o.rules = make(map[string]bool)
+	}
+	o.rules[id] = on
+}
+
+// severity returns the severity of problems found
+// by the rule with the given ID.
+func (o *checkOptions) severity(id string) Severity {
+	if s, ok := o.severities[id]; ok {
+		return s
+	}
+	return ruleSeverity(id)
+}
+
+// EnableRule returns an option that enables the rules with the
+// given IDs, including optional rules, regardless of the roles
+// of the types being checked. Experimental types are still
+// not checked.
+func EnableRule(ids ...string) CheckOption {
+	return func(o *checkOptions) {
+		for _, id := range ids {
+			o.setRule(id, true)
+		}
+	}
+}
+
+// DisableRule returns an option that disables the rules
+// with the given IDs, so that they never report problems.
+func DisableRule(ids ...string) CheckOption {
+	return func(o *checkOptions) {
+		for _, id := range ids {
+			o.setRule(id, false)
+		}
+	}
+}
+
+// SetSeverity returns an option that overrides the severity
+// of the problems found by the rule with the given ID
+// (see RuleInfo.Severity).
+func SetSeverity(id string, s Severity) CheckOption {
+	return func(o *checkOptions) {
+		if o.severities == nil {
+			o.severities = make(map[string]Severity)
+		}
+		o.severities[id] = s
+	}
+}
+
+func newCheckOptions(opts []CheckOption) checkOptions {
+	var o checkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Ignore returns an option that causes any type that
+// satisfies f to be treated as compatible.
+// If f is nil, the option has no effect.
+// When several Ignore options are provided, a type is
+// ignored if it satisfies any of them.
+func Ignore(f func(info *jsontypes.Info, t *jsontypes.Type) bool) CheckOption {
+	return func(o *checkOptions) {
+		if f != nil {
+			o.ignores = append(o.ignores, f)
+		}
+	}
+}
+
+// EncodingTags returns an option that causes the struct tags with
+// the given keys, such as "xml" or "bson", to be compared as a name
+// followed by comma-separated options, as the json, yaml and msgpack
+// tags always are, rather than as plain strings. Changes to the name
+// and to the options are then reported by separate rules, and the
+// order of the options doesn't matter.
+func EncodingTags(keys ...string) CheckOption {
+	return func(o *checkOptions) {
+		o.encodingTags = append(o.encodingTags, keys...)
+	}
+}
+
+// isEncodingTag reports whether the struct tag with
+// the given key holds a name followed by options.
+func (o *checkOptions) isEncodingTag(key string) bool {
+	for _, k := range encodingTagKeys {
+		if k == key {
+			return true
+		}
+	}
+	for _, k := range o.encodingTags {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTags returns an option that restricts the struct tags
+// compared between old and new fields to those with the given
+// keys, such as "json" and "yaml". By default, all tags are
+// compared.
+func CheckTags(keys ...string) CheckOption {
+	return func(o *checkOptions) {
+		if o.checkedTags == nil {
+			o.checkedTags = make(map[string]bool)
+		}
+		for _, key := range keys {
+			o.checkedTags[key] = true
+		}
+	}
+}
+
+// IgnoreTags returns an option that causes changes to the struct
+// tags with the given keys, such as "validate" or "gorm", to be
+// ignored. It takes precedence over CheckTags.
+func IgnoreTags(keys ...string) CheckOption {
+	return func(o *checkOptions) {
+		if o.ignoredTags == nil {
+			o.ignoredTags = make(map[string]bool)
+		}
+		for _, key := range keys {
+			o.ignoredTags[key] = true
+		}
+	}
+}
+
+// checksTag reports whether changes to the struct tag
+// with the given key are checked.
+func (o *checkOptions) checksTag(key string) bool {
+	if o.ignoredTags[key] {
+		return false
+	}
+	return o.checkedTags == nil || o.checkedTags[key]
+}
+
+// IgnoreTypes returns an option that causes named types matching
+// any of the given patterns to be treated as compatible, and not
+// to be reported when removed or added. Patterns are matched as
+// for Opaque, so "example.com/foo#Internal*" matches all the types
+// in that package whose names start with Internal.
+func IgnoreTypes(patterns ...string) CheckOption {
+	return func(o *checkOptions) {
+		o.ignoreNames = append(o.ignoreNames, patterns...)
+	}
+}
+
+// ignoresName reports whether the type with the given
+// name matches a pattern passed to IgnoreTypes.
+func (o *checkOptions) ignoresName(name jsontypes.TypeName) bool {
+	s := string(name.WithoutVersions())
+	for _, pattern := range o.ignoreNames {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignore reports whether t should be treated as compatible
+// regardless of its contents.
+func (o *checkOptions) ignore(info *jsontypes.Info, t *jsontypes.Type) bool {
+	if t != nil && (t.Ignored || t.Name != "" && o.ignoresName(t.Name)) {
+		return true
+	}
+	if t != nil && o.ignoreMarshalers && o.hasCustomMarshaler(t) {
+		return true
+	}
+	for _, f := range o.ignores {
+		if f(info, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Comparator is used to provide custom comparison of types.
+// It is called to compare t0 (from info0) with t1 (from info1).
+// If it returns false, the comparison falls back to the default
+// behavior; otherwise the types are considered compatible
+// if the returned error is nil, and the error is reported
+// as an incompatibility if not.
+//
+// For example, to treat decimal.Decimal as interchangeable
+// with string (both are marshaled as JSON strings):
+//
+//	decimalOrString := func(info0 *jsontypes.Info, t0 *jsontypes.Type, info1 *jsontypes.Info, t1 *jsontypes.Type) (bool, error) {
+//		for _, t := range []*jsontypes.Type{t0, t1} {
+//			if t.Name != "github.com/shopspring/decimal#Decimal" && t.Kind != jsontypes.String {
+//				return false, nil
+//			}
+//		}
+//		return true, nil
+//	}
+//	err := apicompat.Check(info0, info1, t0, t1, nil,
+//		apicompat.CompareType("github.com/shopspring/decimal#Decimal", decimalOrString),
+//	)
+type Comparator func(info0 *jsontypes.Info, t0 *jsontypes.Type, info1 *jsontypes.Info, t1 *jsontypes.Type) (handled bool, err error)
+
+// CompareType returns an option that causes cmp to be called
+// whenever either of the types being compared has the given
+// name. Comparators registered for a type name are called before
+// those registered for a kind.
+func CompareType(name jsontypes.TypeName, cmp Comparator) CheckOption {
+	return func(o *checkOptions) {
+		if o.typeComparators == nil {
+			o.typeComparators = make(map[jsontypes.TypeName][]Comparator)
+		}
+		o.typeComparators[name] = append(o.typeComparators[name], cmp)
+	}
+}
+
+// CompareKind returns an option that causes cmp to be called
+// whenever either of the types being compared has the given kind.
+func CompareKind(kind jsontypes.Kind, cmp Comparator) CheckOption {
+	return func(o *checkOptions) {
+		if o.kindComparators == nil {
+			o.kindComparators = make(map[jsontypes.Kind][]Comparator)
+		}
+		o.kindComparators[kind] = append(o.kindComparators[kind], cmp)
+	}
+}
+
+// comparators returns all the comparators that apply when
+// comparing t0 with t1, in the order they should be called.
+func (o *checkOptions) comparators(t0, t1 *jsontypes.Type) []Comparator {
+	if o.typeComparators == nil && o.kindComparators == nil {
+		return nil
+	}
+	var cmps []Comparator
+	cmps = append(cmps, o.typeComparators[t0.Name]...)
+	if t1.Name != t0.Name {
+		cmps = append(cmps, o.typeComparators[t1.Name]...)
+	}
+	cmps = append(cmps, o.kindComparators[t0.Kind]...)
+	if t1.Kind != t0.Kind {
+		cmps = append(cmps, o.kindComparators[t1.Kind]...)
+	}
+	return cmps
+}
+
+// RequireDeprecation returns an option that makes removal of
+// a type, field or method acceptable only if it was marked as deprecated
+// (see jsontypes.IsDeprecated) in the old API. Removal of an item
+// that was not deprecated is reported as a policy violation
+// rather than as a plain removal.
+//
+// If minVersions is greater than one, the item must also have
+// been deprecated for at least that many versions, which are
+// taken from history, a list of snapshots of API versions before
+// the old API being checked, oldest first. For example, with
+// minVersions set to 2, the item must have been deprecated both
+// in the old API and in the last element of history. Items inside
+// unnamed types can be found only in the old API, so for
+// those the history is not consulted.
+func RequireDeprecation(minVersions int, history ...*jsontypes.Info) CheckOption {
+	return func(o *checkOptions) {
+		o.deprecation = &deprecationPolicy{
+			minVersions: minVersions,
+			history:     history,
+		}
+	}
+}
+
+// deprecatedType reports whether the named type has been
+// deprecated for long enough in the history to satisfy the policy.
+func (p *deprecationPolicy) deprecatedType(name jsontypes.TypeName) bool {
+	need := p.minVersions - 1
+	if need <= 0 {
+		return true
+	}
+	if len(p.history) < need {
+		return false
+	}
+	for _, info := range p.history[len(p.history)-need:] {
+		t := info.Types[name]
+		if t == nil || !jsontypes.IsDeprecated(t.Doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// deprecatedFor reports whether the field or method with the given
+// name in the named type has been deprecated for long enough
+// in the history to satisfy the policy. The old version itself
+// should already have been checked.
+func (p *deprecationPolicy) deprecatedFor(typeName jsontypes.TypeName, member string) bool {
+	need := p.minVersions - 1
+	if need <= 0 {
+		return true
+	}
+	if typeName == "" {
+		return true
+	}
+	if len(p.history) < need {
+		return false
+	}
+	for _, info := range p.history[len(p.history)-need:] {
+		t := info.Types[typeName]
+		if t == nil {
+			return false
+		}
+		var doc string
+		if f := t.FieldByName(member); f != nil {
+			doc = f.Doc
+		} else if m := t.Methods[member]; m != nil {
+			doc = m.Doc
+		} else {
+			return false
+		}
+		if !jsontypes.IsDeprecated(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// LenientMapStruct returns an option that treats a change
+// between a map with string keys and a struct whose fields all
+// have the map's element type as a warning, listing the
+// struct's field names, rather than as an incompatible change of kind.
+// Such a change is compatible as long as producers of the
+// map only use keys that match the struct's fields.
+func LenientMapStruct() CheckOption {
+	return func(o *checkOptions) {
+		o.setRule(ruleMapStruct, true)
+	}
+}
+
+// CheckReceivers returns an option that determines which
+// changes to the receivers of methods are reported: changes
+// from value to pointer receivers, which remove the method from
+// the method set of the value type, and changes from pointer to
+// value receivers. By default, only the former are reported.
+func CheckReceivers(valueToPointer, pointerToValue bool) CheckOption {
+	return func(o *checkOptions) {
+		o.setRule(ruleReceiverChanged, valueToPointer)
+		o.setRule(ruleReceiverToValue, pointerToValue)
+	}
+}
+
+// PackageRename returns an option that declares that the
+// package with the given old path, and all packages inside it,
+// have moved to newPath, so that CheckAll matches the types
+// and functions in them with those in the new location rather
+// than reporting them all as removed and added. For example,
+// after a major version bump of a module, this could be
+// used as:
+//
+//	apicompat.PackageRename("example.com/m", "example.com/m/v2")
+//
+// Types that exist under their old name in the new API are
+// still matched with those.
+func PackageRename(oldPath, newPath string) CheckOption {
+	return func(o *checkOptions) {
+		o.packageRenames = append(o.packageRenames, packageRename{
+			oldPath: oldPath,
+			newPath: newPath,
+		})
+	}
+}
+
+// renamed returns the name that the type or function with the
+// given name in the old API is expected to have in the new API
+// according to any PackageRename options.
+func (o *checkOptions) renamed(name jsontypes.TypeName) jsontypes.TypeName {
+	for _, r := range o.packageRenames {
+		name = name.WithPackagePath(r.oldPath, r.newPath)
+	}
+	return name
+}
+
+// Roots returns an option that causes CheckAll to consider
+// only the types reachable from the given root types (see
+// jsontypes.Info.Reachable), so that helper types that are not
+// part of the public API are not checked or reported as added
+// or removed. Roots that are not present in an API, even
+// after any package renames (see PackageRename), are ignored.
+// Functions, facades, services and routes are checked as usual.
+// When several Roots options are provided, all the roots are used.
+func Roots(names ...jsontypes.TypeName) CheckOption {
+	return func(o *checkOptions) {
+		o.roots = append(o.roots, names...)
+	}
+}
+
+// reachable returns the names of the types in info that
+// are reachable from the roots, or nil if no roots have
+// been specified, meaning that all types are in scope.
+func (o *checkOptions) reachable(info *jsontypes.Info) map[jsontypes.TypeName]bool {
+	if len(o.roots) == 0 {
+		return nil
+	}
+	var roots []jsontypes.TypeName
+	for _, name := range o.roots {
+		if info.Types[name] == nil {
+			name = o.renamed(name)
+		}
+		if info.Types[name] != nil {
+			roots = append(roots, name)
+		}
+	}
+	// The error can be ignored because all the roots are present.
+	reached, _ := info.Reachable(roots...)
+	return reached
+}
+
+// ReportProgress returns an option that causes f to be
+// called as each type is checked by CheckAll.
+func ReportProgress(f jsontypes.ProgressFunc) CheckOption {
+	return func(o *checkOptions) {
+		o.progress = f
+	}
+}
+
+// FormatPath returns an option that causes paths
+// in reported errors to be formatted with f
+// rather than GoPath.
+func FormatPath(f PathFormatter) CheckOption {
+	return func(o *checkOptions) {
+		o.pathFormatter = f
+	}
+}
+
+func (o *checkOptions) formatPath(p Path) string {
+	if o.pathFormatter != nil {
+		return o.pathFormatter(p)
+	}
+	return GoPath(p)
+}
+
+// SinceVersion returns an option that causes items in the old API
+// that were introduced after the given version, as recorded by a
+// "Since:" line in their doc comment (see jsontypes.SinceFromDoc),
+// to be treated as if they were not in the old API. This allows
+// compatibility to be checked relative to an earlier release than
+// the old API itself, so that items that have not yet been released
+// may be changed or removed. Such items in the new API are reported
+// as added.
+func SinceVersion(version string) CheckOption {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return func(o *checkOptions) {
+		o.since = version
+	}
+}
+
+// introducedLater reports whether the item with the given doc
+// comment was introduced after the version set by SinceVersion.
+func (o *checkOptions) introducedLater(doc string) bool {
+	if o.since == "" {
+		return false
+	}
+	v := jsontypes.SinceFromDoc(doc)
+	return v != "" && semver.Compare(v, o.since) > 0
+}
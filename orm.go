@@ -0,0 +1,280 @@
+package apicompat
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// ORMProfile returns an option that checks struct types
+// used as database models by an ORM, as well as checking
+// them as usual.
+//
+// A struct is treated as a model when any of its fields
+// has a gorm or bun struct tag, or when it embeds gorm.Model or bun.BaseModel.
+// The columns of old and new models are matched by column
+// name rather than field name, and changes that would require
+// a destructive migration are reported, such as dropping a
+// column, changing its type or primary key, or adding
+// a NOT NULL or UNIQUE constraint to existing data.
+//
+// Changes to ORM tags that do not affect the schema are
+// not reported as tag changes when this option is in effect.
+func ORMProfile() CheckOption {
+	return func(o *checkOptions) {
+		o.models = true
+		for _, id := range ormRules {
+			o.setRule(id, true)
+		}
+	}
+}
+
+// ormTagKeys holds the struct tag keys understood
+// by ORMProfile.
+var ormTagKeys = map[string]bool{
+	"gorm": true,
+	"bun":  true,
+}
+
+// column describes a database column derived
+// from a struct field.
+type column struct {
+	field      *jsontypes.Field
+	name       string
+	sqlType    string
+	size       int
+	primaryKey bool
+	notNull    bool
+	unique     bool
+	hasDefault bool
+}
+
+// checkModel checks the columns of the model t0
+// against those of t1.
+func (ctxt *checkContext) checkModel(t0, t1 *jsontypes.Type, path Path) {
+	cols0 := modelColumns(ctxt.info0, t0, "", nil)
+	cols1 := modelColumns(ctxt.info1, t1, "", nil)
+	byName := func(cols []*column) map[string]*column {
+		m := make(map[string]*column)
+		for _, c := range cols {
+			m[c.name] = c
+		}
+		return m
+	}
+	byName1 := byName(cols1)
+	var pk0, pk1 []string
+	for _, c0 := range cols0 {
+		if c0.primaryKey {
+			pk0 = append(pk0, c0.name)
+		}
+		c1 := byName1[c0.name]
+		path := path.with(PathElem{Kind: PathField, Name: c0.field.Name})
+		if c1 == nil {
+			restore := ctxt.setDecls(ctxt.fieldDecls(c0.field, nil))
+			ctxt.errorf(ruleColumnRemoved, path, "column %q would be dropped", c0.name)
+			restore()
+			continue
+		}
+		restore := ctxt.setDecls(ctxt.fieldDecls(c0.field, c1.field))
+		switch {
+		case c0.sqlType != c1.sqlType:
+			ctxt.errorf(ruleColumnTypeChanged, path, "column %q type changed from %s to %s", c0.name, sqlTypeString(c0.sqlType), sqlTypeString(c1.sqlType))
+		case c1.size != 0 && (c0.size == 0 || c1.size < c0.size):
+			ctxt.errorf(ruleColumnTypeChanged, path, "column %q size reduced from %s to %d", c0.name, sizeString(c0.size), c1.size)
+		}
+		if !c0.notNull && c1.notNull {
+			ctxt.errorf(ruleNotNullAdded, path, "column %q has become NOT NULL", c0.name)
+		}
+		if !c0.unique && c1.unique {
+			ctxt.errorf(ruleUniqueAdded, path, "column %q has become UNIQUE", c0.name)
+		}
+		restore()
+	}
+	byName0 := byName(cols0)
+	for _, c1 := range cols1 {
+		if c1.primaryKey {
+			pk1 = append(pk1, c1.name)
+		}
+		if byName0[c1.name] != nil || !c1.notNull || c1.hasDefault || c1.primaryKey {
+			continue
+		}
+		restore := ctxt.setDecls(ctxt.fieldDecls(nil, c1.field))
+		ctxt.errorf(ruleRequiredColumnAdded, path.with(PathElem{Kind: PathField, Name: c1.field.Name}), "NOT NULL column %q added with no default", c1.name)
+		restore()
+	}
+	sort.Strings(pk0)
+	sort.Strings(pk1)
+	if strings.Join(pk0, ",") != strings.Join(pk1, ",") {
+		ctxt.errorf(rulePrimaryKeyChanged, path, "primary key changed from (%s) to (%s)", strings.Join(pk0, ", "), strings.Join(pk1, ", "))
+	}
+}
+
+// isModel reports whether the struct type t
+// looks like a database model.
+func isModel(t *jsontypes.Type) bool {
+	if t.Kind != jsontypes.Struct {
+		return false
+	}
+	for _, f := range t.Fields {
+		if f.Anonymous && (f.Type.Name == "gorm.io/gorm#Model" || f.Type.Name == "github.com/uptrace/bun#BaseModel") {
+			return true
+		}
+		tags := allTags(f.Tag)
+		for key := range ormTagKeys {
+			if _, ok := tags[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// modelColumns returns the columns of the model t, adding
+// prefix to the name of each one. Embedded structs are
+// flattened, as the ORMs do.
+func modelColumns(info *jsontypes.Info, t *jsontypes.Type, prefix string, seen map[*jsontypes.Type]bool) []*column {
+	t = info.Deref(t)
+	if seen[t] {
+		return nil
+	}
+	if seen == nil {
+		seen = make(map[*jsontypes.Type]bool)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	var cols []*column
+	for _, f := range t.Fields {
+		tags := allTags(f.Tag)
+		c, embedded, embeddedPrefix := parseColumn(f, tags)
+		if c == nil {
+			continue
+		}
+		if f.Anonymous || embedded {
+			ft := f.Type
+			if ft.Kind == jsontypes.Ptr && ft.Elem != nil {
+				ft = ft.Elem
+			}
+			if ft = info.Deref(ft); ft.Kind == jsontypes.Struct {
+				cols = append(cols, modelColumns(info, ft, prefix+embeddedPrefix, seen)...)
+				continue
+			}
+		}
+		c.name = prefix + c.name
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// parseColumn returns the column for the given field, which
+// has the given struct tags. It returns nil if the field is not
+// stored in the database. It also reports whether the field
+// is explicitly marked as embedded, and any prefix to use for
+// the column names of an embedded struct.
+func parseColumn(f *jsontypes.Field, tags map[string]string) (c *column, embedded bool, embeddedPrefix string) {
+	c = &column{
+		field: f,
+		name:  snakeCase(f.Name),
+	}
+	if tag, ok := tags["gorm"]; ok {
+		for _, item := range strings.Split(tag, ";") {
+			key, val := item, ""
+			if i := strings.Index(item, ":"); i >= 0 {
+				key, val = item[:i], item[i+1:]
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "-":
+				return nil, false, ""
+			case "column":
+				c.name = val
+			case "type":
+				c.sqlType = strings.ToLower(val)
+			case "size":
+				c.size, _ = strconv.Atoi(val)
+			case "primarykey", "primary_key":
+				c.primaryKey = true
+			case "not null", "notnull":
+				c.notNull = true
+			case "unique", "uniqueindex":
+				c.unique = true
+			case "default":
+				c.hasDefault = true
+			case "embedded":
+				embedded = true
+			case "embeddedprefix":
+				embedded, embeddedPrefix = true, val
+			}
+		}
+	}
+	if tag, ok := tags["bun"]; ok {
+		for i, item := range strings.Split(tag, ",") {
+			key, val := item, ""
+			if j := strings.Index(item, ":"); j >= 0 {
+				key, val = item[:j], item[j+1:]
+			}
+			if i == 0 && val == "" {
+				switch key {
+				case "-":
+					return nil, false, ""
+				case "":
+				default:
+					c.name = key
+				}
+				continue
+			}
+			switch key {
+			case "table":
+				// The table name is declared on an embedded
+				// bun.BaseModel field, which is not a column.
+				return nil, false, ""
+			case "type":
+				c.sqlType = strings.ToLower(val)
+			case "pk":
+				c.primaryKey = true
+			case "notnull":
+				c.notNull = true
+			case "unique":
+				c.unique = true
+			case "default", "nullzero":
+				c.hasDefault = true
+			case "embed":
+				embedded, embeddedPrefix = true, val
+			}
+		}
+	}
+	return c, embedded, embeddedPrefix
+}
+
+func sqlTypeString(t string) string {
+	if t == "" {
+		return "the default"
+	}
+	return t
+}
+
+func sizeString(size int) string {
+	if size == 0 {
+		return "the default"
+	}
+	return strconv.Itoa(size)
+}
+
+// snakeCase returns the default column name for the
+// Go field name s, following the conventions used by
+// gorm and bun: for example "UserID" becomes "user_id".
+func snakeCase(s string) string {
+	rs := []rune(s)
+	var buf strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) || i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,147 @@
+package apicompat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Owners maps packages and types to their owners, such
+// as the teams responsible for them, so that the changes
+// in a report can be routed to the right people.
+type Owners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern string
+	owners  []string
+}
+
+// ParseOwners parses an owners file in a form similar
+// to a CODEOWNERS file. Each non-blank line that does
+// not start with # holds a pattern followed by one or more
+// owners, separated by white space. For example:
+//
+//	# The platform team owns everything under example.com/platform.
+//	example.com/platform/...	@platform
+//	example.com/platform/billing	@billing @alice
+//	example.com/platform/users#Address	@identity
+//
+// A pattern that contains # matches the type with that name;
+// otherwise it matches types in the package with that path.
+// A pattern ending in /... also matches any package below it,
+// and patterns may contain wildcards as for path.Match.
+// As with CODEOWNERS, when several lines match, the last one wins.
+func ParseOwners(data []byte) (*Owners, error) {
+	var o Owners
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: no owners for pattern %q", lineNum, fields[0])
+		}
+		pattern := strings.TrimSuffix(fields[0], "/...")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q", lineNum, fields[0])
+		}
+		o.rules = append(o.rules, ownerRule{
+			pattern: fields[0],
+			owners:  fields[1:],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Match returns the owners of the type or function with the
+// given name (for example "example.com/foo#Address"), or nil
+// if it has none.
+func (o *Owners) Match(name string) []string {
+	pkg, typeName := name, ""
+	if i := strings.Index(name, "#"); i >= 0 {
+		pkg, typeName = name[:i], name[i+1:]
+	}
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		r := o.rules[i]
+		if matchOwnerPattern(r.pattern, pkg, typeName) {
+			return r.owners
+		}
+	}
+	return nil
+}
+
+// matchOwnerPattern reports whether the given owners
+// pattern matches the type with the given package path
+// and name.
+func matchOwnerPattern(pattern, pkg, typeName string) bool {
+	if i := strings.Index(pattern, "#"); i >= 0 {
+		if typeName == "" {
+			return false
+		}
+		if ok, _ := path.Match(pattern[i+1:], typeName); !ok {
+			return false
+		}
+		pattern = pattern[:i]
+	}
+	if strings.HasSuffix(pattern, "/...") {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		// Match the prefix against pkg and each of its ancestors.
+		for p := pkg; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if ok, _ := path.Match(prefix, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+	ok, _ := path.Match(pattern, pkg)
+	return ok
+}
+
+// ChangesByOwner returns the same descriptions as Changes (or
+// ChangesWithSuggestions if suggest is true) grouped by owner,
+// with the key of each group holding the owner. A change with
+// several owners appears in the group for each of them; changes
+// with no owner are in a group with an empty key. The groups
+// are sorted by key.
+func (r *Report) ChangesByOwner(owners *Owners, suggest bool) []ChangeGroup {
+	var groups []ChangeGroup
+	index := make(map[string]int)
+	addTo := func(key, line string) {
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ChangeGroup{Key: key})
+		}
+		groups[i].Changes = append(groups[i].Changes, line)
+	}
+	for _, c := range r.changes(suggest) {
+		var who []string
+		if c.pkg != "" {
+			who = owners.Match(c.what)
+		}
+		if len(who) == 0 {
+			addTo("", c.line)
+			continue
+		}
+		for _, owner := range who {
+			addTo(owner, c.line)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
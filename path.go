@@ -0,0 +1,158 @@
+package apicompat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Path describes the location of a change within a type,
+// starting from the type being checked.
+type Path []PathElem
+
+// PathElem is an element of a Path.
+type PathElem struct {
+	Kind PathElemKind
+
+	// Name holds the name of the field or method
+	// for Field and Method elements.
+	Name string `json:",omitempty"`
+
+	// EncodedName holds the name of the field when
+	// encoded as JSON, for Field elements, if known.
+	EncodedName string `json:",omitempty"`
+
+	// Index holds the index of the parameter or
+	// result for Param and Result elements.
+	Index int `json:",omitempty"`
+}
+
+// PathElemKind represents the kind of a PathElem.
+type PathElemKind string
+
+const (
+	// PathField selects a struct field.
+	PathField PathElemKind = "field"
+
+	// PathMethod selects a method.
+	PathMethod PathElemKind = "method"
+
+	// PathElemType selects the element type of
+	// a slice, array or map.
+	PathElemType PathElemKind = "elem"
+
+	// PathKey selects the key type of a map.
+	PathKey PathElemKind = "key"
+
+	// PathDeref selects the element type of a pointer.
+	PathDeref PathElemKind = "deref"
+
+	// PathChanElem selects the element type of a channel.
+	PathChanElem PathElemKind = "chan"
+
+	// PathParam selects a function parameter.
+	PathParam PathElemKind = "param"
+
+	// PathResult selects a function result.
+	PathResult PathElemKind = "result"
+)
+
+// with returns a new path with e appended, leaving
+// p unchanged.
+func (p Path) with(e PathElem) Path {
+	return append(p[0:len(p):len(p)], e)
+}
+
+// String returns the path formatted with GoPath.
+func (p Path) String() string {
+	return GoPath(p)
+}
+
+// PathFormatter formats a path for display in
+// diagnostics.
+type PathFormatter func(Path) string
+
+// GoPath formats a path in the style of a Go expression,
+// for example "(*.Items[]).Name". This is the default format.
+func GoPath(p Path) string {
+	s := ""
+	for _, e := range p {
+		switch e.Kind {
+		case PathField, PathMethod:
+			s += "." + e.Name
+		case PathElemType:
+			s += "[]"
+		case PathKey:
+			s += "[key]"
+		case PathDeref:
+			s = "(*" + s + ")"
+		case PathChanElem:
+			s = "(<-" + s + ")"
+		case PathParam:
+			s += fmt.Sprintf("(param %d)", e.Index)
+		case PathResult:
+			s += fmt.Sprintf("(result %d)", e.Index)
+		}
+	}
+	return s
+}
+
+// JSONPointerPath formats a path in the style of
+// a JSON Pointer (RFC 6901), for example "/Items/*/Name".
+// Array and map elements are shown as "*" and
+// map keys as "{key}". Pointer indirections are omitted
+// because they are invisible in JSON. Fields are shown
+// by their encoded names when known.
+func JSONPointerPath(p Path) string {
+	var buf strings.Builder
+	for _, e := range p {
+		switch e.Kind {
+		case PathField:
+			name := e.Name
+			if e.EncodedName != "" {
+				name = e.EncodedName
+			}
+			buf.WriteString("/")
+			buf.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(name))
+		case PathMethod:
+			buf.WriteString("/" + e.Name + "()")
+		case PathElemType:
+			buf.WriteString("/*")
+		case PathKey:
+			buf.WriteString("/{key}")
+		case PathChanElem:
+			buf.WriteString("/<-")
+		case PathParam:
+			fmt.Fprintf(&buf, "/in/%d", e.Index)
+		case PathResult:
+			fmt.Fprintf(&buf, "/out/%d", e.Index)
+		}
+	}
+	return buf.String()
+}
+
+// DottedPath formats a path as a dot-separated list of
+// names, for example "Items[].Name". Pointer indirections
+// are omitted.
+func DottedPath(p Path) string {
+	var buf strings.Builder
+	for _, e := range p {
+		switch e.Kind {
+		case PathField, PathMethod:
+			if buf.Len() > 0 {
+				buf.WriteString(".")
+			}
+			buf.WriteString(e.Name)
+		case PathElemType:
+			buf.WriteString("[]")
+		case PathKey:
+			buf.WriteString("[key]")
+		case PathChanElem:
+			buf.WriteString("<-")
+		case PathParam:
+			fmt.Fprintf(&buf, "(%d)", e.Index)
+		case PathResult:
+			fmt.Fprintf(&buf, "->%d", e.Index)
+		}
+	}
+	return buf.String()
+}
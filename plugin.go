@@ -0,0 +1,200 @@
+package apicompat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// PluginChange is sent by CheckPlugin to a plugin for each named
+// type in the old or new API, as a line of JSON on the plugin's
+// standard input.
+type PluginChange struct {
+	// Name holds the name of the type.
+	Name jsontypes.TypeName
+
+	// Old and New hold the type in the old and new APIs.
+	// One of them is nil if the type has been added or removed.
+	// Named types that they refer to are not included; plugins
+	// that need them should read the snapshots themselves.
+	Old *jsontypes.Type `json:",omitempty"`
+	New *jsontypes.Type `json:",omitempty"`
+}
+
+// PluginFinding is written by a plugin to its standard output,
+// as a line of JSON, for each problem that it finds.
+type PluginFinding struct {
+	// Name holds the name of the type containing the problem.
+	Name jsontypes.TypeName
+
+	// Rule holds the ID of the plugin's rule that found the
+	// problem. If it's empty, "custom" is used.
+	Rule string `json:",omitempty"`
+
+	// Path holds the location of the problem within the type,
+	// in any form that the plugin chooses.
+	Path string `json:",omitempty"`
+
+	// Message describes the problem.
+	Message string
+}
+
+// CheckPlugin runs the given plugin command, streams a PluginChange
+// to it for each type in info0 or info1, and adds any problems that
+// it reports to r.Incompatible. This allows custom rules to be
+// implemented in any language without rebuilding apicompat.
+//
+// The plugin should read changes from its standard input until EOF
+// and write a PluginFinding for each problem to its standard output.
+// Anything it writes to its standard error is included in the
+// error returned if it fails.
+func CheckPlugin(r *Report, info0, info1 *jsontypes.Info, argv []string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("no plugin command")
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("cannot start plugin: %v", err)
+	}
+	// Write the changes concurrently with reading the
+	// findings so that the plugin can stream its results.
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- writePluginChanges(stdin, info0, info1)
+	}()
+	findings, readErr := readPluginFindings(stdout)
+	if readErr != nil {
+		// Drain the output so that the plugin doesn't block.
+		io.Copy(ioutil.Discard, stdout)
+	}
+	werr := <-writeErr
+	if err := cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s failed: %v: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("plugin %s failed: %v", argv[0], err)
+	}
+	if readErr != nil {
+		return fmt.Errorf("plugin %s: %v", argv[0], readErr)
+	}
+	if werr != nil {
+		return fmt.Errorf("cannot write to plugin %s: %v", argv[0], werr)
+	}
+	addPluginFindings(r, info0, findings)
+	return nil
+}
+
+// writePluginChanges writes a PluginChange for each
+// type in info0 or info1 to w, in name order, then closes w.
+func writePluginChanges(w io.WriteCloser, info0, info1 *jsontypes.Info) error {
+	defer w.Close()
+	names := sortedNames(info0)
+	for _, name := range sortedNames(info1) {
+		if info0.Types[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, name := range names {
+		if err := enc.Encode(PluginChange{
+			Name: name,
+			Old:  info0.Types[name],
+			New:  info1.Types[name],
+		}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// readPluginFindings reads a PluginFinding from each
+// line of r until EOF.
+func readPluginFindings(r io.Reader) ([]PluginFinding, error) {
+	var findings []PluginFinding
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var f PluginFinding
+		if err := json.Unmarshal(line, &f); err != nil {
+			return nil, fmt.Errorf("invalid finding %q: %v", line, err)
+		}
+		if f.Name == "" || f.Message == "" {
+			return nil, fmt.Errorf("finding %q has no name or message", line)
+		}
+		findings = append(findings, f)
+	}
+	return findings, scanner.Err()
+}
+
+// addPluginFindings adds the findings to r.Incompatible,
+// keeping the types in sorted order before the functions.
+func addPluginFindings(r *Report, info0 *jsontypes.Info, findings []PluginFinding) {
+	if len(findings) == 0 {
+		return
+	}
+	reports := make(map[jsontypes.TypeName]*TypeReport)
+	for _, tr := range r.Incompatible {
+		if info0.Funcs[tr.Name] == nil && info0.Vars[tr.Name] == nil && info0.Consts[tr.Name] == nil {
+			reports[tr.Name] = tr
+		}
+	}
+	for _, f := range findings {
+		tr := reports[f.Name]
+		if tr == nil {
+			tr = &TypeReport{
+				Name: f.Name,
+			}
+			reports[f.Name] = tr
+			r.Incompatible = append(r.Incompatible, tr)
+		}
+		rule := f.Rule
+		if rule == "" {
+			rule = ruleCustom
+		}
+		tr.Errors = append(tr.Errors, &Problem{
+			Rule:          rule,
+			Severity:      r.severity(rule),
+			Message:       f.Message,
+			formattedPath: f.Path,
+		})
+	}
+	isFunc := func(tr *TypeReport) bool {
+		return reports[tr.Name] != tr
+	}
+	sort.SliceStable(r.Incompatible, func(i, j int) bool {
+		tri, trj := r.Incompatible[i], r.Incompatible[j]
+		if fi, fj := isFunc(tri), isFunc(trj); fi != fj {
+			return fj
+		}
+		if isFunc(tri) {
+			return false
+		}
+		return tri.Name < trj.Name
+	})
+}
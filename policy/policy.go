@@ -0,0 +1,133 @@
+// Package policy provides check options that allow problems
+// found by package apicompat according to CEL expressions.
+// It's separate from package apicompat so that programs that
+// don't use policies don't depend on CEL.
+package policy
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/rogpeppe/apicompat"
+)
+
+// Policy holds a compiled CEL expression that decides whether
+// a problem found by the checker is acceptable. See Parse.
+type Policy struct {
+	expr string
+	prg  cel.Program
+}
+
+// policyEnv holds the CEL environment used for all policies.
+var policyEnv = mustPolicyEnv()
+
+func mustPolicyEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("rule", cel.StringType),
+		cel.Variable("severity", cel.StringType),
+		cel.Variable("path", cel.StringType),
+		cel.Variable("pkg", cel.StringType),
+		cel.Variable("typeName", cel.StringType),
+		cel.Variable("role", cel.StringType),
+		cel.Variable("stability", cel.StringType),
+		cel.Variable("message", cel.StringType),
+		cel.Variable("oldDecl", cel.StringType),
+		cel.Variable("newDecl", cel.StringType),
+		cel.Function("glob",
+			cel.MemberOverload("string_glob_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(func(s, pattern ref.Val) ref.Val {
+					ok, err := path.Match(string(pattern.(types.String)), string(s.(types.String)))
+					if err != nil {
+						return types.NewErr("%v", err)
+					}
+					return types.Bool(ok)
+				}),
+			),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+// Parse compiles the given CEL expression, which must
+// evaluate to a boolean. The expression can refer to these
+// string variables describing a problem:
+//
+//	rule       the ID of the rule that found the problem
+//	severity   the severity of the problem (see apicompat.Problem.Severity)
+//	path       the path of the problem as formatted by apicompat.GoPath
+//	pkg        the package path of the innermost named type being checked
+//	typeName   the name of that type, for example "example.com/foo#Address"
+//	role       the role of the type, if any
+//	stability  the stability of the item, if any
+//	message    the problem's message
+//	oldDecl    the old declaration, if any
+//	newDecl    the new declaration, if any
+//
+// In addition to the standard CEL functions, s.glob(pattern)
+// reports whether s matches the pattern as for path.Match.
+// For example, to allow fields to be removed only from types
+// in internal packages:
+//
+//	rule == "field-removed" && pkg.glob("*/internal/*")
+func Parse(expr string) (*Policy, error) {
+	ast, iss := policyEnv.Compile(expr)
+	if err := iss.Err(); err != nil {
+		return nil, fmt.Errorf("invalid policy %q: %v", expr, err)
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid policy %q: result is %v, not bool", expr, ast.OutputType())
+	}
+	prg, err := policyEnv.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %q: %v", expr, err)
+	}
+	return &Policy{
+		expr: expr,
+		prg:  prg,
+	}, nil
+}
+
+// String returns the policy's expression.
+func (p *Policy) String() string {
+	return p.expr
+}
+
+// AllowIf returns an option that causes any problem for which
+// the policy evaluates to true to be treated as compatible.
+// When several AllowIf options are provided, a problem is
+// allowed if any of the policies allow it.
+func AllowIf(p *Policy) apicompat.CheckOption {
+	return apicompat.AllowFunc(p.Allows)
+}
+
+// Allows reports whether the policy allows the problem prob,
+// found in the given context. A problem for which the policy
+// cannot be evaluated is not allowed.
+func (p *Policy) Allows(prob *apicompat.Problem, c *apicompat.ProblemContext) bool {
+	out, _, err := p.prg.Eval(map[string]interface{}{
+		"rule":      prob.Rule,
+		"severity":  string(prob.Severity),
+		"path":      apicompat.GoPath(prob.Path),
+		"pkg":       c.TypeName.PkgPath(),
+		"typeName":  string(c.TypeName),
+		"role":      string(c.Role),
+		"stability": string(c.Stability),
+		"message":   prob.Message,
+		"oldDecl":   prob.OldDecl,
+		"newDecl":   prob.NewDecl,
+	})
+	if err != nil {
+		return false
+	}
+	ok, _ := out.Value().(bool)
+	return ok
+}
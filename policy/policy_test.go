@@ -0,0 +1,64 @@
+package policy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rogpeppe/apicompat"
+	"github.com/rogpeppe/apicompat/jsontypes"
+	"github.com/rogpeppe/apicompat/policy"
+)
+
+var allowIfTests = []struct {
+	expr  string
+	allow bool
+}{
+	{expr: `rule == "field-removed"`, allow: true},
+	{expr: `rule == "field-added"`, allow: false},
+	{expr: `pkg.glob("example.com/*/internal") && typeName == "example.com/m/internal#T"`, allow: true},
+	{expr: `pkg.glob("example.com/*/public")`, allow: false},
+	{expr: `path == ".B" && message.contains("missing")`, allow: true},
+	{expr: `severity == "breaking"`, allow: true},
+	{expr: `oldDecl == "B int" && newDecl == ""`, allow: true},
+}
+
+func TestAllowIf(t *testing.T) {
+	info0 := jsontypes.NewInfo().Add(jsontypes.NewStruct("example.com/m/internal#T").Field("A", "int").Field("B", "int").Build())
+	info1 := jsontypes.NewInfo().Add(jsontypes.NewStruct("example.com/m/internal#T").Field("A", "int").Build())
+	for _, test := range allowIfTests {
+		p, err := policy.Parse(test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		if p.String() != test.expr {
+			t.Errorf("%s: got String %q", test.expr, p)
+		}
+		r := apicompat.CheckAll(info0, info1, policy.AllowIf(p))
+		if got := r.OK(); got != test.allow {
+			t.Errorf("%s: got OK %v want %v; changes %q", test.expr, got, test.allow, r.Changes())
+		}
+	}
+}
+
+var parseErrorTests = []struct {
+	expr string
+	want string
+}{
+	{expr: `rule ==`, want: `invalid policy "rule =="`},
+	{expr: `rule`, want: `invalid policy "rule": result is string, not bool`},
+	{expr: `unknown == "x"`, want: `undeclared reference to 'unknown'`},
+}
+
+func TestParseError(t *testing.T) {
+	for _, test := range parseErrorTests {
+		_, err := policy.Parse(test.expr)
+		if err == nil {
+			t.Errorf("%s: unexpected success", test.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %q, want it to contain %q", test.expr, err, test.want)
+		}
+	}
+}
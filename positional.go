@@ -0,0 +1,140 @@
+package apicompat
+
+import (
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// PositionalProfile returns an option that checks struct types
+// that are encoded positionally, as arrays of field values
+// rather than as maps keyed by field name (for example
+// msgpack with the as_array option), as well as checking them
+// as usual. A struct is treated as positional when isPositional
+// returns true for its old definition; if isPositional is nil,
+// all structs are treated as positional.
+//
+// In a positional struct, each field must stay at the same
+// position and new fields may only be appended at the end.
+// Positions are taken from the order of the fields in the
+// struct (see jsontypes.Field.Index).
+func PositionalProfile(isPositional func(info *jsontypes.Info, t *jsontypes.Type) bool) CheckOption {
+	if isPositional == nil {
+		isPositional = func(*jsontypes.Info, *jsontypes.Type) bool {
+			return true
+		}
+	}
+	return func(o *checkOptions) {
+		o.positional = isPositional
+		for _, id := range positionalRules {
+			o.setRule(id, true)
+		}
+	}
+}
+
+// FieldOrderProfile returns an option that reports changes to
+// the relative order of the fields in all struct types, which
+// matters to encodings that depend on the order in which fields
+// are declared even when they are not strictly positional.
+// Fields may still be added and removed. Structs that are
+// treated as positional by PositionalProfile are not checked
+// again, as any reordering is reported as a moved field.
+func FieldOrderProfile() CheckOption {
+	return func(o *checkOptions) {
+		o.setRule(ruleFieldReordered, true)
+	}
+}
+
+// checkFieldOrder checks that the fields that are in both
+// t0 and t1 are in the same relative order. When they are not,
+// the fields reported as moved are those not in the longest
+// sequence of fields that remain in order.
+func (ctxt *checkContext) checkFieldOrder(t0, t1 *jsontypes.Type, path Path) {
+	if !ctxt.enabled(ruleFieldReordered, ctxt.role, ctxt.stability) {
+		return
+	}
+	// common holds the fields in both structs, in their
+	// new order, and pos0 holds their old positions.
+	var common []*jsontypes.Field
+	var pos0 []int
+	for _, f1 := range fieldsByPosition(t1) {
+		for i, f0 := range fieldsByPosition(t0) {
+			if ctxt.nameKey(f0.Name) == ctxt.nameKey(f1.Name) {
+				common = append(common, f1)
+				pos0 = append(pos0, i)
+				break
+			}
+		}
+	}
+	inOrder := longestIncreasing(pos0)
+	for i, f1 := range common {
+		if inOrder[i] {
+			continue
+		}
+		path := path.with(PathElem{Kind: PathField, Name: f1.Name, EncodedName: f1.EncodedName})
+		if i == 0 {
+			ctxt.errorf(ruleFieldReordered, path, "field has moved to before %s", common[1].Name)
+		} else {
+			ctxt.errorf(ruleFieldReordered, path, "field has moved to after %s", common[i-1].Name)
+		}
+	}
+}
+
+// longestIncreasing reports which elements of xs
+// are members of a longest strictly increasing subsequence.
+func longestIncreasing(xs []int) []bool {
+	// length[i] holds the length of the longest increasing
+	// subsequence ending at i, and prev[i] holds the index
+	// of the element before i in it, or -1.
+	length := make([]int, len(xs))
+	prev := make([]int, len(xs))
+	end := -1
+	for i := range xs {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if xs[j] < xs[i] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if end == -1 || length[i] > length[end] {
+			end = i
+		}
+	}
+	member := make([]bool, len(xs))
+	for i := end; i >= 0; i = prev[i] {
+		member[i] = true
+	}
+	return member
+}
+
+// checkPositions checks that the fields of t0 are
+// at the same positions in t1, and that any new fields
+// in t1 come after them.
+func (ctxt *checkContext) checkPositions(t0, t1 *jsontypes.Type, path Path) {
+	fields0 := fieldsByPosition(t0)
+	fields1 := fieldsByPosition(t1)
+	pos0 := make(map[string]int)
+	for i, f := range fields0 {
+		pos0[ctxt.nameKey(f.Name)] = i
+	}
+	for i, f1 := range fields1 {
+		path := path.with(PathElem{Kind: PathField, Name: f1.Name})
+		i0, ok := pos0[ctxt.nameKey(f1.Name)]
+		switch {
+		case !ok && i < len(fields0):
+			ctxt.errorf(ruleFieldInserted, path, "field has been inserted at position %d (want at least %d)", i, len(fields0))
+		case ok && i0 != i:
+			ctxt.errorf(ruleFieldMoved, path, "field has moved from position %d to %d", i0, i)
+		}
+	}
+}
+
+// fieldsByPosition returns the fields of t
+// in the order of their index in the struct.
+func fieldsByPosition(t *jsontypes.Type) []*jsontypes.Field {
+	fields := append([]*jsontypes.Field(nil), t.Fields...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Index < fields[j].Index
+	})
+	return fields
+}
@@ -0,0 +1,58 @@
+package apicompat
+
+import "github.com/rogpeppe/apicompat/jsontypes"
+
+// Problem describes an incompatibility found by Check.
+// The Errors in a CheckError returned by Check
+// are all of type *Problem.
+type Problem struct {
+	// Rule holds the ID of the rule that found the problem.
+	// See Rules for details of all the rules.
+	Rule string
+
+	// Severity holds the severity of the rule
+	// (see RuleInfo.Severity).
+	Severity Severity
+
+	// Path holds the location of the problem within
+	// the type being checked.
+	Path Path
+
+	// Message describes the problem.
+	Message string
+
+	// OldDecl and NewDecl hold Go-like renderings of the
+	// old and new declarations (for example a struct field or
+	// method) that contain the problem, if known. When an item
+	// has been removed or added, one of them will be empty.
+	OldDecl string `json:",omitempty"`
+	NewDecl string `json:",omitempty"`
+
+	// Suggestion holds a suggested remediation for the
+	// problem, if there is one.
+	Suggestion string `json:",omitempty"`
+
+	// Old and New hold the innermost old and new types
+	// being checked when the problem was found, if known.
+	// For a problem with a struct field, for example,
+	// they hold the struct types. Either may be nil.
+	Old *jsontypes.Type `json:"-"`
+	New *jsontypes.Type `json:"-"`
+
+	// formattedPath holds the path as formatted by the
+	// path formatter in use when the problem was found.
+	formattedPath string
+}
+
+// Error implements the error interface. It returns
+// the path, if any, followed by the message.
+func (p *Problem) Error() string {
+	path := p.formattedPath
+	if path == "" {
+		path = GoPath(p.Path)
+	}
+	if path == "" {
+		return p.Message
+	}
+	return path + ": " + p.Message
+}
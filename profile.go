@@ -0,0 +1,173 @@
+package apicompat
+
+import (
+	"fmt"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// compatProfile describes a named compatibility profile,
+// which selects the rules that matter to one way of
+// using the types being checked.
+type compatProfile struct {
+	name string
+
+	// disabled holds the rules for changes that are
+	// compatible under the profile.
+	disabled [][]string
+
+	// enabled holds the optional rules for changes
+	// that matter under the profile.
+	enabled []string
+
+	// lenientNumbers holds whether numeric types
+	// may be widened, as for LenientNumbers.
+	lenientNumbers bool
+
+	// encodedOnly holds whether struct fields that are
+	// not encoded as JSON are ignored.
+	encodedOnly bool
+
+	// noRoles holds whether the roles of types are ignored
+	// when deciding which rules apply.
+	noRoles bool
+}
+
+// goSourceRules holds the rules for changes that matter
+// only to Go code using the types, such as changes to the
+// signatures of functions and methods, which are never
+// encoded.
+var goSourceRules = []string{
+	ruleParamCount,
+	ruleResultCount,
+	ruleVariadicChanged,
+	ruleReceiverChanged,
+	ruleReceiverToValue,
+	ruleBecameSealed,
+	ruleBecameUnsealed,
+	ruleTypeSetNarrowed,
+	ruleTypeParamCount,
+	ruleConstraintNarrowed,
+	ruleFuncRemoved,
+	ruleVarRemoved,
+	ruleConstRemoved,
+	ruleVarTypeChanged,
+	ruleConstTypeChanged,
+	ruleConstValueChanged,
+	ruleBecameConditional,
+}
+
+// jsonRules holds the rules for changes that matter
+// only to the JSON encoding of the types.
+var jsonRules = []string{
+	ruleTagChanged,
+	ruleTagNameChanged,
+	ruleOmitEmptyAdded,
+	ruleDefaultChanged,
+	ruleRequiredAdded,
+	ruleMapKeyEncoding,
+	ruleJSUnsafeInteger,
+}
+
+var compatProfiles = []*compatProfile{{
+	// json-wire checks only that values of the types are
+	// encoded compatibly as JSON.
+	name:        "json-wire",
+	disabled:    [][]string{goSourceRules},
+	encodedOnly: true,
+}, {
+	// gob checks that values of the types are encoded
+	// compatibly by encoding/gob, which ignores struct tags,
+	// flattens pointers and converts between integer types
+	// and between floating point types.
+	name:           "gob",
+	disabled:       [][]string{goSourceRules, jsonRules, {ruleBecameNullable, ruleBecameNonNull}},
+	lenientNumbers: true,
+}, {
+	// go-source checks that Go code using the types still
+	// compiles and behaves the same. How the types are
+	// encoded doesn't matter, so neither do their roles.
+	name:     "go-source",
+	disabled: [][]string{jsonRules},
+	enabled:  []string{ruleReceiverToValue, ruleComparabilityLost, ruleInterfaceWidened},
+	noRoles:  true,
+}, {
+	// grpc checks that protocol buffer messages generated
+	// for gRPC services are compatible on the wire. Field
+	// numbers are recorded in protobuf struct tags, and
+	// optional scalar fields are pointers. The generated
+	// methods, such as getters, are not checked.
+	name:     "grpc",
+	disabled: [][]string{goSourceRules, jsonRules, {ruleMethodRemoved, ruleBecameNullable, ruleBecameNonNull}},
+	enabled:  []string{ruleTagChanged},
+}}
+
+// ProfileNames returns the names of the profiles
+// accepted by Profile.
+func ProfileNames() []string {
+	names := make([]string, len(compatProfiles))
+	for i, p := range compatProfiles {
+		names[i] = p.name
+	}
+	return names
+}
+
+// Profile returns an option that selects the rules suited
+// to the named compatibility profile, which describes how the
+// types being checked are used:
+//
+//	json-wire  values are encoded as JSON; changes that
+//	           matter only to Go code, such as to method
+//	           receivers and function signatures, and changes
+//	           to fields that are not encoded are ignored
+//	gob        values are encoded with encoding/gob
+//	go-source  the types are used by Go code, which must
+//	           still compile; changes to struct tags and the
+//	           roles of types are ignored, and changes that
+//	           make types incomparable or add methods to
+//	           interfaces are reported
+//	grpc       the types are protocol buffer messages sent
+//	           by gRPC services
+//
+// The go-source profile is most useful when the APIs being
+// checked include all the methods of their types rather than
+// only those that affect marshaling (see PruneMethods), and
+// types with custom marshalers are not ignored.
+//
+// Without a profile, all the rules apply. Rules explicitly
+// enabled or disabled by options that follow the profile
+// take precedence over it.
+func Profile(name string) (CheckOption, error) {
+	for _, p := range compatProfiles {
+		if p.name == name {
+			return p.option, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown profile %q", name)
+}
+
+func (p *compatProfile) option(o *checkOptions) {
+	for _, ids := range p.disabled {
+		for _, id := range ids {
+			o.setRule(id, false)
+		}
+	}
+	for _, id := range p.enabled {
+		o.setRule(id, true)
+	}
+	if p.lenientNumbers {
+		o.lenientNumbers = true
+	}
+	if p.encodedOnly {
+		o.encodedOnly = true
+	}
+	if p.noRoles {
+		o.noRoles = true
+	}
+}
+
+// unencoded reports whether the field f should be
+// ignored because it's not encoded as JSON.
+func (o *checkOptions) unencoded(f *jsontypes.Field) bool {
+	return o.encodedOnly && !f.Anonymous && jsontypes.EncodedName(f.Name, f.Tag, "json", false) == ""
+}
@@ -0,0 +1,157 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Reduce returns copies of info0 and info1 reduced to a small set
+// of types for which the report produced by CheckAll with the given
+// options is still interesting, as determined by the interesting
+// function. This is useful for producing small self-contained
+// reproductions of problems for bug reports and for debugging rules.
+//
+// Only the types are reduced: the results hold no functions,
+// facades, services or routes. The types are first reduced to those
+// reachable from as few as possible of the removed and incompatible
+// types; then the fields and methods of the remaining types are
+// removed one at a time where that leaves the report interesting.
+// The result is minimal only in the sense that no single further
+// step of either kind keeps it interesting.
+//
+// Reduce returns an error if the report for info0 and info1
+// themselves is not interesting.
+func Reduce(info0, info1 *jsontypes.Info, interesting func(r *Report) bool, opts ...CheckOption) (*jsontypes.Info, *jsontypes.Info, error) {
+	test := func(info0, info1 *jsontypes.Info) bool {
+		return interesting(CheckAll(info0, info1, opts...))
+	}
+	r := CheckAll(info0, info1, opts...)
+	if !interesting(r) {
+		return nil, nil, fmt.Errorf("report is not interesting to start with")
+	}
+	var candidates []jsontypes.TypeName
+	candidates = append(candidates, r.Removed...)
+	for _, tr := range r.Incompatible {
+		if info0.Types[tr.Name] != nil {
+			candidates = append(candidates, tr.Name)
+		}
+	}
+	// Try the candidates that pull in the fewest types first.
+	size := make(map[jsontypes.TypeName]int)
+	for _, name := range candidates {
+		reached, _ := info0.Reachable(name)
+		size[name] = len(reached)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return size[candidates[i]] < size[candidates[j]]
+	})
+	var roots []jsontypes.TypeName
+	found := false
+	for _, name := range candidates {
+		roots = append(roots, name)
+		if test(reduceTo(info0, roots), reduceTo(info1, roots)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("cannot reproduce the report with types alone")
+	}
+	for i := len(roots) - 1; i >= 0 && len(roots) > 1; i-- {
+		fewer := append(append([]jsontypes.TypeName(nil), roots[:i]...), roots[i+1:]...)
+		if test(reduceTo(info0, fewer), reduceTo(info1, fewer)) {
+			roots = fewer
+		}
+	}
+	reduced0, reduced1 := reduceTo(info0, roots), reduceTo(info1, roots)
+	for _, name := range sortedNames(reduced0) {
+		t0, t1 := reduced0.Types[name], reduced1.Types[name]
+		reduceMembers(t0, t1, func() bool {
+			// Prune so that types no longer reachable
+			// from the roots don't count.
+			return test(reduceTo(reduced0, roots), reduceTo(reduced1, roots))
+		})
+	}
+	// Removing members may have made some types unreachable.
+	return reduceTo(reduced0, roots), reduceTo(reduced1, roots), nil
+}
+
+// reduceMembers removes each field and method of t0 in turn,
+// along with the member of the same name in t1, which may be nil,
+// keeping the removal when test still returns true.
+func reduceMembers(t0, t1 *jsontypes.Type, test func() bool) {
+	for i := len(t0.Fields) - 1; i >= 0; i-- {
+		f0 := t0.Fields[i]
+		fields0 := t0.Fields
+		t0.Fields = append(append([]*jsontypes.Field(nil), fields0[:i]...), fields0[i+1:]...)
+		var fields1 []*jsontypes.Field
+		if t1 != nil {
+			fields1 = t1.Fields
+			t1.Fields = nil
+			for _, f1 := range fields1 {
+				if f1.Name != f0.Name {
+					t1.Fields = append(t1.Fields, f1)
+				}
+			}
+		}
+		if !test() {
+			t0.Fields = fields0
+			if t1 != nil {
+				t1.Fields = fields1
+			}
+		}
+	}
+	for _, name := range sortedMethodNames(t0) {
+		m0 := t0.Methods[name]
+		delete(t0.Methods, name)
+		var m1 *jsontypes.Method
+		if t1 != nil {
+			m1 = t1.Methods[name]
+			delete(t1.Methods, name)
+		}
+		if !test() {
+			t0.Methods[name] = m0
+			if m1 != nil {
+				t1.Methods[name] = m1
+			}
+		}
+	}
+}
+
+// reduceTo returns a copy of info holding only the types reachable
+// from those of the given roots that are present in info. Each named
+// type is copied so that its fields and methods can be changed
+// without affecting info.
+func reduceTo(info *jsontypes.Info, roots []jsontypes.TypeName) *jsontypes.Info {
+	var present []jsontypes.TypeName
+	for _, name := range roots {
+		if info.Types[name] != nil {
+			present = append(present, name)
+		}
+	}
+	// The error can be ignored because all the roots are present.
+	pruned, _ := info.Prune(present...)
+	for name, t := range pruned.Types {
+		t1 := *t
+		t1.Fields = append([]*jsontypes.Field(nil), t.Fields...)
+		if t.Methods != nil {
+			t1.Methods = make(map[string]*jsontypes.Method)
+			for name, m := range t.Methods {
+				t1.Methods[name] = m
+			}
+		}
+		pruned.Types[name] = &t1
+	}
+	return pruned
+}
+
+func sortedMethodNames(t *jsontypes.Type) []string {
+	names := make([]string, 0, len(t.Methods))
+	for name := range t.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
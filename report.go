@@ -0,0 +1,600 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Report holds the results of comparing two complete
+// sets of types with CheckAll.
+type Report struct {
+	// Old and New hold the metadata from the old
+	// and new APIs, if known.
+	Old, New *jsontypes.Meta
+
+	// Warnings holds descriptions of any inconsistencies
+	// between the old and new metadata that suggest that
+	// the snapshots are stale or mismatched.
+	// See jsontypes.Meta.Mismatches.
+	Warnings []string `json:",omitempty"`
+
+	// Removed holds the names of all the types in the old
+	// API that are not in the new API, in sorted order.
+	// Types that could be removed without breaking the
+	// compatibility rules (for example because they
+	// were experimental) are not included.
+	Removed []jsontypes.TypeName
+
+	// Added holds the names of all the types in the new
+	// API that are not in the old API, in sorted order.
+	Added []jsontypes.TypeName
+
+	// RemovedFuncs holds the names of all the package-level
+	// functions in the old API that are not in the new API,
+	// in sorted order, subject to the same exceptions as Removed.
+	RemovedFuncs []jsontypes.TypeName `json:",omitempty"`
+
+	// RemovedVars and RemovedConsts similarly hold the
+	// names of the package-level variables and constants
+	// that have been removed.
+	RemovedVars   []jsontypes.TypeName `json:",omitempty"`
+	RemovedConsts []jsontypes.TypeName `json:",omitempty"`
+
+	// AddedFuncs, AddedVars and AddedConsts hold the names of
+	// the package-level functions, variables and constants in
+	// the new API that are not in the old API, in sorted order.
+	AddedFuncs  []jsontypes.TypeName `json:",omitempty"`
+	AddedVars   []jsontypes.TypeName `json:",omitempty"`
+	AddedConsts []jsontypes.TypeName `json:",omitempty"`
+
+	// Incompatible holds an entry for each type that is
+	// present in both APIs but has changed incompatibly,
+	// sorted by type name, followed by an entry for
+	// each function that has changed incompatibly,
+	// sorted by function name, and then similarly for
+	// variables and constants whose types or values
+	// have changed.
+	Incompatible []*TypeReport
+
+	// Additions holds an entry for each type present in
+	// both APIs that has had fields or methods added,
+	// sorted by type name, when ReportAdditions is used.
+	// All its problems have Additive severity.
+	Additions []*TypeReport `json:",omitempty"`
+
+	// Facades holds an entry for each version of an RPC
+	// facade in the old API that has been removed or has
+	// changed incompatibly, sorted by name and version.
+	Facades []*FacadeReport `json:",omitempty"`
+
+	// Services holds an entry for each gRPC service in the
+	// old API that has been removed or has changed
+	// incompatibly, sorted by name.
+	Services []*ServiceReport `json:",omitempty"`
+
+	// Routes holds an entry for each HTTP route in the
+	// old API that has been removed or has changed
+	// incompatibly, sorted by path and method.
+	Routes []*RouteReport `json:",omitempty"`
+
+	// Impact maps the name of each removed or incompatible
+	// type to the root types in the old API that refer to it,
+	// directly or indirectly, in sorted order, so that the extent
+	// of each change can be seen. The root types are those
+	// passed to Roots or, if there are none, the types that
+	// are not referred to by any other type. Types that
+	// affect no root type other than themselves are omitted.
+	Impact map[jsontypes.TypeName][]jsontypes.TypeName `json:",omitempty"`
+
+	// severities holds any severities set with SetSeverity.
+	severities map[string]Severity
+}
+
+// severity returns the severity of changes found by
+// the rule with the given ID.
+func (r *Report) severity(id string) Severity {
+	if s, ok := r.severities[id]; ok {
+		return s
+	}
+	return ruleSeverity(id)
+}
+
+// TypeReport holds the incompatibilities found in a type
+// or package-level function.
+type TypeReport struct {
+	Name jsontypes.TypeName
+
+	// NewName holds the name of the type in the new API
+	// if that's different from Name, for example because
+	// it comes from a different version of its module.
+	NewName jsontypes.TypeName `json:",omitempty"`
+
+	Errors []error
+}
+
+// OK reports whether the new API is backwardly
+// compatible with the old one: that is, whether the report
+// holds no breaking changes, as counted by CountBySeverity.
+// Changes found by rules with Warning severity are allowed.
+func (r *Report) OK() bool {
+	return r.CountAtLeast(Breaking) == 0
+}
+
+// CountBySeverity returns the number of changes in the report
+// of each severity. Each change is counted according to the
+// severity of the rule that found it (see LookupRule and
+// SetSeverity), or as breaking if the rule is unknown. Added
+// types, functions, variables and constants, and any fields
+// and methods in Additions, are counted as additive.
+func (r *Report) CountBySeverity() map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, c := range r.changes(false) {
+		counts[c.severity]++
+	}
+	counts[Additive] += len(r.Added) + len(r.AddedFuncs) + len(r.AddedVars) + len(r.AddedConsts)
+	for _, tr := range r.Additions {
+		counts[Additive] += len(tr.Errors)
+	}
+	return counts
+}
+
+// CountAtLeast returns the number of changes in the report
+// that are at least as serious as min, as counted by
+// CountBySeverity. For example, r.CountAtLeast(Warning)
+// counts the breaking changes and the warnings.
+func (r *Report) CountAtLeast(min Severity) int {
+	n := 0
+	for severity, count := range r.CountBySeverity() {
+		if severity.AtLeast(min) {
+			n += count
+		}
+	}
+	return n
+}
+
+// Changes returns a description of each change in the report,
+// in a form suitable for showing to users. When the version of the
+// old API is known, each description mentions it, so that the
+// descriptions are self-describing when taken out of context.
+// When a change concerns a particular declaration, the
+// description includes the old and new declarations on
+// subsequent indented lines.
+func (r *Report) Changes() []string {
+	return changeLines(r.changes(false))
+}
+
+// ChangesWithSuggestions is like Changes except that
+// each description also includes any suggested remediation
+// (see Problem.Suggestion) on a subsequent indented line.
+func (r *Report) ChangesWithSuggestions() []string {
+	return changeLines(r.changes(true))
+}
+
+// GroupBy specifies how GroupedChanges groups
+// the changes in a report.
+type GroupBy string
+
+const (
+	// GroupByPackage groups changes by the package
+	// path of the type or function that changed.
+	GroupByPackage GroupBy = "package"
+
+	// GroupByRule groups changes by the ID of
+	// the rule that found them.
+	GroupByRule GroupBy = "rule"
+
+	// GroupByType groups changes by the type or
+	// function that changed.
+	GroupByType GroupBy = "type"
+)
+
+// ChangeGroup holds the descriptions of a set
+// of related changes.
+type ChangeGroup struct {
+	// Key holds the package path, rule ID or type
+	// name that the changes have in common. It is empty
+	// for changes that have no such value, for example
+	// changes to HTTP routes when grouping by package.
+	Key string
+
+	// Changes holds the descriptions of the changes
+	// as returned by Changes.
+	Changes []string
+}
+
+// GroupedChanges returns the same descriptions as Changes (or
+// ChangesWithSuggestions if suggest is true), grouped as
+// specified by by, so that related changes are adjacent.
+// The groups are sorted by key; within each group, changes
+// are in the same order as returned by Changes.
+func (r *Report) GroupedChanges(by GroupBy, suggest bool) ([]ChangeGroup, error) {
+	var keyOf func(c change) string
+	switch by {
+	case GroupByPackage:
+		keyOf = func(c change) string { return c.pkg }
+	case GroupByRule:
+		keyOf = func(c change) string { return c.rule }
+	case GroupByType:
+		keyOf = func(c change) string { return c.what }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q", by)
+	}
+	var groups []ChangeGroup
+	index := make(map[string]int)
+	for _, c := range r.changes(suggest) {
+		key := keyOf(c)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ChangeGroup{Key: key})
+		}
+		groups[i].Changes = append(groups[i].Changes, c.line)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// change describes a single change in a report.
+type change struct {
+	// what holds the name of the item that changed.
+	what string
+	// pkg holds its package path, if any.
+	pkg string
+	// rule holds the ID of the rule that found the change.
+	rule string
+	// severity holds the severity of the change.
+	severity Severity
+	// line holds the description of the change.
+	line string
+}
+
+func changeLines(changes []change) []string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.line
+	}
+	return lines
+}
+
+func (r *Report) changes(suggest bool) []change {
+	var since string
+	if s := r.Old.String(); s != "" {
+		since = " since " + s
+	}
+	var changes []change
+	for _, name := range r.Removed {
+		changes = append(changes, change{
+			what:     string(name),
+			pkg:      name.PkgPath(),
+			rule:     ruleTypeRemoved,
+			severity: r.severity(ruleTypeRemoved),
+			line:     fmt.Sprintf("type %s has gone away%s", name, since),
+		})
+	}
+	for _, name := range r.RemovedFuncs {
+		changes = append(changes, change{
+			what:     string(name),
+			pkg:      name.PkgPath(),
+			rule:     ruleFuncRemoved,
+			severity: r.severity(ruleFuncRemoved),
+			line:     fmt.Sprintf("function %s has gone away%s", name, since),
+		})
+	}
+	for _, name := range r.RemovedVars {
+		changes = append(changes, change{
+			what:     string(name),
+			pkg:      name.PkgPath(),
+			rule:     ruleVarRemoved,
+			severity: r.severity(ruleVarRemoved),
+			line:     fmt.Sprintf("variable %s has gone away%s", name, since),
+		})
+	}
+	for _, name := range r.RemovedConsts {
+		changes = append(changes, change{
+			what:     string(name),
+			pkg:      name.PkgPath(),
+			rule:     ruleConstRemoved,
+			severity: r.severity(ruleConstRemoved),
+			line:     fmt.Sprintf("constant %s has gone away%s", name, since),
+		})
+	}
+	add := func(what, pkg string, errs []error) {
+		for _, err := range errs {
+			c := change{
+				what:     what,
+				pkg:      pkg,
+				line:     changeLine(what, err, since, suggest),
+				severity: Breaking,
+			}
+			if p, ok := err.(*Problem); ok {
+				c.rule = p.Rule
+				c.severity = p.Severity
+				if c.severity == "" {
+					c.severity = r.severity(p.Rule)
+				}
+			}
+			changes = append(changes, c)
+		}
+	}
+	for _, tr := range r.Incompatible {
+		add(string(tr.Name), tr.Name.PkgPath(), tr.Errors)
+	}
+	for _, fr := range r.Facades {
+		add(fmt.Sprintf("facade %s v%d", fr.Name, fr.Version), "", fr.Errors)
+	}
+	for _, sr := range r.Services {
+		add("service "+sr.Name, "", sr.Errors)
+	}
+	for _, rr := range r.Routes {
+		add(rr.Method+" "+rr.Path, "", rr.Errors)
+	}
+	return changes
+}
+
+// changeLine returns a line describing the given error
+// found in the named item, including any suggestion
+// if suggest is true.
+func changeLine(what string, err error, since string, suggest bool) string {
+	line := fmt.Sprintf("%s incompatible: %v", what, err)
+	if since != "" {
+		line += fmt.Sprintf(" (changed%s)", since)
+	}
+	if p, ok := err.(*Problem); ok {
+		line += formatDecls(p.OldDecl, p.NewDecl)
+		if suggest && p.Suggestion != "" {
+			line += "\n\tsuggestion: " + p.Suggestion
+		}
+	}
+	return line
+}
+
+// formatDecls returns the given old and new declarations
+// formatted as indented lines.
+func formatDecls(decl0, decl1 string) string {
+	if decl0 == "" && decl1 == "" {
+		return ""
+	}
+	if decl0 == "" {
+		decl0 = "(none)"
+	}
+	if decl1 == "" {
+		decl1 = "(none)"
+	}
+	return "\n\told: " + decl0 + "\n\tnew: " + decl1
+}
+
+// CheckAll checks that all the types in info1 are backwardly
+// compatible with the types of the same name in info0, and that
+// no types have been removed. Any RPC facades, gRPC services
+// and HTTP routes are checked similarly (see CheckFacade,
+// CheckService and CheckRoute).
+// A nil Info is treated as empty, so, for example, all the
+// types in info1 are reported as added when info0 is nil.
+// The options are as for Check.
+func CheckAll(info0, info1 *jsontypes.Info, opts ...CheckOption) *Report {
+	if info0 == nil {
+		info0 = jsontypes.NewInfo()
+	}
+	if info1 == nil {
+		info1 = jsontypes.NewInfo()
+	}
+	o := newCheckOptions(opts)
+	r := &Report{
+		Old:        info0.Meta,
+		New:        info1.Meta,
+		Warnings:   info0.Meta.Mismatches(info1.Meta),
+		severities: o.severities,
+	}
+	progress := jsontypes.NewProgressReporter(o.progress, "check", len(info0.Types))
+	m := newNameMatcher(info1, &o)
+	reachable0, reachable1 := o.reachable(info0), o.reachable(info1)
+	for _, name := range sortedNames(info0) {
+		progress.Report(string(name))
+		if reachable0 != nil && !reachable0[name] {
+			continue
+		}
+		t0 := info0.Types[name]
+		if o.introducedLater(t0.Doc) {
+			continue
+		}
+		name1 := m.match(name)
+		if name1 == "" {
+			if !o.removalAllowed(t0) && !o.ignoresName(name) {
+				r.Removed = append(r.Removed, name)
+			}
+			continue
+		}
+		t1 := info1.Types[name1]
+		ctxt := newCheckContext(o, info0, info1)
+		ctxt.check(t0, t1, nil)
+		if len(ctxt.errors) > 0 {
+			r.Incompatible = append(r.Incompatible, newTypeReport(name, name1, ctxt.errors))
+		}
+		if len(ctxt.added) > 0 {
+			r.Additions = append(r.Additions, newTypeReport(name, name1, ctxt.added))
+		}
+	}
+	for _, name := range sortedNames(info1) {
+		if !m.matched[name] && (reachable1 == nil || reachable1[name]) && !o.ignoresName(name) && !info1.Types[name].Ignored {
+			r.Added = append(r.Added, name)
+		}
+	}
+	changed := append([]jsontypes.TypeName(nil), r.Removed...)
+	for _, tr := range r.Incompatible {
+		changed = append(changed, tr.Name)
+	}
+	r.Impact = impact(info0, o.roots, changed)
+	o.checkFuncs(r, info0, info1, opts)
+	o.checkValues(r, info0, info1)
+	r.AddedFuncs = o.addedNames(info0.Funcs, info1.Funcs)
+	r.AddedVars = o.addedNames(info0.Vars, info1.Vars)
+	r.AddedConsts = o.addedNames(info0.Consts, info1.Consts)
+	o.checkFacades(r, info0, info1, opts)
+	o.checkServices(r, info0, info1, opts)
+	o.checkRoutes(r, info0, info1, opts)
+	return r
+}
+
+func newTypeReport(name, newName jsontypes.TypeName, errs []error) *TypeReport {
+	tr := &TypeReport{
+		Name:   name,
+		Errors: errs,
+	}
+	if newName != name {
+		tr.NewName = newName
+	}
+	return tr
+}
+
+// nameMatcher finds the types in a new API that
+// correspond to types in an old API.
+type nameMatcher struct {
+	info *jsontypes.Info
+	// versions maps from type names with all versions removed
+	// to the names of all the types in info with that name.
+	versions map[jsontypes.TypeName][]jsontypes.TypeName
+	// matched holds all the names that have been matched.
+	matched map[jsontypes.TypeName]bool
+	// renamed returns the name expected for an old
+	// type in the new API.
+	renamed func(jsontypes.TypeName) jsontypes.TypeName
+	// normalize, if non-nil, returns the normalized form
+	// of a type name (see MatchNames), and normalized maps
+	// from normalized names to the names of the types in
+	// info with that normalized name.
+	normalize  func(jsontypes.TypeName) jsontypes.TypeName
+	normalized map[jsontypes.TypeName][]jsontypes.TypeName
+}
+
+func newNameMatcher(info *jsontypes.Info, o *checkOptions) *nameMatcher {
+	m := &nameMatcher{
+		info:     info,
+		renamed:  o.renamed,
+		versions: make(map[jsontypes.TypeName][]jsontypes.TypeName),
+		matched:  make(map[jsontypes.TypeName]bool),
+	}
+	for name := range info.Types {
+		key := name.WithoutVersions()
+		m.versions[key] = append(m.versions[key], name)
+	}
+	if o.normalizeName != nil {
+		m.normalize = o.normalizeTypeName
+		m.normalized = make(map[jsontypes.TypeName][]jsontypes.TypeName)
+		for name := range info.Types {
+			key := m.normalize(name)
+			m.normalized[key] = append(m.normalized[key], name)
+		}
+	}
+	return m
+}
+
+// match returns the name of the type in the new API that
+// corresponds to the type with the given name in the old API, or
+// the empty string if there is none. A type with exactly the same
+// name is preferred, then one with the name expected after any
+// declared package renames; otherwise, if the new API holds the
+// type at one or more different module versions, the latest is used.
+// Failing that, when names are normalized (see MatchNames), the
+// latest version of a type with the same normalized name is used.
+func (m *nameMatcher) match(name jsontypes.TypeName) jsontypes.TypeName {
+	if m.info.Types[name] != nil {
+		m.matched[name] = true
+		return name
+	}
+	name = m.renamed(name)
+	if m.info.Types[name] != nil {
+		m.matched[name] = true
+		return name
+	}
+	candidates := m.versions[name.WithoutVersions()]
+	if len(candidates) == 0 && m.normalize != nil {
+		candidates = m.normalized[m.normalize(name)]
+	}
+	var best jsontypes.TypeName
+	for _, name1 := range candidates {
+		if best == "" || semver.Compare(name1.Version(), best.Version()) > 0 {
+			best = name1
+		}
+	}
+	if best != "" {
+		m.matched[best] = true
+	}
+	return best
+}
+
+// removalAllowed reports whether the given type may be
+// removed without breaking the compatibility rules.
+func (o *checkOptions) removalAllowed(t *jsontypes.Type) bool {
+	if t.Ignored {
+		return true
+	}
+	return o.removalAllowedFor(t.Name, t.StabilityOf(), t.Doc)
+}
+
+// removalAllowedFor reports whether the named item with the
+// given stability and doc comment may be removed without
+// breaking the compatibility rules.
+func (o *checkOptions) removalAllowedFor(name jsontypes.TypeName, st jsontypes.Stability, doc string) bool {
+	switch st {
+	case jsontypes.StabilityExperimental, jsontypes.StabilityBeta:
+		return true
+	}
+	if o.deprecation != nil {
+		return jsontypes.IsDeprecated(doc) && o.deprecation.deprecatedType(name)
+	}
+	return false
+}
+
+// checkFuncs checks all the package-level functions in info1
+// against those in info0, adding the results to r.
+func (o *checkOptions) checkFuncs(r *Report, info0, info1 *jsontypes.Info, opts []CheckOption) {
+	names := make([]jsontypes.TypeName, 0, len(info0.Funcs))
+	for name := range info0.Funcs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		f0, f1 := info0.Funcs[name], info1.Funcs[name]
+		if o.introducedLater(f0.Doc) {
+			continue
+		}
+		if f1 == nil {
+			f1 = info1.Funcs[o.renamed(name)]
+		}
+		if f1 == nil {
+			if !o.removalAllowedFor(name, f0.StabilityOf(), f0.Doc) && o.enabled(ruleFuncRemoved, jsontypes.NoRole, jsontypes.StabilityUnknown) {
+				r.RemovedFuncs = append(r.RemovedFuncs, name)
+			}
+			continue
+		}
+		if f0.StabilityOf() == jsontypes.StabilityExperimental {
+			continue
+		}
+		if err := Check(info0, info1, f0.Type, f1.Type, nil, opts...); err != nil {
+			r.Incompatible = append(r.Incompatible, &TypeReport{
+				Name:   name,
+				Errors: err.(*CheckError).Errors,
+			})
+		}
+	}
+}
+
+func sortedNames(info *jsontypes.Info) []jsontypes.TypeName {
+	names := make([]jsontypes.TypeName, 0, len(info.Types))
+	for name := range info.Types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,113 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// RouteReport holds the incompatibilities found
+// in an HTTP route.
+type RouteReport struct {
+	Method string
+	Path   string
+	Errors []error
+}
+
+// CheckRoute checks that the HTTP route r1 (from info1) is
+// backwardly compatible with r0 (from info0). The request body
+// and query parameter types are checked as requests and the
+// response body type as a response; a route that starts to
+// require a request body or stops returning a response body is
+// also incompatible. Changes to the names or patterns of path
+// parameters are reported too. The options are as for Check.
+func CheckRoute(info0, info1 *jsontypes.Info, r0, r1 *jsontypes.Route, opts ...CheckOption) error {
+	ctxt := checkContext{
+		checkOptions: newCheckOptions(opts),
+		info0:        info0,
+		info1:        info1,
+		checked:      make(map[checkKey]bool),
+	}
+	defer ctxt.setStability(r0.Stability)()
+	if p0, p1 := r0.PathParams(), r1.PathParams(); strings.Join(p0, "/") != strings.Join(p1, "/") {
+		ctxt.errorf(rulePathParamChanged, nil, "path parameters changed from %s to %s", formatParams(p0), formatParams(p1))
+	}
+	ctxt.checkBody(r0.Request, r1.Request, jsontypes.RoleRequest, Path{{Kind: PathParam}}, "request body")
+	ctxt.checkBody(r0.Response, r1.Response, jsontypes.RoleResponse, Path{{Kind: PathResult}}, "response body")
+	for _, name := range sortedQueryParams(r0) {
+		if t1 := r1.Query[name]; t1 != nil {
+			ctxt.checkBody(r0.Query[name], t1, jsontypes.RoleRequest, Path{{Kind: PathField, Name: "?" + name}}, "query parameter")
+		}
+	}
+	if len(ctxt.errors) > 0 {
+		return &CheckError{
+			Errors: ctxt.errors,
+		}
+	}
+	return nil
+}
+
+// checkBody checks a request or response body type,
+// using the given role for types without one.
+func (ctxt *checkContext) checkBody(t0, t1 *jsontypes.Type, role jsontypes.Role, path Path, what string) {
+	switch {
+	case t0 == nil && t1 == nil:
+		return
+	case t0 == nil:
+		if role == jsontypes.RoleRequest {
+			ctxt.errorf(ruleBodyChanged, path, "%s added", what)
+		}
+		return
+	case t1 == nil:
+		if role == jsontypes.RoleResponse {
+			ctxt.errorf(ruleBodyChanged, path, "%s removed", what)
+		}
+		return
+	}
+	defer func(old jsontypes.Role) {
+		ctxt.role = old
+	}(ctxt.role)
+	ctxt.role = role
+	ctxt.check(t0, t1, path)
+}
+
+// checkRoutes checks all the routes in info1 against
+// those in info0, adding the results to r.
+func (o *checkOptions) checkRoutes(r *Report, info0, info1 *jsontypes.Info, opts []CheckOption) {
+	for _, r0 := range info0.Routes {
+		r1 := info1.Route(r0.Method, r0.Path)
+		var err error
+		switch {
+		case r1 != nil:
+			err = CheckRoute(info0, info1, r0, r1, opts...)
+		case r0.Stability == jsontypes.StabilityExperimental || jsontypes.StabilityFromDoc(r0.Doc) == jsontypes.StabilityExperimental:
+		default:
+			err = o.facadeError(ruleRouteRemoved, "endpoint has been removed")
+		}
+		if err != nil {
+			r.Routes = append(r.Routes, &RouteReport{
+				Method: r0.Method,
+				Path:   r0.Path,
+				Errors: err.(*CheckError).Errors,
+			})
+		}
+	}
+}
+
+func formatParams(params []string) string {
+	if len(params) == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("{%s}", strings.Join(params, "}, {"))
+}
+
+func sortedQueryParams(r *jsontypes.Route) []string {
+	names := make([]string, 0, len(r.Query))
+	for name := range r.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
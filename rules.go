@@ -0,0 +1,690 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// Identifiers for the rules applied by Check and CheckAll.
+// See the registry below for their descriptions.
+const (
+	ruleNilType            = "nil-type"
+	ruleCustom             = "custom"
+	ruleKindChanged        = "kind-changed"
+	ruleBecameNullable     = "became-nullable"
+	ruleBecameNonNull      = "became-non-nullable"
+	ruleLengthChanged      = "length-changed"
+	ruleMapStruct          = "map-struct-changed"
+	ruleBytesString        = "bytes-string-changed"
+	ruleMapKeyEncoding     = "map-key-encoding-changed"
+	ruleParamCount         = "param-count-changed"
+	ruleResultCount        = "result-count-changed"
+	ruleVariadicChanged    = "variadic-changed"
+	ruleFieldRemoved       = "field-removed"
+	ruleFieldAdded         = "field-added"
+	ruleRequiredAdded      = "required-field-added"
+	ruleTagChanged         = "tag-changed"
+	ruleTagNameChanged     = "tag-name-changed"
+	ruleOmitEmptyAdded     = "omitempty-added"
+	ruleDefaultChanged     = "default-changed"
+	ruleMethodRemoved      = "method-removed"
+	ruleMethodAdded        = "method-added"
+	ruleReceiverChanged    = "receiver-changed"
+	ruleReceiverToValue    = "receiver-changed-to-value"
+	ruleBecameSealed       = "interface-sealed"
+	ruleBecameUnsealed     = "interface-unsealed"
+	ruleTypeSetNarrowed    = "type-set-narrowed"
+	ruleTypeParamCount     = "type-param-count-changed"
+	ruleConstraintNarrowed = "constraint-narrowed"
+	ruleTypeRemoved        = "type-removed"
+	ruleTypeAdded          = "type-added"
+	ruleFuncRemoved        = "func-removed"
+	ruleVarRemoved         = "var-removed"
+	ruleConstRemoved       = "const-removed"
+	ruleVarTypeChanged     = "var-type-changed"
+	ruleConstTypeChanged   = "const-type-changed"
+	ruleConstValueChanged  = "const-value-changed"
+	ruleEnumValueChanged   = "enum-value-changed"
+	ruleEnumValueRemoved   = "enum-value-removed"
+
+	// ruleBecameConditional is used when an unconditional
+	// declaration becomes platform-specific.
+	ruleBecameConditional = "became-conditional"
+
+	// ruleInterfaceLost is used when a type stops implementing
+	// a well-known interface (see jsontypes.WellKnownInterfaces).
+	ruleInterfaceLost = "interface-lost"
+
+	// Rules applied to RPC facades.
+	ruleFacadeVersionRemoved = "facade-version-removed"
+	ruleFacadeMethodRemoved  = "facade-method-removed"
+	ruleFacadeSignature      = "facade-signature-changed"
+
+	// Rules applied to gRPC services.
+	ruleServiceRemoved       = "service-removed"
+	ruleServiceMethodRemoved = "service-method-removed"
+	ruleStreamingChanged     = "streaming-changed"
+	ruleMessageTypeChanged   = "message-type-changed"
+
+	// Rules applied to HTTP routes.
+	ruleRouteRemoved     = "route-removed"
+	rulePathParamChanged = "path-param-changed"
+	ruleBodyChanged      = "body-changed"
+
+	// Rules for changes between numeric types.
+	ruleNumberNarrowed       = "number-narrowed"
+	ruleNumberRepresentation = "number-representation-changed"
+	ruleJSUnsafeInteger      = "js-unsafe-integer"
+
+	// ruleNotDeprecated is used in place of ruleFieldRemoved
+	// and ruleMethodRemoved when the RequireDeprecation
+	// option is in effect.
+	ruleNotDeprecated = "removed-without-deprecation"
+
+	// Rules applied to database models by ORMProfile.
+	ruleColumnRemoved       = "column-removed"
+	ruleColumnTypeChanged   = "column-type-changed"
+	rulePrimaryKeyChanged   = "primary-key-changed"
+	ruleNotNullAdded        = "not-null-added"
+	ruleUniqueAdded         = "unique-added"
+	ruleRequiredColumnAdded = "required-column-added"
+
+	// Rules applied to positionally encoded structs
+	// by PositionalProfile.
+	ruleFieldMoved    = "field-moved"
+	ruleFieldInserted = "field-inserted"
+
+	// ruleFieldReordered is applied by FieldOrderProfile.
+	ruleFieldReordered = "field-reordered"
+
+	// Rules applied by the go-source profile (see Profile).
+	ruleComparabilityLost = "comparability-lost"
+	ruleInterfaceWidened  = "interface-method-added"
+
+	// ruleOpaqueChanged is used when an opaque type
+	// (see Opaque) has changed to a different type.
+	ruleOpaqueChanged = "opaque-type-changed"
+
+	// Rules applied to a single snapshot by Lint.
+	ruleEncodingNameMismatch = "encoding-name-mismatch"
+	ruleOmitEmptyMismatch    = "omitempty-mismatch"
+)
+
+// ormRules holds the rules enabled by ORMProfile.
+var ormRules = []string{
+	ruleColumnRemoved,
+	ruleColumnTypeChanged,
+	rulePrimaryKeyChanged,
+	ruleNotNullAdded,
+	ruleUniqueAdded,
+	ruleRequiredColumnAdded,
+}
+
+// positionalRules holds the rules enabled by PositionalProfile.
+var positionalRules = []string{
+	ruleFieldMoved,
+	ruleFieldInserted,
+}
+
+// Severity describes how serious a change is.
+type Severity string
+
+const (
+	// Breaking is used for changes that are
+	// likely to break existing users.
+	Breaking Severity = "breaking"
+
+	// Warning is used for changes that may break
+	// some users or that deserve attention.
+	Warning Severity = "warning"
+
+	// Additive is used for compatible changes
+	// that add to the API.
+	Additive Severity = "additive"
+)
+
+// severityLevels holds the severities in
+// increasing order of seriousness.
+var severityLevels = []Severity{Additive, Warning, Breaking}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range severityLevels {
+		if string(sev) == s {
+			return sev, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// AtLeast reports whether s is at least as serious as min.
+// Unknown severities are treated as breaking.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.level() >= min.level()
+}
+
+func (s Severity) level() int {
+	for i, sev := range severityLevels {
+		if sev == s {
+			return i
+		}
+	}
+	return len(severityLevels) - 1
+}
+
+// RuleInfo describes one of the rules applied
+// by the checker.
+type RuleInfo struct {
+	// ID holds the identifier of the rule, as used
+	// in configuration and suppression files.
+	ID string
+
+	// Description holds a description of the rule.
+	Description string
+
+	// Severity holds the default severity of changes
+	// reported by the rule.
+	Severity Severity
+
+	// Optional holds whether the rule is only applied
+	// when enabled, for example by the role of a type.
+	Optional bool `json:",omitempty"`
+
+	// Example holds an example of a change reported by
+	// the rule, showing the old and new declarations.
+	Example string `json:",omitempty"`
+}
+
+var ruleRegistry = []*RuleInfo{{
+	ID:          ruleNilType,
+	Description: "A type in one of the APIs is missing (nil). This indicates a malformed API snapshot.",
+	Severity:    Breaking,
+}, {
+	ID:          ruleCustom,
+	Description: "A custom comparator (see CompareType and CompareKind) reported an incompatibility.",
+	Severity:    Breaking,
+}, {
+	ID:          ruleKindChanged,
+	Description: "A type has changed to a different kind of type, for example from a struct to a string.",
+	Severity:    Breaking,
+	Example: `old: type T struct{ ... }
+new: type T string`,
+}, {
+	ID:          ruleBecameNullable,
+	Description: "A value type has changed to a pointer to the same type, so it may now be encoded as null, which old clients might not expect. This is not reported for request types, which can still decode the same data.",
+	Severity:    Warning,
+	Example:     "old: Count int\nnew: Count *int",
+}, {
+	ID:          ruleBecameNonNull,
+	Description: "A pointer type has changed to its element type, so null can no longer be distinguished from the zero value and may be silently ignored when decoding. This is not reported for response types, as old clients can decode the non-null values.",
+	Severity:    Breaking,
+	Example:     "old: Count *int\nnew: Count int",
+}, {
+	ID:          ruleLengthChanged,
+	Description: "A slice has changed to an array or vice versa, or the length of an array has changed. The JSON encoding is the same shape, but the number of elements allowed has changed: excess elements are dropped when decoding into an array, and missing ones are zeroed.",
+	Severity:    Breaking,
+	Example:     "old: Coords []float64\nnew: Coords [3]float64",
+}, {
+	ID:          ruleMapStruct,
+	Description: "A map with string keys has changed to a struct whose fields all have the map's element type, or vice versa. The JSON encoding stays compatible as long as the map keys used match the struct's field names. Applied only with LenientMapStruct; otherwise the change is reported as a change of kind.",
+	Severity:    Warning,
+	Optional:    true,
+	Example:     "old: Limits map[string]int\nnew: Limits struct{ CPU int `json:\"cpu\"`; Memory int `json:\"memory\"` }",
+}, {
+	ID:          ruleBytesString,
+	Description: "A string has changed to a byte slice or vice versa. Although both hold text, encoding/json encodes byte slices as base64, so the encodings are not compatible.",
+	Severity:    Breaking,
+	Example:     "old: Data string\nnew: Data []byte",
+}, {
+	ID:          ruleNumberNarrowed,
+	Description: "A numeric type has changed to one that cannot represent all of its values, such as a smaller integer or floating point type, or between signed and unsigned integers.",
+	Severity:    Breaking,
+	Example:     "old: Size int64\nnew: Size int32",
+}, {
+	ID:          ruleNumberRepresentation,
+	Description: "A numeric type has changed between an integer and a floating point type. Integers may lose precision as floating point numbers, and fractional values cannot be decoded into integers. This is reported even with LenientNumbers.",
+	Severity:    Breaking,
+	Example:     "old: Price int\nnew: Price float64",
+}, {
+	ID:          ruleJSUnsafeInteger,
+	Description: "A 64-bit integer field is encoded as a JSON number rather than a string, either because it lacks the \",string\" JSON tag option or because that option has been removed. JavaScript clients cannot represent all such values exactly. Applied only with JSSafeIntegers.",
+	Severity:    Warning,
+	Optional:    true,
+	Example:     "old: ID int64 `json:\"id,string\"`\nnew: ID int64 `json:\"id\"`",
+}, {
+	ID:          ruleMapKeyEncoding,
+	Description: "The key type of a map has changed in a way that changes how encoding/json encodes the keys: between strings, integers, types that implement encoding.TextMarshaler, and types that cannot be used as JSON object keys at all.",
+	Severity:    Breaking,
+	Example:     "old: Counts map[string]int\nnew: Counts map[Point]int",
+}, {
+	ID:          ruleParamCount,
+	Description: "The number of parameters of a function or method has changed.",
+	Severity:    Breaking,
+	Example: `old: func (T) M(a int)
+new: func (T) M(a int, b string)`,
+}, {
+	ID:          ruleResultCount,
+	Description: "The number of results of a function or method has changed.",
+	Severity:    Breaking,
+	Example: `old: func (T) M() int
+new: func (T) M() (int, error)`,
+}, {
+	ID:          ruleVariadicChanged,
+	Description: "A function or method has changed between variadic and non-variadic.",
+	Severity:    Breaking,
+	Example: `old: func (T) M(a []int)
+new: func (T) M(a ...int)`,
+}, {
+	ID:          ruleFieldRemoved,
+	Description: "A struct field has been removed. Not applied to request types, because decoders ignore unknown fields.",
+	Severity:    Breaking,
+	Example: `old: type T struct{ A, B int }
+new: type T struct{ A int }`,
+}, {
+	ID:          ruleFieldAdded,
+	Description: "A struct field has been added. Applied only to stored types, which older code may need to read.",
+	Severity:    Warning,
+	Optional:    true,
+	Example: `old: type T struct{ A int }
+new: type T struct{ A, B int }`,
+}, {
+	ID:          ruleRequiredAdded,
+	Description: "A field that appears to be required has been added to a request type, so requests from old clients, which do not supply it, may be rejected. A field is taken to be required if it has a \"required\" validation tag, or if it is not a pointer and its JSON tag lacks the omitempty option. Applied only to request types.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: (no field Region)\nnew: Region string `json:\"region\" validate:\"required\"`",
+}, {
+	ID:          ruleTagChanged,
+	Description: "A struct tag value has changed or been removed, which may change how the field is encoded. For encoding tags, such as json, that hold a name followed by options, the options are compared irrespective of order, and this applies only to options other than omitempty being added or removed.",
+	Severity:    Breaking,
+	Example:     "old: A int `json:\"a\"`\nnew: A int `json:\"a,string\"`",
+}, {
+	ID:          ruleTagNameChanged,
+	Description: "The name under which a struct field is encoded, as given by an encoding tag such as json or defaulting to the field name, has changed, so existing data no longer matches it.",
+	Severity:    Breaking,
+	Example:     "old: A int `json:\"a\"`\nnew: A int `json:\"b\"`",
+}, {
+	ID:          ruleOmitEmptyAdded,
+	Description: "The omitempty option has been added to an encoding tag, so the field is no longer present in the encoded form when it has its zero value. Readers that expect it to be present may need to treat its absence as the zero value.",
+	Severity:    Warning,
+	Example:     "old: A int `json:\"a\"`\nnew: A int `json:\"a,omitempty\"`",
+}, {
+	ID:          ruleDefaultChanged,
+	Description: "The default value of a field, as given by its \"default\" struct tag, has changed or been removed, which silently changes the behavior for clients that omit the field.",
+	Severity:    Breaking,
+	Example:     "old: Retries int `default:\"3\"`\nnew: Retries int `default:\"5\"`",
+}, {
+	ID:          ruleMethodRemoved,
+	Description: "A method has been removed.",
+	Severity:    Breaking,
+	Example: `old: func (T) String() string
+new: (no String method)`,
+}, {
+	ID:          ruleMethodAdded,
+	Description: "A method has been added. Reported only with ReportAdditions, as are added fields.",
+	Severity:    Additive,
+	Example: `old: (no String method)
+new: func (T) String() string`,
+}, {
+	ID:          ruleReceiverChanged,
+	Description: "A method has changed from a value receiver to a pointer receiver, so it is no longer in the method set of the value type.",
+	Severity:    Breaking,
+	Example: `old: func (T) M()
+new: func (*T) M()`,
+}, {
+	ID:          ruleReceiverToValue,
+	Description: "A method has changed from a pointer receiver to a value receiver. The method is still in the method set of the pointer type, but code that relies on the method mutating its receiver, or on the value type not satisfying an interface, may be affected. Applied only when enabled with CheckReceivers.",
+	Severity:    Warning,
+	Optional:    true,
+	Example: `old: func (*T) M()
+new: func (T) M()`,
+}, {
+	ID:          ruleBecameSealed,
+	Description: "An interface has gained an unexported method, so types outside its package that implemented it no longer do.",
+	Severity:    Breaking,
+	Example:     "old: type I interface{ M() }\nnew: type I interface{ M(); m() }",
+}, {
+	ID:          ruleBecameUnsealed,
+	Description: "An interface has lost all its unexported methods, so it can now be implemented outside its package. Code that assumed it knew all the implementations, for example in type switches, may need to handle others.",
+	Severity:    Warning,
+	Example:     "old: type I interface{ M(); m() }\nnew: type I interface{ M() }",
+}, {
+	ID:          ruleTypeSetNarrowed,
+	Description: "The type set of a constraint interface no longer includes some types that it used to, so generic code instantiated with those types no longer compiles.",
+	Severity:    Breaking,
+	Example:     "old: type Number interface{ ~int | ~float64 }\nnew: type Number interface{ ~int }",
+}, {
+	ID:          ruleTypeParamCount,
+	Description: "The number of type parameters of a generic type has changed, so existing instantiations of it no longer compile.",
+	Severity:    Breaking,
+	Example:     "old: type List[T any] struct{}\nnew: type List[T any, A Allocator] struct{}",
+}, {
+	ID:          ruleConstraintNarrowed,
+	Description: "The constraint on a type parameter of a generic type has become stricter, by requiring more methods, restricting its type set or requiring comparable types, so some existing instantiations may no longer compile.",
+	Severity:    Breaking,
+	Example:     "old: type Set[T any] struct{}\nnew: type Set[T comparable] struct{}",
+}, {
+	ID:          ruleOpaqueChanged,
+	Description: "A type declared as opaque with Opaque has changed to a different type. Opaque types are compared by name only, so any other type is considered incompatible, even one with the same structure.",
+	Severity:    Breaking,
+	Example:     "old: Created time.Time\nnew: Created mytime.Time",
+}, {
+	ID:          ruleEncodingNameMismatch,
+	Description: "A struct field has tags for more than one encoding (json, yaml or msgpack) that give it different names, so the encodings of the same value disagree. Names may differ as declared with TagNamePolicy. Applied only by Lint, to a single snapshot.",
+	Severity:    Warning,
+	Example:     "Name string `json:\"name\" yaml:\"full_name\"`",
+}, {
+	ID:          ruleOmitEmptyMismatch,
+	Description: "A struct field has tags for more than one encoding (json, yaml or msgpack) that disagree on whether it is omitted when empty. Applied only by Lint, to a single snapshot.",
+	Severity:    Warning,
+	Example:     "Name string `json:\"name,omitempty\" yaml:\"name\"`",
+}, {
+	ID:          ruleTypeRemoved,
+	Description: "A named type has been removed.",
+	Severity:    Breaking,
+	Example: `old: type T struct{}
+new: (no type T)`,
+}, {
+	ID:          ruleTypeAdded,
+	Description: "A named type has been added.",
+	Severity:    Additive,
+	Example: `old: (no type T)
+new: type T struct{}`,
+}, {
+	ID:          ruleFuncRemoved,
+	Description: "A package-level function has been removed.",
+	Severity:    Breaking,
+	Example: `old: func NewClient(addr string) *Client
+new: (no function NewClient)`,
+}, {
+	ID:          ruleVarRemoved,
+	Description: "A package-level variable has been removed.",
+	Severity:    Breaking,
+	Example: `old: var DefaultClient *Client
+new: (no variable DefaultClient)`,
+}, {
+	ID:          ruleConstRemoved,
+	Description: "A package-level constant has been removed.",
+	Severity:    Breaking,
+	Example: `old: const MaxSize = 1024
+new: (no constant MaxSize)`,
+}, {
+	ID:          ruleVarTypeChanged,
+	Description: "The type of a package-level variable has changed. Unlike struct fields, variables must keep exactly the same type, because code may assign to them or pass them where the old type is required.",
+	Severity:    Breaking,
+	Example: `old: var Timeout int
+new: var Timeout time.Duration`,
+}, {
+	ID:          ruleConstTypeChanged,
+	Description: "The type of a package-level constant has changed, or it has changed between typed and untyped, so code that uses it where the old type is required may no longer compile.",
+	Severity:    Breaking,
+	Example: `old: const MaxSize = 1024
+new: const MaxSize int64 = 1024`,
+}, {
+	ID:          ruleConstValueChanged,
+	Description: "The value of a package-level constant has changed. Code compiled against the old version, and data stored or sent using it, will still use the old value.",
+	Severity:    Warning,
+	Example: `old: const MaxSize = 1024
+new: const MaxSize = 2048`,
+}, {
+	ID:          ruleEnumValueChanged,
+	Description: "The value of a constant of an enum type has changed. A type is taken to be an enum type when constants of that type are declared in its package. Such values are usually serialized, as for status codes and enum strings, so old and new code will no longer agree on their meaning.",
+	Severity:    Breaking,
+	Example: `old: const StatusActive Status = "active"
+new: const StatusActive Status = "enabled"`,
+}, {
+	ID:          ruleEnumValueRemoved,
+	Description: "A constant of an enum type has been removed and no remaining constant of that type has its value, so values of that type sent by old code may no longer be understood.",
+	Severity:    Breaking,
+	Example: `old: const StatusPending Status = 2
+new: (no constant with value 2)`,
+}, {
+	ID:          ruleBecameConditional,
+	Description: "A type, field or method that was present on all platforms is now only present under some build constraints or on some platforms, so it has effectively been removed elsewhere.",
+	Severity:    Warning,
+	Example: `old: type Handle uintptr // in handle.go
+new: type Handle uintptr // in handle_windows.go`,
+}, {
+	ID:          ruleComparabilityLost,
+	Description: "A struct or array type that was comparable is no longer comparable, because it now has a field or element of slice, map or function type, so Go code that compares its values with == or uses them as map keys no longer compiles. Applied only with the go-source profile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example: `old: type Key struct{ Name string }
+new: type Key struct{ Name string; Tags []string }`,
+}, {
+	ID:          ruleInterfaceWidened,
+	Description: "A method has been added to an interface, so types outside its package that implemented it no longer do. Interfaces that are sealed, or whose doc comments say they are not for implementation, are exempt. Applied only with the go-source profile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example: `old: type Store interface{ Get(key string) string }
+new: type Store interface{ Get(key string) string; Delete(key string) }`,
+}, {
+	ID:          ruleInterfaceLost,
+	Description: "A type no longer implements a well-known interface such as fmt.Stringer, json.Marshaler or sql.Scanner, which changes how it behaves when printed, encoded or stored.",
+	Severity:    Breaking,
+	Example: `old: func (T) MarshalText() ([]byte, error)
+new: func (*T) MarshalText() ([]byte, error)`,
+}, {
+	ID:          ruleFacadeVersionRemoved,
+	Description: "A version of an RPC facade is no longer served, so clients using that version will fail.",
+	Severity:    Breaking,
+	Example: `old: facade Client versions 1, 2
+new: facade Client version 2`,
+}, {
+	ID:          ruleFacadeMethodRemoved,
+	Description: "A method has been removed from a version of an RPC facade.",
+	Severity:    Breaking,
+	Example: `old: Client v2 FullStatus(StatusParams) (FullStatus, error)
+new: (no method FullStatus in Client v2)`,
+}, {
+	ID:          ruleFacadeSignature,
+	Description: "A method of an RPC facade has started to require parameters or has stopped returning a result.",
+	Severity:    Breaking,
+	Example: `old: Client v2 Status() (FullStatus, error)
+new: Client v2 Status(StatusParams) (FullStatus, error)`,
+}, {
+	ID:          ruleServiceRemoved,
+	Description: "A gRPC service has been removed.",
+	Severity:    Breaking,
+	Example: `old: service Greeter { ... }
+new: (no service Greeter)`,
+}, {
+	ID:          ruleServiceMethodRemoved,
+	Description: "A method has been removed from a gRPC service.",
+	Severity:    Breaking,
+	Example: `old: rpc SayHello(HelloRequest) returns (HelloReply)
+new: (no method SayHello)`,
+}, {
+	ID:          ruleStreamingChanged,
+	Description: "A gRPC method has changed its streaming mode, for example from unary to server streaming.",
+	Severity:    Breaking,
+	Example: `old: rpc List(ListRequest) returns (ListReply)
+new: rpc List(ListRequest) returns (stream ListReply)`,
+}, {
+	ID:          ruleMessageTypeChanged,
+	Description: "A gRPC method has changed its request or response message type. The message is still checked for compatibility, but generated client code will break.",
+	Severity:    Breaking,
+	Example: `old: rpc SayHello(HelloRequest) returns (HelloReply)
+new: rpc SayHello(GreetRequest) returns (HelloReply)`,
+}, {
+	ID:          ruleRouteRemoved,
+	Description: "An HTTP endpoint has been removed, or its path has changed so that existing requests no longer match it.",
+	Severity:    Breaking,
+	Example: `old: GET /users/{id}
+new: (no GET /users/{id})`,
+}, {
+	ID:          rulePathParamChanged,
+	Description: "The names or patterns of the path parameters of an HTTP endpoint have changed, which may change how requests are interpreted.",
+	Severity:    Warning,
+	Example: `old: GET /users/{id:[0-9]+}
+new: GET /users/{name}`,
+}, {
+	ID:          ruleBodyChanged,
+	Description: "An HTTP endpoint has started to require a request body or has stopped returning a response body. Changes to the body types themselves are reported by the usual type rules.",
+	Severity:    Breaking,
+	Example: `old: POST /users/{id}/reset (no request body)
+new: POST /users/{id}/reset (request body ResetRequest)`,
+}, {
+	ID:          ruleNotDeprecated,
+	Description: "An item has been removed without having been marked as deprecated first (or without having been deprecated for long enough). Reported instead of the plain removal rules when deprecation is required.",
+	Severity:    Breaking,
+	Example: `old: A int
+new: (no field A; A was not marked "Deprecated:")`,
+}, {
+	ID:          ruleColumnRemoved,
+	Description: "A database model no longer has a column, so migrating would drop it and its data. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: Email string `gorm:\"column:email\"`\nnew: Email string `gorm:\"column:email_address\"`",
+}, {
+	ID:          ruleColumnTypeChanged,
+	Description: "The SQL type of a database column has changed or its size has been reduced, which may lose data when migrating. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: Name string `gorm:\"size:256\"`\nnew: Name string `gorm:\"size:64\"`",
+}, {
+	ID:          rulePrimaryKeyChanged,
+	Description: "The primary key of a database model has changed. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: ID int `gorm:\"primaryKey\"`\nnew: ID int\n     UUID string `gorm:\"primaryKey\"`",
+}, {
+	ID:          ruleNotNullAdded,
+	Description: "A NOT NULL constraint has been added to an existing database column, so migrating fails if there are null values. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: Name *string\nnew: Name *string `gorm:\"not null\"`",
+}, {
+	ID:          ruleUniqueAdded,
+	Description: "A UNIQUE constraint has been added to an existing database column, so migrating fails if there are duplicate values. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: Email string\nnew: Email string `gorm:\"uniqueIndex\"`",
+}, {
+	ID:          ruleRequiredColumnAdded,
+	Description: "A NOT NULL column without a default has been added to a database model, so migrating fails if there are existing rows. Applied only with ORMProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: (no field Owner)\nnew: Owner string `gorm:\"not null\"`",
+}, {
+	ID:          ruleFieldMoved,
+	Description: "A field of a struct that is encoded as an array has moved to a different position, so old and new code decode each other's values into the wrong fields. Applied only with PositionalProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: struct { A int; B int }\nnew: struct { B int; A int }",
+}, {
+	ID:          ruleFieldInserted,
+	Description: "A field has been added to a struct that is encoded as an array other than at the end, shifting the position of later fields. Applied only with PositionalProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: struct { A int; B int }\nnew: struct { A int; C int; B int }",
+}, {
+	ID:          ruleFieldReordered,
+	Description: "The fields of a struct have been reordered. This does not affect JSON, but does affect encodings that depend on the order in which fields are declared, such as some reflection-based binary codecs. Applied only with FieldOrderProfile.",
+	Severity:    Breaking,
+	Optional:    true,
+	Example:     "old: struct { A int; B int }\nnew: struct { B int; A int }",
+}}
+
+var rulesByID = func() map[string]*RuleInfo {
+	m := make(map[string]*RuleInfo)
+	for _, r := range ruleRegistry {
+		m[r.ID] = r
+	}
+	return m
+}()
+
+// Rules returns information on all the rules
+// known to the checker, sorted by ID.
+func Rules() []*RuleInfo {
+	rules := make([]*RuleInfo, len(ruleRegistry))
+	for i, r := range ruleRegistry {
+		r1 := *r
+		rules[i] = &r1
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// LookupRule returns information on the rule with
+// the given ID, or nil if there is no such rule.
+func LookupRule(id string) *RuleInfo {
+	r := rulesByID[id]
+	if r == nil {
+		return nil
+	}
+	r1 := *r
+	return &r1
+}
+
+// ruleSeverity returns the default severity of
+// the rule with the given ID, or Breaking if
+// the rule is unknown.
+func ruleSeverity(id string) Severity {
+	if r := rulesByID[id]; r != nil {
+		return r.Severity
+	}
+	return Breaking
+}
+
+// roleRules holds the rules that are enabled (true) or
+// disabled (false) within types of a given role, overriding
+// the defaults.
+var roleRules = map[jsontypes.Role]map[string]bool{
+	// Requests are decoded by the new code from data encoded
+	// by old clients, so fields can be removed because
+	// the decoder will ignore them.
+	jsontypes.RoleRequest: {
+		ruleFieldRemoved:   false,
+		ruleBecameNullable: false,
+		ruleRequiredAdded:  true,
+	},
+	// Responses are decoded by old clients, which can
+	// decode a value where they allowed null.
+	jsontypes.RoleResponse: {
+		ruleBecameNonNull: false,
+	},
+	// Stored data must be readable by old code (for example
+	// after a rollback) as well as new code, so added fields
+	// are a concern too.
+	jsontypes.RoleStored: {
+		ruleFieldAdded: true,
+	},
+}
+
+// stabilityRules holds the rules that are disabled for
+// items at a given stability level. No rules at all
+// apply to experimental items.
+var stabilityRules = map[jsontypes.Stability]map[string]bool{
+	jsontypes.StabilityBeta: {
+		ruleFieldRemoved:  false,
+		ruleMethodRemoved: false,
+	},
+}
+
+// enabled reports whether the given rule applies
+// within a type with the given role and stability.
+// Rules explicitly enabled or disabled by options
+// take precedence over the role and stability.
+func (o *checkOptions) enabled(rule string, role jsontypes.Role, stability jsontypes.Stability) bool {
+	if stability == jsontypes.StabilityExperimental {
+		return false
+	}
+	if on, ok := o.rules[rule]; ok {
+		return on
+	}
+	if on, ok := stabilityRules[stability][rule]; ok && !on {
+		return false
+	}
+	if on, ok := roleRules[role][rule]; ok && !o.noRoles {
+		return on
+	}
+	if r := rulesByID[rule]; r != nil {
+		return !r.Optional
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package apicompat
+
+import (
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// ServiceReport holds the incompatibilities
+// found in a gRPC service.
+type ServiceReport struct {
+	Name   string
+	Errors []error
+}
+
+// CheckService checks that the gRPC service s1 (from info1) is
+// backwardly compatible with s0 (from info0). All the methods
+// of s0 must be present in s1 with the same streaming mode
+// and the same request and response message types. The message
+// types are also checked as for Check, with request messages
+// checked as requests and response messages as responses.
+// The options are as for Check.
+func CheckService(info0, info1 *jsontypes.Info, s0, s1 *jsontypes.Service, opts ...CheckOption) error {
+	ctxt := checkContext{
+		checkOptions: newCheckOptions(opts),
+		info0:        info0,
+		info1:        info1,
+		checked:      make(map[checkKey]bool),
+	}
+	for _, name := range sortedServiceMethods(s0) {
+		m0, m1 := s0.Methods[name], s1.Methods[name]
+		path := Path{{Kind: PathMethod, Name: name}}
+		restore := ctxt.setStability(m0.Stability)
+		if m1 == nil {
+			if !jsontypes.IsDeprecated(m0.Doc) || ctxt.deprecation == nil {
+				ctxt.errorf(ruleServiceMethodRemoved, path, "method %s is missing", name)
+			}
+			restore()
+			continue
+		}
+		if k0, k1 := m0.StreamingKind(), m1.StreamingKind(); k0 != k1 {
+			ctxt.errorf(ruleStreamingChanged, path, "method %s has changed from %s to %s", name, k0, k1)
+		}
+		ctxt.checkMessage(m0.Request, m1.Request, jsontypes.RoleRequest, path.with(PathElem{Kind: PathParam}))
+		ctxt.checkMessage(m0.Response, m1.Response, jsontypes.RoleResponse, path.with(PathElem{Kind: PathResult}))
+		restore()
+	}
+	if len(ctxt.errors) > 0 {
+		return &CheckError{
+			Errors: ctxt.errors,
+		}
+	}
+	return nil
+}
+
+// checkMessage checks the request or response message
+// type of a gRPC method, using the given role for types
+// without one.
+func (ctxt *checkContext) checkMessage(t0, t1 *jsontypes.Type, role jsontypes.Role, path Path) {
+	if t0.Name != t1.Name && t0.Name.WithoutVersions() != t1.Name.WithoutVersions() {
+		ctxt.errorf(ruleMessageTypeChanged, path, "message type changed from %s to %s", jsontypes.Format(ctxt.info0, t0), jsontypes.Format(ctxt.info1, t1))
+	}
+	defer func(old jsontypes.Role) {
+		ctxt.role = old
+	}(ctxt.role)
+	ctxt.role = role
+	ctxt.check(t0, t1, path)
+}
+
+// checkServices checks all the services in info1 against
+// those in info0, adding the results to r.
+func (o *checkOptions) checkServices(r *Report, info0, info1 *jsontypes.Info, opts []CheckOption) {
+	for _, s0 := range info0.Services {
+		s1 := info1.Service(s0.Name)
+		var err error
+		switch {
+		case s1 != nil:
+			err = CheckService(info0, info1, s0, s1, opts...)
+		case jsontypes.StabilityFromDoc(s0.Doc) == jsontypes.StabilityExperimental:
+		default:
+			err = o.facadeError(ruleServiceRemoved, "service has been removed")
+		}
+		if err != nil {
+			r.Services = append(r.Services, &ServiceReport{
+				Name:   s0.Name,
+				Errors: err.(*CheckError).Errors,
+			})
+		}
+	}
+}
+
+func sortedServiceMethods(s *jsontypes.Service) []string {
+	names := make([]string, 0, len(s.Methods))
+	for name := range s.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
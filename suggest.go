@@ -0,0 +1,79 @@
+package apicompat
+
+import "fmt"
+
+// ruleSuggestions holds functions that return a suggested
+// remediation for problems found by each rule.
+var ruleSuggestions = map[string]func(p *Problem) string{
+	ruleFieldRemoved:  reintroduce("field"),
+	ruleMethodRemoved: reintroduce("method"),
+	ruleNotDeprecated: func(p *Problem) string {
+		return "reintroduce it, marked as deprecated, and remove it in a later version" + declSuffix(p.OldDecl)
+	},
+	ruleTagChanged: func(p *Problem) string {
+		return "restore the old tag, and add a new field if the new encoding is needed" + declSuffix(p.OldDecl)
+	},
+	ruleKindChanged:          preserveEncoding,
+	ruleBytesString:          preserveEncoding,
+	ruleNumberRepresentation: preserveEncoding,
+	ruleNumberNarrowed:       fixed("keep the wider type, validating the range of values where needed"),
+	ruleBecameNonNull:        fixed("keep the pointer type so that null can still be distinguished from the zero value"),
+	ruleReceiverChanged:      fixed("keep the value receiver, or add a new method with a pointer receiver"),
+	ruleRequiredAdded:        fixed("make the field optional with a pointer type or the omitempty option, and use a default value when it is missing"),
+	ruleJSUnsafeInteger:      fixed(`add the ",string" option to the field's json tag`),
+	ruleDefaultChanged:       fixed("restore the old default, and add a new field if a different default is needed"),
+	ruleVarTypeChanged:       fixed("keep the old type, and add a new variable if a different type is needed"),
+	ruleConstTypeChanged:     fixed("keep the old type, and add a new constant if a different type is needed"),
+	ruleConstValueChanged:    fixed("restore the old value, and add a new constant if a different value is needed"),
+	ruleEnumValueChanged:     fixed("restore the old value, and add a new constant if a different value is needed"),
+	ruleEnumValueRemoved:     fixed("keep a constant with the old value, deprecating it if it should no longer be used"),
+	ruleTypeParamCount:       fixed("keep the existing type parameters, and define a new generic type if more are needed"),
+	ruleConstraintNarrowed:   fixed("restore the old constraint, and define a new generic type if a stricter one is needed"),
+	ruleBecameSealed:         fixed("define a new sealed interface rather than changing the existing one"),
+	ruleTypeSetNarrowed:      fixed("define a new constraint rather than restricting the existing one"),
+	ruleOpaqueChanged:        fixed("keep the old type, or add a new field with the new type"),
+	ruleEncodingNameMismatch: fixed("use the same name in all the field's encoding tags"),
+	ruleOmitEmptyMismatch:    fixed("use the omitempty option in all or none of the field's encoding tags"),
+	ruleMapKeyEncoding:       fixed("implement encoding.TextMarshaler and encoding.TextUnmarshaler on the key type to preserve the old key encoding"),
+	ruleFieldMoved:           fixed("keep existing fields in their original order"),
+	ruleFieldInserted:        fixed("add new fields at the end of the struct"),
+	ruleFieldReordered:       fixed("keep existing fields in their original order"),
+}
+
+// suggestion returns a suggested remediation for p,
+// or the empty string if there is none.
+func suggestion(p *Problem) string {
+	if f := ruleSuggestions[p.Rule]; f != nil {
+		return f(p)
+	}
+	return ""
+}
+
+// reintroduce returns a suggestion function for
+// removed items of the given kind.
+func reintroduce(what string) func(p *Problem) string {
+	return func(p *Problem) string {
+		return fmt.Sprintf("reintroduce the %s, marked as deprecated", what) + declSuffix(p.OldDecl)
+	}
+}
+
+// fixed returns a suggestion function that
+// always returns s.
+func fixed(s string) func(p *Problem) string {
+	return func(*Problem) string {
+		return s
+	}
+}
+
+func preserveEncoding(p *Problem) string {
+	return "add MarshalJSON and UnmarshalJSON methods that preserve the old encoding, or keep the old field and add a new one with the new type"
+}
+
+// declSuffix returns the given declaration formatted
+// for appending to a suggestion.
+func declSuffix(decl string) string {
+	if decl == "" {
+		return ""
+	}
+	return ": " + decl
+}
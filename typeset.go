@@ -0,0 +1,62 @@
+package apicompat
+
+import "github.com/rogpeppe/apicompat/jsontypes"
+
+// checkTypeSet checks that the type set of the interface t1
+// includes all the types in the type set of t0, as restricted
+// by their unions (see jsontypes.Type.Terms).
+func (ctxt *checkContext) checkTypeSet(t0, t1 *jsontypes.Type, path Path) {
+	if len(t1.Terms) == 0 {
+		return
+	}
+	if len(t0.Terms) == 0 {
+		ctxt.errorf(ruleTypeSetNarrowed, path, "type set has been restricted to %s", ctxt.formatTerms(ctxt.info1, t1.Terms))
+		return
+	}
+	for _, term0 := range t0.Terms {
+		if !ctxt.termIncluded(term0, t1.Terms) {
+			ctxt.errorf(ruleTypeSetNarrowed, path, "type set no longer includes %s", ctxt.formatTerms(ctxt.info0, []*jsontypes.Term{term0}))
+		}
+	}
+}
+
+// termIncluded reports whether all the types in the old term
+// are included in one of the new terms.
+func (ctxt *checkContext) termIncluded(term0 *jsontypes.Term, terms1 []*jsontypes.Term) bool {
+	for _, term1 := range terms1 {
+		switch {
+		case term1.Tilde:
+			// ~T includes T itself and all types with underlying
+			// type T, whether or not term0 is a tilde term.
+			if jsontypes.TypeEqual(ctxt.info0, underlying(ctxt.info0, term0.Type), ctxt.info1, term1.Type) {
+				return true
+			}
+		case !term0.Tilde:
+			if term0.Type.Name == term1.Type.Name && jsontypes.TypeEqual(ctxt.info0, term0.Type, ctxt.info1, term1.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// underlying returns the underlying type of t.
+func underlying(info *jsontypes.Info, t *jsontypes.Type) *jsontypes.Type {
+	t = info.Deref(t)
+	if t.Name == "" || t.Kind == jsontypes.Interface {
+		return t
+	}
+	u := *t
+	u.Name = ""
+	u.Methods = nil
+	u.Implements = nil
+	return &u
+}
+
+// formatTerms returns the given terms formatted as a union.
+func (ctxt *checkContext) formatTerms(info *jsontypes.Info, terms []*jsontypes.Term) string {
+	return jsontypes.Format(info, &jsontypes.Type{
+		Kind:  jsontypes.Interface,
+		Terms: terms,
+	})
+}
@@ -0,0 +1,181 @@
+package apicompat
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// checkValues checks the package-level variables and constants
+// in info1 against those in info0, adding the results to r.
+// Unlike the types of fields, the types of variables and
+// constants must stay the same, because Go code that uses
+// them, for example by assigning to them or passing them as
+// arguments, depends on their exact types.
+//
+// The values of constants are checked too, more strictly
+// for constants of enum types (see enumValues), whose
+// values are likely to be serialized.
+func (o *checkOptions) checkValues(r *Report, info0, info1 *jsontypes.Info) {
+	ctxt := &checkContext{
+		checkOptions: *o,
+		info0:        info0,
+		info1:        info1,
+	}
+	varNames := make([]jsontypes.TypeName, 0, len(info0.Vars))
+	for name := range info0.Vars {
+		varNames = append(varNames, name)
+	}
+	for _, name := range sortTypeNames(varNames) {
+		v0, v1 := info0.Vars[name], info1.Vars[name]
+		if o.introducedLater(v0.Doc) {
+			continue
+		}
+		if v1 == nil {
+			v1 = info1.Vars[o.renamed(name)]
+		}
+		if v1 == nil {
+			if !o.removalAllowedFor(name, v0.StabilityOf(), v0.Doc) && o.enabled(ruleVarRemoved, jsontypes.NoRole, jsontypes.StabilityUnknown) {
+				r.RemovedVars = append(r.RemovedVars, name)
+			}
+			continue
+		}
+		ctxt.errors = nil
+		ctxt.stability = v0.StabilityOf()
+		ctxt.typeName = name
+		ctxt.decls = func() (string, string) {
+			return valueDecl("var", info0, name, v0.Type), valueDecl("var", info1, v1.Name, v1.Type)
+		}
+		if !o.sameType(info0, v0.Type, info1, v1.Type) {
+			ctxt.errorf(ruleVarTypeChanged, nil, "type changed from %s to %s", jsontypes.Format(info0, v0.Type), jsontypes.Format(info1, v1.Type))
+		}
+		r.addValueReport(name, ctxt.errors)
+	}
+	constNames := make([]jsontypes.TypeName, 0, len(info0.Consts))
+	for name := range info0.Consts {
+		constNames = append(constNames, name)
+	}
+	enums0, enums1 := enumValues(info0), enumValues(info1)
+	for _, name := range sortTypeNames(constNames) {
+		c0, c1 := info0.Consts[name], info1.Consts[name]
+		if o.introducedLater(c0.Doc) {
+			continue
+		}
+		if c1 == nil {
+			c1 = info1.Consts[o.renamed(name)]
+		}
+		ctxt.errors = nil
+		ctxt.stability = c0.StabilityOf()
+		ctxt.typeName = name
+		enum := c0.Type != nil && !c0.Untyped && enums0[c0.Type.Name] != nil
+		if c1 == nil {
+			if o.removalAllowedFor(name, c0.StabilityOf(), c0.Doc) {
+				continue
+			}
+			if o.enabled(ruleConstRemoved, jsontypes.NoRole, jsontypes.StabilityUnknown) {
+				r.RemovedConsts = append(r.RemovedConsts, name)
+			}
+			if enum && c0.Value != "" && !enums1[o.renamed(c0.Type.Name)][c0.Value] {
+				ctxt.decls = func() (string, string) {
+					return constDecl(info0, c0), ""
+				}
+				ctxt.errorf(ruleEnumValueRemoved, nil, "no constant of type %s has value %s any more", c0.Type.Name.Name(), c0.Value)
+				r.addValueReport(name, ctxt.errors)
+			}
+			continue
+		}
+		ctxt.decls = func() (string, string) {
+			return constDecl(info0, c0), constDecl(info1, c1)
+		}
+		switch {
+		case c0.Untyped && !c1.Untyped:
+			ctxt.errorf(ruleConstTypeChanged, nil, "untyped constant now has type %s", jsontypes.Format(info1, c1.Type))
+		case !c0.Untyped && c1.Untyped:
+			ctxt.errorf(ruleConstTypeChanged, nil, "constant of type %s is now untyped", jsontypes.Format(info0, c0.Type))
+		case !c0.Untyped && !o.sameType(info0, c0.Type, info1, c1.Type):
+			ctxt.errorf(ruleConstTypeChanged, nil, "type changed from %s to %s", jsontypes.Format(info0, c0.Type), jsontypes.Format(info1, c1.Type))
+		}
+		if c0.Value != "" && c1.Value != "" && c0.Value != c1.Value {
+			rule := ruleConstValueChanged
+			if enum {
+				rule = ruleEnumValueChanged
+			}
+			ctxt.errorf(rule, nil, "value changed from %s to %s", c0.Value, c1.Value)
+		}
+		r.addValueReport(name, ctxt.errors)
+	}
+}
+
+// addValueReport adds an entry to r.Incompatible for the
+// variable or constant with the given name if there are
+// any errors.
+func (r *Report) addValueReport(name jsontypes.TypeName, errs []error) {
+	if len(errs) > 0 {
+		r.Incompatible = append(r.Incompatible, &TypeReport{
+			Name:   name,
+			Errors: errs,
+		})
+	}
+}
+
+// enumValues returns the values of the constants of each enum
+// type in info, indexed by type name and then by value. A type
+// is taken to be an enum type when it is a named type in info
+// with typed constants declared in the same package.
+func enumValues(info *jsontypes.Info) map[jsontypes.TypeName]map[string]bool {
+	enums := make(map[jsontypes.TypeName]map[string]bool)
+	for name, c := range info.Consts {
+		if c.Type == nil || c.Untyped {
+			continue
+		}
+		tname := c.Type.Name
+		if c.Value == "" || info.Types[tname] == nil || tname.PkgPath() != name.PkgPath() {
+			continue
+		}
+		if enums[tname] == nil {
+			enums[tname] = make(map[string]bool)
+		}
+		enums[tname][c.Value] = true
+	}
+	return enums
+}
+
+// sameType reports whether t0 in info0 and t1 in info1 are the
+// same type, taking into account any package renames.
+func (o *checkOptions) sameType(info0 *jsontypes.Info, t0 *jsontypes.Type, info1 *jsontypes.Info, t1 *jsontypes.Type) bool {
+	if t0 == nil || t1 == nil {
+		return t0 == t1
+	}
+	if t0.Name != "" || t1.Name != "" {
+		return t0.Name == t1.Name || o.renamed(t0.Name) == t1.Name
+	}
+	return jsontypes.TypeEqual(info0, t0, info1, t1)
+}
+
+// valueDecl returns a Go-like rendering of the declaration
+// of the variable or constant with the given name and type.
+func valueDecl(keyword string, info *jsontypes.Info, name jsontypes.TypeName, t *jsontypes.Type) string {
+	return fmt.Sprintf("%s %s %s", keyword, name.Name(), jsontypes.Format(info, t))
+}
+
+// constDecl returns a Go-like rendering of the declaration
+// of the constant c.
+func constDecl(info *jsontypes.Info, c *jsontypes.Const) string {
+	switch {
+	case c.Untyped && c.Value != "":
+		return fmt.Sprintf("const %s = %s", c.Name.Name(), c.Value)
+	case c.Untyped:
+		return fmt.Sprintf("const %s (untyped %s)", c.Name.Name(), jsontypes.Format(info, c.Type))
+	case c.Value != "":
+		return valueDecl("const", info, c.Name, c.Type) + " = " + c.Value
+	}
+	return valueDecl("const", info, c.Name, c.Type)
+}
+
+func sortTypeNames(names []jsontypes.TypeName) []jsontypes.TypeName {
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
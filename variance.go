@@ -0,0 +1,86 @@
+package apicompat
+
+import (
+	"github.com/rogpeppe/apicompat/jsontypes"
+)
+
+// LenientFuncs returns an option that checks function types, such
+// as those of methods and callbacks, according to the variance of
+// their parameters and results rather than requiring each to be
+// compatible in the usual way. A parameter may change to any type
+// that the old parameter type is assignable to, for example from
+// *Foo to interface{} or to an interface that *Foo implements,
+// because all existing arguments remain valid. Similarly, a result
+// may change to any type that is assignable to the old result type.
+// Other changes are checked as usual.
+func LenientFuncs() CheckOption {
+	return func(o *checkOptions) {
+		o.lenientFuncs = true
+	}
+}
+
+// assignable reports whether a value of type from (within
+// infoFrom) can be used where a value of type to (within infoTo)
+// is expected: either the types are identical, or to is an interface
+// type without type terms whose methods are all in the method set
+// of from.
+func assignable(infoFrom *jsontypes.Info, from *jsontypes.Type, infoTo *jsontypes.Info, to *jsontypes.Type) bool {
+	if from == nil || to == nil {
+		return false
+	}
+	if from.Name != "" && to.Name != "" && from.Name != to.Name {
+		return false
+	}
+	from1, to1 := lookupType(infoFrom, from), lookupType(infoTo, to)
+	if from1 == nil || to1 == nil {
+		return false
+	}
+	if (from.Name == "") == (to.Name == "") && jsontypes.TypeEqual(infoFrom, from1, infoTo, to1) {
+		return true
+	}
+	if to1.Kind != jsontypes.Interface || len(to1.Terms) > 0 {
+		return false
+	}
+	methods := methodSet(infoFrom, from1)
+	for name, im := range to1.Methods {
+		m := methods[name]
+		if m == nil || jsontypes.Format(nil, m.Type) != jsontypes.Format(nil, im.Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// methodSet returns the methods that can be called on
+// a value of type t, indexed by name.
+func methodSet(info *jsontypes.Info, t *jsontypes.Type) map[string]*jsontypes.Method {
+	ptr := false
+	if t.Kind == jsontypes.Ptr && t.Name == "" {
+		if t = lookupType(info, t.Elem); t == nil {
+			return nil
+		}
+		ptr = true
+	}
+	methods := make(map[string]*jsontypes.Method)
+	for name, m := range t.Methods {
+		if ptr || !m.PtrReceiver || t.Kind == jsontypes.Interface {
+			methods[name] = m
+		}
+	}
+	return methods
+}
+
+// lookupType returns the definition of t within info,
+// or nil if t refers to a named type that isn't known.
+func lookupType(info *jsontypes.Info, t *jsontypes.Type) *jsontypes.Type {
+	if t == nil {
+		return nil
+	}
+	if dt := info.Types[t.Name]; dt != nil {
+		return dt
+	}
+	if t.Kind == jsontypes.Unknown || t.Kind == "" {
+		return nil
+	}
+	return t
+}